@@ -0,0 +1,81 @@
+package soft_delete
+
+import (
+	"database/sql/driver"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ActiveFlag 与 DeletedAt 相同，但语义相反：true 表示未删除，适用于 is_active 一类的列。
+// 查询过滤 is_active = true，删除时写入 false，恢复时写回 true。
+// 字段的零值 false 表示已删除，没有声明 default 时列默认为 true，插入零值时写入 true
+//
+//	IsActive soft_delete.ActiveFlag
+type ActiveFlag bool
+
+// activeFlagValues 为 ActiveFlag 默认的取值
+func activeFlagValues() flagValues {
+	return flagValues{active: flagValue(true), deleted: flagValue(false)}
+}
+
+func activeFlagFromDeleted(deleted bool) interface{} {
+	return ActiveFlag(!deleted)
+}
+
+// IsDeleted 判断记录是否已删除
+func (a ActiveFlag) IsDeleted() bool {
+	return !bool(a)
+}
+
+// IsActive 判断记录是否未删除
+func (a ActiveFlag) IsActive() bool {
+	return bool(a)
+}
+
+// String 返回 "deleted" 或 "active"
+func (a ActiveFlag) String() string {
+	return stateString(a.IsDeleted())
+}
+
+// MarkDeleted 将标记设为已删除，只修改内存中的值
+func (a *ActiveFlag) MarkDeleted() {
+	*a = false
+}
+
+// MarkActive 将标记设为未删除，只修改内存中的值
+func (a *ActiveFlag) MarkActive() {
+	*a = true
+}
+
+func (ActiveFlag) QueryClauses(f *schema.Field) []clause.Interface {
+	return flagQueryClauses(f, activeFlagValues(), activeFlagFromDeleted)
+}
+
+func (ActiveFlag) UpdateClauses(f *schema.Field) []clause.Interface {
+	return flagUpdateClauses(f)
+}
+
+func (ActiveFlag) DeleteClauses(f *schema.Field) []clause.Interface {
+	return flagDeleteClauses(f, activeFlagValues(), activeFlagFromDeleted)
+}
+
+// 实现 driver.Valuer 接口
+func (a ActiveFlag) Value() (driver.Value, error) {
+	return flagValue(bool(a)), nil
+}
+
+// 实现 sql.Scanner 接口，与 DeletedAt 一样兼容整数、字节和字符串，NULL 视为未删除
+func (a *ActiveFlag) Scan(value interface{}) error {
+	if value == nil {
+		*a = true
+		return nil
+	}
+
+	var b DeletedAt
+	if err := b.Scan(value); err != nil {
+		return err
+	}
+	*a = ActiveFlag(b)
+	return nil
+}
@@ -0,0 +1,81 @@
+package soft_delete
+
+import "testing"
+
+type ActiveUser struct {
+	ID       uint
+	Name     string
+	IsActive ActiveFlag
+}
+
+// ActiveFlag 以 true 表示未删除：插入零值时写入 true，删除写入 false，恢复写回 true
+func TestActiveFlag(t *testing.T) {
+	db := openDB(t, &ActiveUser{})
+	db.Create(&[]ActiveUser{{Name: "a"}, {Name: "b"}})
+
+	var users []ActiveUser
+	db.Find(&users)
+	if len(users) != 2 || users[0].IsActive.IsDeleted() {
+		t.Fatalf("created = %+v", users)
+	}
+
+	user := ActiveUser{ID: 1}
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsActive.IsDeleted() {
+		t.Errorf("in memory after delete = %v", user.IsActive)
+	}
+	var raw []bool
+	db.Table("active_users").Order("id").Pluck("is_active", &raw)
+	if len(raw) != 2 || raw[0] || !raw[1] {
+		t.Errorf("stored = %v, want [false true]", raw)
+	}
+
+	var n int64
+	db.Model(&ActiveUser{}).Count(&n)
+	if n != 1 {
+		t.Errorf("active = %d, want 1", n)
+	}
+	var deleted []ActiveUser
+	db.Scopes(OnlyDeleted).Find(&deleted)
+	if len(deleted) != 1 || deleted[0].ID != 1 || deleted[0].IsActive.String() != "deleted" {
+		t.Errorf("deleted = %+v", deleted)
+	}
+
+	if n, err := Restore(db, &user); n != 1 || err != nil {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	if !user.IsActive.IsActive() {
+		t.Errorf("in memory after restore = %v", user.IsActive)
+	}
+	db.Model(&ActiveUser{}).Count(&n)
+	if n != 2 {
+		t.Errorf("active after restore = %d, want 2", n)
+	}
+}
+
+func TestActiveFlagScan(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		deleted bool
+	}{
+		{nil, false},
+		{true, false},
+		{false, true},
+		{int64(1), false},
+		{int64(0), true},
+		{[]byte("1"), false},
+		{"false", true},
+	}
+	for _, tt := range tests {
+		var a ActiveFlag
+		if err := a.Scan(tt.value); err != nil {
+			t.Errorf("Scan(%v): %v", tt.value, err)
+			continue
+		}
+		if a.IsDeleted() != tt.deleted {
+			t.Errorf("Scan(%v) deleted = %v, want %v", tt.value, a.IsDeleted(), tt.deleted)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package soft_delete
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	archiveCallbackName = "soft_delete:archive"
+	archiveTableSuffix  = "_archive"
+)
+
+// ArchiveStrategy 使模型删除时不修改标记，而是在同一事务中将记录复制到归档表后物理删除，Restore 时移回原表。
+// 原表的索引中不再有已删除的记录；模型中不能有软删除字段
+type ArchiveStrategy struct {
+	Model interface{}
+	// Table 为归档表名，默认为 <表名>_archive。归档表有原表的全部列，以及 deleted_at、deleted_by
+	Table string
+}
+
+// archiveColumns 为归档表在原表之外的列
+type archiveColumns struct {
+	DeletedAt time.Time
+	DeletedBy *string `gorm:"size:255"`
+}
+
+// Archiver 为使用 ArchiveStrategy 的 gorm 插件，OnlyDeleted 查询这些模型时读取归档表：
+//
+//	db.Use(soft_delete.NewArchiver(soft_delete.ArchiveStrategy{Model: &Event{}}))
+//
+// 删除需要 gorm 默认的事务保证复制和删除同时成功，WithDeleted 只查询原表
+type Archiver struct {
+	strategies []ArchiveStrategy
+}
+
+// archiveTables 按模型的 schema 保存归档表名
+var archiveTables sync.Map
+
+func NewArchiver(strategies ...ArchiveStrategy) *Archiver {
+	return &Archiver{strategies: strategies}
+}
+
+func (a *Archiver) Name() string {
+	return archiveCallbackName
+}
+
+func (a *Archiver) Initialize(db *gorm.DB) error {
+	for _, strategy := range a.strategies {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(strategy.Model); err != nil {
+			return err
+		}
+		if _, ok := lookUpDeleteClause(stmt.Schema); ok {
+			return fmt.Errorf("soft_delete: %s has a soft delete field and cannot use ArchiveStrategy", stmt.Schema.Name)
+		}
+		table := strategy.Table
+		if table == "" {
+			table = stmt.Schema.Table + archiveTableSuffix
+		}
+		archiveTables.Store(stmt.Schema, table)
+	}
+
+	if err := db.Callback().Delete().After("gorm:before_delete").Before("gorm:delete").Register(archiveCallbackName, archiveDeleted); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register(archiveCallbackName, queryArchive); err != nil {
+		return err
+	}
+	return db.Callback().Row().Before("gorm:row").Register(archiveCallbackName, queryArchive)
+}
+
+// AutoMigrate 创建各模型的归档表：复制原表的列，加上 deleted_at、deleted_by 和主键索引。原表需要先迁移
+func (a *Archiver) AutoMigrate(db *gorm.DB) error {
+	for _, strategy := range a.strategies {
+		if err := migrateArchive(db, strategy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateArchive(db *gorm.DB, strategy ArchiveStrategy) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(strategy.Model); err != nil {
+		return err
+	}
+	s := stmt.Schema
+	table := strategy.Table
+	if table == "" {
+		table = s.Table + archiveTableSuffix
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true})
+	if !tx.Migrator().HasTable(table) {
+		// 只复制列，不复制索引和约束，归档表中同一唯一值可以有多条记录
+		if err := tx.Exec("CREATE TABLE ? AS SELECT * FROM ? WHERE 1 = 0", clause.Table{Name: table}, clause.Table{Name: s.Table}).Error; err != nil {
+			return err
+		}
+		columns := make([]clause.Column, len(s.PrimaryFieldDBNames))
+		for i, name := range s.PrimaryFieldDBNames {
+			columns[i] = clause.Column{Name: name}
+		}
+		if len(columns) > 0 {
+			if err := tx.Exec("CREATE INDEX ? ON ? ?", clause.Column{Name: "idx_" + table + "_pk"}, clause.Table{Name: table}, columns).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, name := range []string{"DeletedAt", "DeletedBy"} {
+		if migrator := tx.Table(table).Migrator(); !migrator.HasColumn(&archiveColumns{}, name) {
+			if err := migrator.AddColumn(&archiveColumns{}, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func archiveTableOf(s *schema.Schema) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	if table, ok := archiveTables.Load(s); ok {
+		return table.(string), true
+	}
+	return "", false
+}
+
+// archiveDeleted 在 gorm 删除之前，将同样条件匹配的记录复制到归档表
+func archiveDeleted(db *gorm.DB) {
+	stmt := db.Statement
+	table, ok := archiveTableOf(stmt.Schema)
+	if !ok || db.Error != nil || stmt.Unscoped || stmt.DryRun || stmt.SQL.Len() > 0 {
+		return
+	}
+
+	exprs, err := archiveConditions(stmt)
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+	// 没有条件时 gorm 会拒绝删除，不需要复制
+	if len(exprs) == 0 {
+		return
+	}
+
+	var actor interface{}
+	if value, ok := ActorFromContext(stmt.Context); ok {
+		actor = fmt.Sprint(value)
+	}
+
+	columns := make([]clause.Column, 0, len(stmt.Schema.DBNames)+2)
+	selects := make([]clause.Expression, 0, len(stmt.Schema.DBNames)+2)
+	for _, name := range stmt.Schema.DBNames {
+		columns = append(columns, clause.Column{Name: name})
+		selects = append(selects, clause.Expr{SQL: "?", Vars: []interface{}{clause.Column{Table: clause.CurrentTable, Name: name}}})
+	}
+	// 与 migrateArchive 一样按 NamingStrategy 取列名
+	columns = append(columns, clause.Column{Name: db.NamingStrategy.ColumnName("", "DeletedAt")}, clause.Column{Name: db.NamingStrategy.ColumnName("", "DeletedBy")})
+	selects = append(selects, clause.Expr{SQL: "?", Vars: []interface{}{db.NowFunc()}}, clause.Expr{SQL: "?", Vars: []interface{}{actor}})
+
+	source := db.Session(&gorm.Session{NewDB: true}).Table(stmt.Table).
+		Clauses(clause.Select{Expression: clause.CommaExpression{Exprs: selects}}, clause.Where{Exprs: exprs})
+	db.AddError(db.Session(&gorm.Session{NewDB: true}).Exec("INSERT INTO ? ? ?", clause.Table{Name: table}, columns, source).Error)
+}
+
+// archiveConditions 返回与 gorm 删除时相同的条件：已有的 WHERE 以及 Dest、Model 的主键
+func archiveConditions(stmt *gorm.Statement) ([]clause.Expression, error) {
+	where, _ := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	exprs := append([]clause.Expression(nil), where.Exprs...)
+
+	add := func(rv reflect.Value, name string) error {
+		keys, err := primaryKeys(stmt, rv, name)
+		if err != nil || len(keys) == 0 {
+			return err
+		}
+		column, values := schema.ToQueryValues(clause.CurrentTable, stmt.Schema.PrimaryFieldDBNames, keys)
+		exprs = append(exprs, clause.IN{Column: column, Values: values})
+		return nil
+	}
+	if err := add(stmt.ReflectValue, "Dest"); err != nil {
+		return nil, err
+	}
+	if stmt.ReflectValue.CanAddr() && stmt.Dest != stmt.Model && stmt.Model != nil {
+		if err := add(reflect.ValueOf(stmt.Model), "Model"); err != nil {
+			return nil, err
+		}
+	}
+	return exprs, nil
+}
+
+// queryArchive 使 OnlyDeleted 查询归档表
+func queryArchive(db *gorm.DB) {
+	if table, ok := archiveTableOf(db.Statement.Schema); ok && isOnlyDeleted(db.Statement) {
+		db.Statement.Table = table
+	}
+}
+
+// restoreArchived 将 value 对应的记录从归档表移回原表，并重新读取到 value 中
+func restoreArchived(db *gorm.DB, s *schema.Schema, table string, value interface{}) (rowsAffected int64, err error) {
+	_, keys := schema.GetIdentityFieldValuesMap(db.Statement.Context, reflect.ValueOf(value), s.PrimaryFields)
+	if len(keys) == 0 {
+		return 0, gorm.ErrMissingWhereClause
+	}
+	column, values := schema.ToQueryValues(clause.CurrentTable, s.PrimaryFieldDBNames, keys)
+	where := clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}}
+
+	columns := make([]clause.Column, len(s.DBNames))
+	for i, name := range s.DBNames {
+		columns[i] = clause.Column{Name: name}
+	}
+
+	err = Transaction(db, func(tx *gorm.DB) error {
+		if err := callRestoreHooks(tx, value, beforeRestore); err != nil {
+			return err
+		}
+
+		// 以 clause.Select 引用列名，Table 没有 schema 时 Select 的字符串会原样写入，保留字作为列名时出错
+		source := tx.Session(&gorm.Session{NewDB: true}).Table(table).Clauses(clause.Select{Columns: columns}, where)
+		result := tx.Session(&gorm.Session{NewDB: true}).Exec("INSERT INTO ? ? ?", clause.Table{Name: s.Table}, columns, source)
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+
+		if err := tx.Session(&gorm.Session{NewDB: true}).Table(table).Clauses(where).Delete(map[string]interface{}{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Session(&gorm.Session{NewDB: true}).Clauses(where).Find(value).Error; err != nil {
+			return err
+		}
+		return callRestoreHooks(tx, value, afterRestore)
+	})
+	return
+}
@@ -0,0 +1,123 @@
+package soft_delete
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type AuditEvent struct {
+	ID   uint
+	Name string
+}
+
+// FailingEvent 在恢复后返回错误，用于检查恢复中途失败时回滚
+type FailingEvent struct {
+	ID   uint
+	Name string
+}
+
+func (FailingEvent) AfterRestore(tx *gorm.DB) error {
+	return errors.New("after restore")
+}
+
+func openArchiveDB(t *testing.T, model interface{}) *gorm.DB {
+	t.Helper()
+	db := openDB(t, model)
+	archiver := NewArchiver(ArchiveStrategy{Model: model})
+	if err := db.Use(archiver); err != nil {
+		t.Fatal(err)
+	}
+	if err := archiver.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestArchiveDeleteAndRestore(t *testing.T) {
+	db := openArchiveDB(t, &AuditEvent{})
+	events := []AuditEvent{{Name: "a"}, {Name: "b"}}
+	db.Create(&events)
+
+	ctx := WithActor(context.Background(), "admin")
+	if err := db.WithContext(ctx).Delete(&events[0]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, db, "audit_events"); n != 1 {
+		t.Errorf("audit_events rows = %d, want 1", n)
+	}
+
+	var deleted []AuditEvent
+	if err := db.Scopes(OnlyDeleted).Find(&deleted).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0].Name != "a" {
+		t.Errorf("OnlyDeleted = %+v", deleted)
+	}
+	var by string
+	db.Table("audit_events_archive").Select("deleted_by").Where("id = ?", events[0].ID).Scan(&by)
+	if by != "admin" {
+		t.Errorf("deleted_by = %q", by)
+	}
+
+	restored := AuditEvent{ID: events[0].ID}
+	n, err := Restore(db, &restored)
+	if err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	if restored.Name != "a" {
+		t.Errorf("restored = %+v", restored)
+	}
+	if n := countRows(t, db, "audit_events_archive"); n != 0 {
+		t.Errorf("archive rows = %d after restore", n)
+	}
+	if n := countRows(t, db, "audit_events"); n != 2 {
+		t.Errorf("audit_events rows = %d after restore", n)
+	}
+}
+
+func TestArchiveDeleteRollback(t *testing.T) {
+	db := openArchiveDB(t, &AuditEvent{})
+	event := AuditEvent{Name: "a"}
+	db.Create(&event)
+
+	boom := errors.New("boom")
+	db.Callback().Delete().After(CallbackArchive).Before("gorm:delete").Register("test:fail", func(tx *gorm.DB) {
+		tx.AddError(boom)
+	})
+	if err := db.Delete(&event).Error; !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+	if n := countRows(t, db, "audit_events_archive"); n != 0 {
+		t.Errorf("archive rows = %d after rollback", n)
+	}
+	if n := countRows(t, db, "audit_events"); n != 1 {
+		t.Errorf("audit_events rows = %d after rollback", n)
+	}
+}
+
+func TestArchiveRestoreRollback(t *testing.T) {
+	db := openArchiveDB(t, &FailingEvent{})
+	event := FailingEvent{Name: "a"}
+	db.Create(&event)
+	db.Delete(&event)
+
+	if _, err := Restore(db, &FailingEvent{ID: event.ID}); err == nil {
+		t.Fatal("Restore should fail")
+	}
+	if n := countRows(t, db, "failing_events_archive"); n != 1 {
+		t.Errorf("archive rows = %d after rollback", n)
+	}
+	if n := countRows(t, db, "failing_events"); n != 0 {
+		t.Errorf("failing_events rows = %d after rollback", n)
+	}
+}
+
+func TestArchiveRejectsSoftDeleteModel(t *testing.T) {
+	db := openDB(t)
+	if err := db.Use(NewArchiver(ArchiveStrategy{Model: &User{}})); err == nil {
+		t.Error("ArchiveStrategy on a model with a soft delete field should fail")
+	}
+}
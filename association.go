@@ -0,0 +1,94 @@
+package soft_delete
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const associationCallbackName = "soft_delete:association"
+
+// SoftDeleteAssociations 注册回调，使通过 Association 取得的 has one、has many 关联的 Replace、Clear、Delete
+// 软删除解除关联的子记录，而不是将外键置为 NULL。子记录保留原来的外键，恢复后重新属于原来的父记录，
+// Association.Find 和 Preload 不再返回它们。直接使用 db.Association 或其他 UpdateColumns 不受影响
+//
+//	soft_delete.SoftDeleteAssociations(db)
+//	soft_delete.Association(db.Model(&user), "Addresses").Replace(newAddresses)
+func SoftDeleteAssociations(db *gorm.DB) error {
+	if db.Callback().Update().Get(associationCallbackName) != nil {
+		return nil
+	}
+	return db.Callback().Update().After("gorm:before_update").Before("gorm:update").Register(associationCallbackName, softDeleteUnlinked)
+}
+
+type unlinkKey struct{}
+
+// Association 与 db.Association(column) 相同，但在 context 中标记解除关联，
+// 注册了 SoftDeleteAssociations 时其中将外键置为 NULL 的 UPDATE 改写为软删除
+func Association(db *gorm.DB, column string) *gorm.Association {
+	ctx := db.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return db.WithContext(context.WithValue(ctx, unlinkKey{}, true)).Association(column)
+}
+
+// softDeleteUnlinked 将 gorm 解除关联的 UPDATE 改写为软删除
+func softDeleteUnlinked(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || stmt.Unscoped || stmt.SQL.Len() > 0 || !isUnlinking(stmt) {
+		return
+	}
+	if _, archived := archiveTableOf(stmt.Schema); archived {
+		return
+	}
+	if sd, ok := lookUpDeleteClause(stmt.Schema); ok {
+		sd.ModifyStatement(stmt)
+	}
+}
+
+// isUnlinking 判断语句是否为 Association 中 gorm 解除关联时生成的 UpdateColumns：只将外键列置为 NULL，
+// 并且 WHERE 中有按这些外键的 IN 条件。Association 中保存关联等其他 UPDATE 不会改写
+func isUnlinking(stmt *gorm.Statement) bool {
+	if stmt.Context == nil || stmt.Context.Value(unlinkKey{}) == nil {
+		return false
+	}
+	updates, ok := stmt.Dest.(map[string]interface{})
+	if !ok || len(updates) == 0 {
+		return false
+	}
+	for _, value := range updates {
+		if value != nil {
+			return false
+		}
+	}
+
+	where, _ := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	for _, expr := range where.Exprs {
+		in, ok := expr.(clause.IN)
+		if !ok {
+			continue
+		}
+		var columns []clause.Column
+		switch column := in.Column.(type) {
+		case clause.Column:
+			columns = []clause.Column{column}
+		case []clause.Column:
+			columns = column
+		}
+		if len(columns) != len(updates) {
+			continue
+		}
+		matched := true
+		for _, column := range columns {
+			if _, ok := updates[column.Name]; !ok {
+				matched = false
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
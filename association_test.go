@@ -0,0 +1,112 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Owner struct {
+	ID        uint
+	Addresses []Address
+}
+
+type Address struct {
+	ID      uint
+	OwnerID *uint
+	Street  string
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+func openAssociationDB(t *testing.T) (*gorm.DB, Owner) {
+	t.Helper()
+	db := openDB(t, &Owner{}, &Address{})
+	if err := SoftDeleteAssociations(db); err != nil {
+		t.Fatal(err)
+	}
+	owner := Owner{Addresses: []Address{{Street: "a"}, {Street: "b"}}}
+	if err := db.Create(&owner).Error; err != nil {
+		t.Fatal(err)
+	}
+	return db, owner
+}
+
+// unlinked 返回已软删除并保留外键的地址数
+func unlinked(t *testing.T, db *gorm.DB, owner Owner) int64 {
+	t.Helper()
+	var n int64
+	db.Model(&Address{}).Scopes(OnlyDeleted).Where("owner_id = ?", owner.ID).Count(&n)
+	return n
+}
+
+func TestAssociationReplace(t *testing.T) {
+	db, owner := openAssociationDB(t)
+
+	if err := Association(db.Model(&owner), "Addresses").Replace([]Address{{Street: "c"}}); err != nil {
+		t.Fatal(err)
+	}
+	if n := unlinked(t, db, owner); n != 2 {
+		t.Errorf("soft deleted addresses = %d, want 2", n)
+	}
+	if n := countRows(t, db, "addresses"); n != 3 {
+		t.Errorf("rows = %d, want 3", n)
+	}
+
+	// 替换后追加，已软删除的旧记录不会重新出现
+	if err := Association(db.Model(&owner), "Addresses").Append(&Address{Street: "d"}); err != nil {
+		t.Fatal(err)
+	}
+	var found []Address
+	db.Model(&owner).Association("Addresses").Find(&found)
+	if len(found) != 2 || found[0].Street != "c" || found[1].Street != "d" {
+		t.Errorf("Find = %+v", found)
+	}
+}
+
+func TestAssociationClearAndDelete(t *testing.T) {
+	db, owner := openAssociationDB(t)
+
+	if err := Association(db.Model(&owner), "Addresses").Delete(&owner.Addresses[0]); err != nil {
+		t.Fatal(err)
+	}
+	if n := unlinked(t, db, owner); n != 1 {
+		t.Errorf("soft deleted after Delete = %d, want 1", n)
+	}
+
+	if err := Association(db.Model(&owner), "Addresses").Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if n := unlinked(t, db, owner); n != 2 {
+		t.Errorf("soft deleted after Clear = %d, want 2", n)
+	}
+	if n := db.Model(&owner).Association("Addresses").Count(); n != 0 {
+		t.Errorf("Count = %d, want 0", n)
+	}
+}
+
+func TestUnlinkWithoutAssociationSetsNull(t *testing.T) {
+	db, owner := openAssociationDB(t)
+
+	// 形状与解除关联相同的普通 UpdateColumns 不改写
+	err := db.Model(&Address{}).Where(clause.IN{Column: clause.Column{Name: "owner_id"}, Values: []interface{}{owner.ID}}).
+		UpdateColumns(map[string]interface{}{"owner_id": nil}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	var orphans int64
+	db.Model(&Address{}).Where("owner_id IS NULL").Count(&orphans)
+	if orphans != 2 {
+		t.Errorf("active addresses with NULL owner = %d, want 2", orphans)
+	}
+
+	// 没有通过 Association 包装的 gorm 关联同样按 gorm 的方式置为 NULL
+	other := Owner{Addresses: []Address{{Street: "c"}}}
+	db.Create(&other)
+	if err := db.Model(&other).Association("Addresses").Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if n := unlinked(t, db, other); n != 0 {
+		t.Errorf("soft deleted = %d, want 0", n)
+	}
+}
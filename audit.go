@@ -0,0 +1,165 @@
+package soft_delete
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	auditDeleteCallbackName  = "soft_delete:audit_delete"
+	auditRestoreCallbackName = "soft_delete:audit_restore"
+
+	defaultAuditTable = "soft_delete_audit"
+)
+
+// 审计记录的操作类型
+const (
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+)
+
+// AuditConfig 为审计日志的配置
+type AuditConfig struct {
+	// Table 为审计表名，默认为 soft_delete_audit
+	Table string
+	// BestEffort 时写入审计记录失败只记录日志，默认中止删除或恢复所在的事务
+	BestEffort bool
+}
+
+func (c AuditConfig) table() string {
+	if c.Table == "" {
+		return defaultAuditTable
+	}
+	return c.Table
+}
+
+// AuditLog 为一条审计记录。已知主键时每条记录一行，PrimaryKeys 为主键列到值的 JSON；
+// 否则整条语句一行，Conditions 为展开参数后的 WHERE 条件
+type AuditLog struct {
+	ID          uint64 `gorm:"primaryKey"`
+	TableName   string `gorm:"size:255;index"`
+	PrimaryKeys string
+	Conditions  string
+	Action      string `gorm:"size:16"`
+	Actor       string `gorm:"size:255"`
+	CreatedAt   time.Time
+}
+
+// MigrateAuditLog 创建或更新审计表
+func MigrateAuditLog(db *gorm.DB, config AuditConfig) error {
+	return db.Table(config.table()).AutoMigrate(&AuditLog{})
+}
+
+// WithAuditLog 注册回调，在软删除和恢复所在的事务中写入审计记录，执行者取自 WithActor 设置的 context。
+// 关闭了 gorm 默认事务（SkipDefaultTransaction）时审计记录与删除不在同一事务中
+//
+//	soft_delete.WithAuditLog(db, soft_delete.AuditConfig{Table: "soft_delete_audit"})
+func WithAuditLog(db *gorm.DB, config AuditConfig) error {
+	if db.Callback().Delete().Get(auditDeleteCallbackName) == nil {
+		if err := db.Callback().Delete().After("gorm:delete").Before("gorm:commit_or_rollback_transaction").Register(auditDeleteCallbackName, func(tx *gorm.DB) {
+			if isSoftDeleting(tx.Statement) {
+				writeAuditLog(tx, config, ActionDelete)
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	if db.Callback().Update().Get(auditRestoreCallbackName) == nil {
+		return db.Callback().Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").Register(auditRestoreCallbackName, func(tx *gorm.DB) {
+			if isRestoring(tx.Statement) {
+				writeAuditLog(tx, config, ActionRestore)
+			}
+		})
+	}
+	return nil
+}
+
+// isSoftDeleting 判断删除语句是否被改写为软删除
+func isSoftDeleting(stmt *gorm.Statement) bool {
+	_, ok := stmt.Clauses[ClauseDelete]
+	return ok && !stmt.Unscoped && !isPermanentDelete(stmt)
+}
+
+func writeAuditLog(db *gorm.DB, config AuditConfig, action string) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || db.RowsAffected == 0 {
+		return
+	}
+
+	record := AuditLog{TableName: stmt.Table, Action: action, CreatedAt: stmt.DB.NowFunc()}
+	if actor, ok := ActorFromContext(stmt.Context); ok {
+		record.Actor = fmt.Sprint(actor)
+	}
+
+	var records []AuditLog
+	for _, keys := range affectedKeys(stmt) {
+		data, err := json.Marshal(keys)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		r := record
+		r.PrimaryKeys = string(data)
+		records = append(records, r)
+	}
+	if len(records) == 0 {
+		record.Conditions = renderWhere(stmt)
+		records = append(records, record)
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true, Context: stmt.Context, SkipHooks: true})
+	if err := tx.Table(config.table()).Create(&records).Error; err != nil {
+		if config.BestEffort {
+			db.Logger.Error(stmt.Context, "soft_delete: write audit log failed: %v", err)
+			return
+		}
+		db.AddError(err)
+	}
+}
+
+// affectedKeys 返回语句中模型的主键，每条记录一个列名到值的 map，主键为零值的记录跳过
+func affectedKeys(stmt *gorm.Statement) []map[string]interface{} {
+	var keys []map[string]interface{}
+	collect := func(rv reflect.Value) {
+		if rv.Kind() != reflect.Struct || len(stmt.Schema.PrimaryFields) == 0 {
+			return
+		}
+		key := make(map[string]interface{}, len(stmt.Schema.PrimaryFields))
+		for _, field := range stmt.Schema.PrimaryFields {
+			value, zero := field.ValueOf(stmt.Context, rv)
+			if zero {
+				return
+			}
+			key[field.DBName] = value
+		}
+		keys = append(keys, key)
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			collect(reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		collect(stmt.ReflectValue)
+	}
+	return keys
+}
+
+// renderWhere 返回展开参数后的 WHERE 条件，用于记录不知道主键的批量操作
+func renderWhere(stmt *gorm.Statement) string {
+	where := &gorm.Statement{DB: stmt.DB, Table: stmt.Table, Schema: stmt.Schema, Context: stmt.Context,
+		Clauses: map[string]clause.Clause{}}
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		where.Clauses["WHERE"] = c
+	}
+	where.Build("WHERE")
+	return strings.TrimPrefix(stmt.DB.Dialector.Explain(where.SQL.String(), where.Vars...), "WHERE ")
+}
@@ -0,0 +1,72 @@
+package soft_delete
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAuditLog(t *testing.T) {
+	db := openDB(t, &User{})
+	config := AuditConfig{Table: "audit"}
+	if err := MigrateAuditLog(db, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := WithAuditLog(db, config); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+
+	ctx := WithActor(context.Background(), "alice")
+	db.WithContext(ctx).Delete(&[]User{{ID: 1}, {ID: 2}})
+	db.WithContext(ctx).Where("name = ?", "c").Delete(&User{})
+	Restore(db, &User{ID: 1})
+
+	var logs []AuditLog
+	db.Table("audit").Order("id").Find(&logs)
+	if len(logs) != 4 {
+		t.Fatalf("logs = %+v", logs)
+	}
+	for i, want := range []string{`{"id":1}`, `{"id":2}`} {
+		if logs[i].PrimaryKeys != want || logs[i].Action != ActionDelete || logs[i].Actor != "alice" || logs[i].TableName != "users" {
+			t.Errorf("log %d = %+v", i, logs[i])
+		}
+	}
+	if logs[2].PrimaryKeys != "" {
+		t.Errorf("batch log = %+v", logs[2])
+	}
+	assertContains(t, logs[2].Conditions, `name = "c"`)
+	if logs[3].Action != ActionRestore || logs[3].PrimaryKeys != `{"id":1}` {
+		t.Errorf("restore log = %+v", logs[3])
+	}
+}
+
+// 写入审计记录失败时删除回滚，BestEffort 时删除照常执行
+func TestAuditLogFailure(t *testing.T) {
+	db := openDB(t, &User{})
+	if err := WithAuditLog(db, AuditConfig{Table: "missing"}); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&User{Name: "a"})
+
+	if err := db.Delete(&User{ID: 1}).Error; err == nil {
+		t.Fatal("delete succeeded without audit table")
+	}
+	var n int64
+	db.Model(&User{}).Count(&n)
+	if n != 1 {
+		t.Errorf("active after failed audit = %d, want 1", n)
+	}
+
+	best := openDB(t, &User{})
+	if err := WithAuditLog(best, AuditConfig{Table: "missing", BestEffort: true}); err != nil {
+		t.Fatal(err)
+	}
+	best.Create(&User{Name: "a"})
+	if err := best.Delete(&User{ID: 1}).Error; err != nil {
+		t.Fatalf("BestEffort delete: %v", err)
+	}
+	best.Model(&User{}).Count(&n)
+	if n != 0 {
+		t.Errorf("active after BestEffort = %d, want 0", n)
+	}
+}
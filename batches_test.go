@@ -0,0 +1,59 @@
+package soft_delete
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// FindInBatches 复用同一个语句，每一批都要带上过滤条件
+func TestFindInBatches(t *testing.T) {
+	db := openDB(t, &User{})
+	users := make([]User, 1500)
+	if err := db.CreateInBatches(&users, 500).Error; err != nil {
+		t.Fatal(err)
+	}
+	var ids []uint
+	for i := 0; i < len(users); i += 3 {
+		ids = append(ids, users[i].ID)
+	}
+	if err := db.Where("id IN ?", ids).Delete(&User{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deleted := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		deleted[id] = true
+	}
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	var rows []User
+	seen, batches := 0, 0
+	err := db.Session(&gorm.Session{Logger: recorder}).FindInBatches(&rows, 100, func(tx *gorm.DB, batch int) error {
+		batches++
+		for _, u := range rows {
+			if deleted[u.ID] || !u.Deleted.IsActive() {
+				t.Errorf("batch %d contains deleted user %d", batch, u.ID)
+			}
+		}
+		seen += len(rows)
+		return nil
+	}).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seen != 1000 || batches != 10 {
+		t.Errorf("seen %d rows in %d batches, want 1000 in 10", seen, batches)
+	}
+	// 最后一次查询返回空结果后结束
+	if len(recorder.sqls) != batches+1 {
+		t.Fatalf("executed %d queries, want %d", len(recorder.sqls), batches+1)
+	}
+	for _, sql := range recorder.sqls {
+		assertContains(t, sql, "`users`.`deleted` = false")
+		if strings.Count(sql, "`users`.`deleted`") != 1 {
+			t.Errorf("filter repeated in %s", sql)
+		}
+	}
+}
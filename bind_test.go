@@ -0,0 +1,68 @@
+package soft_delete
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// 标记值作为参数绑定，SQL 中没有字面量
+func TestFlagValuesAreBound(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+
+	tx := db.Where("name = ?", "a").Find(&[]User{})
+	sql := sqlOf(t, tx)
+	assertContains(t, sql, "`users`.`deleted` = ?")
+	assertNotContains(t, sql, "false", "true", "= 0", "= 1")
+	if vars := tx.Statement.Vars; len(vars) != 2 || vars[1] != false {
+		t.Errorf("Vars = %#v, want [a false]", vars)
+	}
+
+	tx = db.Delete(&User{ID: 1})
+	sql = sqlOf(t, tx)
+	assertContains(t, sql, "SET `deleted`=? WHERE `users`.`id` = ? AND `users`.`deleted` = ?")
+	if vars := tx.Statement.Vars; len(vars) != 3 || vars[0] != true || vars[2] != false {
+		t.Errorf("Vars = %#v, want [true 1 false]", vars)
+	}
+}
+
+func TestFlagValuesBoundAsInt(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+	config := DefaultConfig()
+	config.ValueMode = ValueInt
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := db.Find(&[]User{})
+	assertContains(t, sqlOf(t, tx), "`users`.`deleted` = ?")
+	if vars := tx.Statement.Vars; len(vars) != 1 || vars[0] != int64(0) {
+		t.Errorf("Vars = %#v, want [0]", vars)
+	}
+}
+
+// PrepareStmt 下同一查询只准备一条语句
+func TestFlagValuesPreparedOnce(t *testing.T) {
+	db := openDB(t, &User{})
+	prepared := db.Session(&gorm.Session{PrepareStmt: true})
+	seedUsers(t, prepared)
+
+	for _, name := range []string{"a", "b", "c", "a"} {
+		var users []User
+		if err := prepared.Where("name = ?", name).Find(&users).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pool := prepared.ConnPool.(*gorm.PreparedStmtDB)
+	var selects int
+	for _, sql := range pool.PreparedSQL {
+		if strings.HasPrefix(sql, "SELECT") && strings.Contains(sql, "name = ?") {
+			selects++
+		}
+	}
+	if selects != 1 {
+		t.Errorf("prepared %d SELECT statements: %q", selects, pool.PreparedSQL)
+	}
+}
@@ -0,0 +1,227 @@
+package soft_delete
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidBit 表示 BitFlag 的 Bit 标签不是 0 到 63 的整数，使用该模型的语句和 Validate 返回此错误
+var ErrInvalidBit = errors.New("soft_delete: Bit must be an integer from 0 to 63")
+
+// BitFlag 用于将多个布尔状态压缩在一个整数列中的表，其中一位表示已删除，位置由标签 Bit 指定，默认为第 0 位：
+// 查询过滤 (flags & 8) = 0，删除时写入 flags = flags | 8，恢复时写入 flags = flags & ~8，其他位保持不变。
+// Oracle 没有位运算符，使用 BITAND
+//
+//	Flags soft_delete.BitFlag `gorm:"softDelete:bit,Bit:3"`
+type BitFlag int64
+
+// Has 判断第 bit 位是否为 1
+func (b BitFlag) Has(bit uint) bool {
+	return b&(1<<bit) != 0
+}
+
+// IsDeleted 判断默认的第 0 位是否为 1，通过标签 Bit 指定了位置的字段使用 IsDeletedFor
+func (b BitFlag) IsDeleted() bool {
+	return b.Has(0)
+}
+
+// IsActive 判断记录是否未删除
+func (b BitFlag) IsActive() bool {
+	return !b.IsDeleted()
+}
+
+// String 返回 "deleted" 或 "active"
+func (b BitFlag) String() string {
+	return stateString(b.IsDeleted())
+}
+
+// IsDeletedFor 按字段 f 标签中的 Bit 判断记录是否已删除，f 为模型中的 BitFlag 字段，位置无效时按第 0 位
+func (b BitFlag) IsDeletedFor(f *schema.Field) bool {
+	bit, _ := bitOf(f)
+	return b.Has(uint(bit))
+}
+
+// IsActiveFor 按字段 f 的位置判断记录是否未删除
+func (b BitFlag) IsActiveFor(f *schema.Field) bool {
+	return !b.IsDeletedFor(f)
+}
+
+// 实现 driver.Valuer 接口
+func (b BitFlag) Value() (driver.Value, error) {
+	return int64(b), nil
+}
+
+// 实现 sql.Scanner 接口，NULL 视为 0
+func (b *BitFlag) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*b = 0
+	case int64:
+		*b = BitFlag(v)
+	case []byte:
+		return b.scanString(string(v))
+	case string:
+		return b.scanString(v)
+	default:
+		return fmt.Errorf("invalid data type for BitFlag: %T", value)
+	}
+	return nil
+}
+
+func (b *BitFlag) scanString(s string) error {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid value for BitFlag: %q", s)
+	}
+	*b = BitFlag(n)
+	return nil
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface 接口
+func (BitFlag) GormDataType() string {
+	return string(schema.Int)
+}
+
+// bitOf 解析标签中的位置，没有 Bit 标签时为第 0 位
+func bitOf(f *schema.Field) (uint64, error) {
+	setting, ok := parseSettings(f)["BIT"]
+	if !ok {
+		return 0, nil
+	}
+	bit, err := strconv.ParseUint(setting, 10, 6)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q on %s.%s", ErrInvalidBit, setting, f.Schema.Name, f.Name)
+	}
+	return bit, nil
+}
+
+// bindBitFlag 解析标签中的位置，并使字段在删除、恢复后按位更新内存中的值。
+// 位置无效时按第 0 位绑定，语句由 bitFieldError 返回错误
+func bindBitFlag(f *schema.Field) {
+	bit, _ := bitOf(f)
+	if _, loaded := fieldFlagValues.LoadOrStore(f, flagValues{mask: 1 << bit}); loaded {
+		return
+	}
+
+	valueOf, set := f.ValueOf, f.Set
+	f.Set = func(ctx context.Context, v reflect.Value, value interface{}) error {
+		assign, ok := value.(bitAssign)
+		if !ok {
+			return set(ctx, v, value)
+		}
+		current, _ := valueOf(ctx, v)
+		flags, _ := current.(BitFlag)
+		if assign.set {
+			return set(ctx, v, flags|BitFlag(assign.mask))
+		}
+		return set(ctx, v, flags&^BitFlag(assign.mask))
+	}
+}
+
+func (BitFlag) QueryClauses(f *schema.Field) []clause.Interface {
+	bindBitFlag(f)
+	return []clause.Interface{SoftDeleteQueryClause{Field: f, Flag: true}.withQuerySettings()}
+}
+
+func (BitFlag) UpdateClauses(f *schema.Field) []clause.Interface {
+	return flagUpdateClauses(f)
+}
+
+func (BitFlag) DeleteClauses(f *schema.Field) []clause.Interface {
+	bindBitFlag(f)
+	settings := parseSettings(f)
+	softDeleteClause := SoftDeleteDeleteClause{
+		Field:    f,
+		Flag:     true,
+		TimeType: getTimeType(settings),
+	}
+	return []clause.Interface{softDeleteClause.withCompanionFields(settings)}
+}
+
+// bitFieldError 返回 BitFlag 字段的配置错误，其他字段返回 nil
+func bitFieldError(f *schema.Field) error {
+	if flagValuesOf(f).mask == 0 {
+		return nil
+	}
+	_, err := bitOf(f)
+	return err
+}
+
+// bitExpr 为 BitFlag 的过滤条件，deleted 时为删除位为 1
+type bitExpr struct {
+	column  clause.Column
+	mask    int64
+	deleted bool
+}
+
+func (e bitExpr) Build(builder clause.Builder) {
+	writeBitAnd(builder, e.column, e.mask)
+	if e.deleted {
+		builder.WriteString(" <> 0")
+	} else {
+		builder.WriteString(" = 0")
+	}
+}
+
+// bitAssign 为删除、恢复时写入 BitFlag 列的表达式，set 时置位，否则清除
+type bitAssign struct {
+	column clause.Column
+	mask   int64
+	set    bool
+}
+
+func (a bitAssign) Build(builder clause.Builder) {
+	mask := strconv.FormatInt(a.mask, 10)
+	if dialectOf(builder) == "oracle" {
+		builder.WriteQuoted(a.column)
+		if a.set {
+			builder.WriteString(" + " + mask)
+		}
+		builder.WriteString(" - ")
+		writeBitAnd(builder, a.column, a.mask)
+		return
+	}
+
+	builder.WriteQuoted(a.column)
+	if a.set {
+		builder.WriteString(" | " + mask)
+	} else {
+		builder.WriteString(" & ~" + mask)
+	}
+}
+
+// writeBitAnd 写入列与 mask 的按位与
+func writeBitAnd(builder clause.Builder, column clause.Column, mask int64) {
+	if dialectOf(builder) == "oracle" {
+		builder.WriteString("BITAND(")
+		builder.WriteQuoted(column)
+		builder.WriteString(", " + strconv.FormatInt(mask, 10) + ")")
+		return
+	}
+	builder.WriteByte('(')
+	builder.WriteQuoted(column)
+	builder.WriteString(" & " + strconv.FormatInt(mask, 10) + ")")
+}
+
+// bitSQL 为部分索引中的按位与，值直接写入语句
+func bitSQL(db *gorm.DB, column string, mask int64) string {
+	if db.Dialector.Name() == "oracle" {
+		return "BITAND(" + column + ", " + strconv.FormatInt(mask, 10) + ")"
+	}
+	return "(" + column + " & " + strconv.FormatInt(mask, 10) + ")"
+}
+
+func dialectOf(builder clause.Builder) string {
+	if stmt, ok := builder.(*gorm.Statement); ok && stmt.DB != nil && stmt.DB.Dialector != nil {
+		return stmt.DB.Dialector.Name()
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type BitUser struct {
+	ID    uint
+	Name  string
+	Flags BitFlag `gorm:"softDelete:bit,Bit:3"`
+}
+
+type BitZeroUser struct {
+	ID    uint
+	Flags BitFlag `gorm:"softDelete:bit"`
+}
+
+type BadBitUser struct {
+	ID    uint
+	Flags BitFlag `gorm:"softDelete:bit,Bit:x"`
+}
+
+type OverflowBitUser struct {
+	ID    uint
+	Flags BitFlag `gorm:"softDelete:bit,Bit:64"`
+}
+
+// restoreSQL 返回 Restore 在 DryRun 下生成的 UPDATE
+func restoreSQL(t *testing.T, db *gorm.DB, value interface{}) string {
+	t.Helper()
+	recorder := &sqlRecorder{Interface: db.Logger}
+	if _, err := Restore(db.Session(&gorm.Session{Logger: recorder}), value); err != nil {
+		t.Fatal(err)
+	}
+	for _, sql := range recorder.sqls {
+		if len(sql) > 6 && sql[:6] == "UPDATE" {
+			return sql
+		}
+	}
+	t.Fatalf("no UPDATE in %v", recorder.sqls)
+	return ""
+}
+
+// Bit 标签决定表示删除的位，没有标签时为第 0 位
+func TestBitFlagParse(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+	tests := []struct {
+		model interface{}
+		mask  int64
+	}{
+		{&BitUser{}, 8},
+		{&BitZeroUser{}, 1},
+	}
+	for _, tt := range tests {
+		if mask := flagValuesOf(fieldOf(t, db, tt.model, "Flags")).mask; mask != tt.mask {
+			t.Errorf("%T mask = %d, want %d", tt.model, mask, tt.mask)
+		}
+	}
+}
+
+// 无效的 Bit 标签使查询、删除、恢复和 Validate 返回 ErrInvalidBit，而不是按第 0 位执行
+func TestBitFlagInvalidBit(t *testing.T) {
+	db := openDB(t, &BadBitUser{}, &OverflowBitUser{})
+	db.Exec("INSERT INTO bad_bit_users (id, flags) VALUES (1, 0)")
+
+	for _, model := range []interface{}{&BadBitUser{}, &OverflowBitUser{}} {
+		if err := Validate(db, model); !errors.Is(err, ErrInvalidBit) {
+			t.Errorf("Validate(%T) = %v, want ErrInvalidBit", model, err)
+		}
+	}
+	if err := db.Find(&[]BadBitUser{}).Error; !errors.Is(err, ErrInvalidBit) {
+		t.Errorf("Find error = %v", err)
+	}
+	if err := db.Delete(&BadBitUser{ID: 1}).Error; !errors.Is(err, ErrInvalidBit) {
+		t.Errorf("Delete error = %v", err)
+	}
+	if _, err := Restore(db, &BadBitUser{ID: 1}); !errors.Is(err, ErrInvalidBit) {
+		t.Errorf("Restore error = %v", err)
+	}
+	var flags int64
+	db.Table("bad_bit_users").Select("flags").Where("id = 1").Scan(&flags)
+	if flags != 0 {
+		t.Errorf("flags = %d, want untouched 0", flags)
+	}
+}
+
+// 按位运算的写法按数据库生成，Oracle 使用 BITAND
+func TestBitFlagSQLPerDialect(t *testing.T) {
+	tests := []struct {
+		dialect               string
+		active, deleted       string
+		deleteSet, restoreSet string
+	}{
+		{"sqlite", "(`bit_users`.`flags` & 8) = 0", "(`bit_users`.`flags` & 8) <> 0", "`flags`=`flags` | 8", "`flags`=`flags` & ~8"},
+		{"mysql", "(`bit_users`.`flags` & 8) = 0", "(`bit_users`.`flags` & 8) <> 0", "`flags`=`flags` | 8", "`flags`=`flags` & ~8"},
+		{"postgres", "(`bit_users`.`flags` & 8) = 0", "(`bit_users`.`flags` & 8) <> 0", "`flags`=`flags` | 8", "`flags`=`flags` & ~8"},
+		{"oracle", "BITAND(`bit_users`.`flags`, 8) = 0", "BITAND(`bit_users`.`flags`, 8) <> 0",
+			"`flags`=`flags` + 8 - BITAND(`flags`, 8)", "`flags`=`flags` - BITAND(`flags`, 8)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			db := dryRunDB(t, tt.dialect)
+			assertContains(t, sqlOf(t, db.Find(&[]BitUser{})), "WHERE "+tt.active)
+			assertContains(t, sqlOf(t, db.Scopes(OnlyDeleted).Find(&[]BitUser{})), "WHERE "+tt.deleted)
+
+			sql := sqlOf(t, db.Delete(&BitUser{ID: 1}))
+			assertContains(t, sql, "UPDATE `bit_users` SET "+tt.deleteSet, tt.active)
+			assertNotContains(t, sql, "DELETE")
+
+			assertContains(t, restoreSQL(t, db, &BitUser{ID: 1}), "SET "+tt.restoreSet, tt.deleted)
+		})
+	}
+}
+
+// 删除、恢复只修改表示删除的位，其他位保持不变
+func TestBitFlag(t *testing.T) {
+	db := openDB(t, &BitUser{})
+	db.Create(&[]BitUser{{Name: "a", Flags: 5}, {Name: "b", Flags: 1}})
+
+	user := BitUser{ID: 1, Flags: 5}
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Flags != 13 || !user.Flags.Has(3) {
+		t.Errorf("in-memory flags = %d, want 13", user.Flags)
+	}
+
+	var users []BitUser
+	db.Find(&users)
+	if len(users) != 1 || users[0].Name != "b" {
+		t.Errorf("active = %+v", users)
+	}
+	var deleted BitUser
+	if err := db.Scopes(OnlyDeleted).First(&deleted).Error; err != nil || deleted.Flags != 13 {
+		t.Errorf("deleted = %+v, %v", deleted, err)
+	}
+
+	if n, err := Restore(db, &BitUser{ID: 1}); err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	var restored BitUser
+	db.First(&restored, 1)
+	if restored.Flags != 5 || restored.Flags.Has(3) {
+		t.Errorf("restored flags = %d, want 5", restored.Flags)
+	}
+	// 再次删除已删除的记录不会改变其他位
+	db.Delete(&BitUser{ID: 1})
+	if n := db.Delete(&BitUser{ID: 1}).RowsAffected; n != 0 {
+		t.Errorf("second delete affected %d rows", n)
+	}
+}
+
+func TestBitFlagScan(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    BitFlag
+		wantErr bool
+	}{
+		{nil, 0, false},
+		{int64(13), 13, false},
+		{[]byte("8"), 8, false},
+		{"5", 5, false},
+		{"x", 0, true},
+		{1.5, 0, true},
+	}
+	for _, tt := range tests {
+		var b BitFlag
+		err := b.Scan(tt.value)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && b != tt.want) {
+			t.Errorf("Scan(%#v) = %d, %v, want %d, error %v", tt.value, b, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package soft_delete
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+var cascadeDepth = 3
+
+// SetCascadeDepth 设置级联软删除的最大层数，0 表示关闭级联
+//
+// Deprecated: 运行时修改会产生数据竞争，使用 Use 为 db 设置 Config.CascadeDepth
+func SetCascadeDepth(depth int) {
+	cascadeDepth = depth
+}
+
+type cascadeKey struct{}
+
+// cascadeState 记录当前级联所在的层数和经过的模型，用于限制深度和检测环
+type cascadeState struct {
+	depth int
+	path  []*schema.Schema
+}
+
+func cascadeStateFrom(ctx context.Context) cascadeState {
+	if ctx != nil {
+		if state, ok := ctx.Value(cascadeKey{}).(cascadeState); ok {
+			return state
+		}
+	}
+	return cascadeState{}
+}
+
+func (state cascadeState) visited(s *schema.Schema) bool {
+	for _, p := range state.path {
+		if p == s {
+			return true
+		}
+	}
+	return false
+}
+
+// isCascadeRelation 判断 has one / has many 关联是否需要级联软删除，
+// 关联字段声明了 constraint:OnDelete:CASCADE 或 softDeleteCascade 标签，且子模型使用了本包的类型
+func isCascadeRelation(rel *schema.Relationship) bool {
+	if rel.Type != schema.HasOne && rel.Type != schema.HasMany {
+		return false
+	}
+	if _, ok := lookUpDeleteClause(rel.FieldSchema); !ok {
+		return false
+	}
+	if _, ok := rel.Field.TagSettings["SOFTDELETECASCADE"]; ok {
+		return true
+	}
+	constraint := rel.ParseConstraint()
+	return constraint != nil && strings.EqualFold(constraint.OnDelete, "CASCADE")
+}
+
+// cascadeDelete 在父记录的 UPDATE 执行之前，按外键软删除子记录。
+// 子记录的条件为外键在父记录子查询中，因此无论按主键还是按条件删除父记录都适用；
+// 子记录通过 Delete 完成，会继续级联到下一层，已删除的子记录保持不变
+func cascadeDelete(stmt *gorm.Statement) {
+	if stmt.Schema == nil {
+		return
+	}
+
+	state := cascadeStateFrom(stmt.Context)
+	if state.depth >= configOf(stmt.DB).CascadeDepth {
+		return
+	}
+
+	var rels []*schema.Relationship
+	for _, rel := range stmt.Schema.Relationships.Relations {
+		if isCascadeRelation(rel) && rel.FieldSchema != stmt.Schema && !state.visited(rel.FieldSchema) {
+			rels = append(rels, rel)
+		}
+	}
+	if len(rels) == 0 {
+		return
+	}
+
+	wheres := cascadeConditions(stmt)
+	next := cascadeState{depth: state.depth + 1, path: append(append([]*schema.Schema{}, state.path...), stmt.Schema)}
+	ctx := context.WithValue(stmt.Context, cascadeKey{}, next)
+	// 子记录的事件暂存在父记录的语句中，父记录删除成功后再调用
+	if _, ok := ctx.Value(eventBufferKey{}).(*eventBuffer); !ok {
+		buffer := &eventBuffer{}
+		ctx = context.WithValue(ctx, eventBufferKey{}, buffer)
+		stmt.Settings.Store(cascadeEventsKey, buffer)
+	}
+
+	for _, rel := range rels {
+		var (
+			primaryKeys []clause.Column
+			foreignKeys []interface{}
+			conds       []clause.Expression
+		)
+		for _, ref := range rel.References {
+			if ref.OwnPrimaryKey {
+				primaryKeys = append(primaryKeys, clause.Column{Table: clause.CurrentTable, Name: ref.PrimaryKey.DBName})
+				foreignKeys = append(foreignKeys, clause.Column{Table: clause.CurrentTable, Name: ref.ForeignKey.DBName})
+			} else if ref.PrimaryValue != "" {
+				conds = append(conds, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: ref.ForeignKey.DBName}, Value: ref.PrimaryValue})
+			}
+		}
+
+		for _, where := range wheres {
+			parents := stmt.DB.Session(&gorm.Session{NewDB: true}).Table(stmt.Table).
+				Clauses(clause.Select{Columns: primaryKeys}, clause.Where{Exprs: where})
+			childConds := append(append([]clause.Expression{}, conds...), clause.Expr{SQL: "? IN (?)", Vars: []interface{}{foreignKeys, parents}})
+
+			child := reflect.New(rel.FieldSchema.ModelType).Interface()
+			tx := stmt.DB.Session(&gorm.Session{NewDB: true, Context: ctx}).Model(child).Clauses(clause.Where{Exprs: childConds})
+			if reason, ok := reasonFromStatement(stmt); ok {
+				tx = tx.Clauses(reason)
+			}
+			if err := tx.Delete(child).Error; err != nil {
+				stmt.AddError(err)
+				return
+			}
+		}
+	}
+}
+
+// cascadeConditions 返回父记录子查询使用的条件。主键分为多批时每一批一组条件，
+// 否则子查询只会按构建时的第一批主键查找父记录
+func cascadeConditions(stmt *gorm.Statement) [][]clause.Expression {
+	where, _ := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	for i, expr := range where.Exprs {
+		k, ok := expr.(*keyCondition)
+		if !ok || len(k.conds) < 2 {
+			continue
+		}
+		wheres := make([][]clause.Expression, len(k.conds))
+		for j, cond := range k.conds {
+			exprs := append([]clause.Expression{}, where.Exprs...)
+			exprs[i] = cond
+			wheres[j] = exprs
+		}
+		return wheres
+	}
+	return [][]clause.Expression{append([]clause.Expression{}, where.Exprs...)}
+}
+
+const defaultCascadeTolerance = time.Second
+
+type restoreConfig struct {
+	cascade   bool
+	tolerance time.Duration
+	stats     *RestoreStats
+	strict    bool
+	conflicts *conflictConfig
+}
+
+// RestoreOption 配置 Restore 的行为
+type RestoreOption func(*restoreConfig)
+
+// Cascade 同时恢复与父记录一起被级联删除的子记录。子记录的删除时间与父记录相差不超过容差时才会恢复，
+// 在父记录之前被单独删除的子记录保持删除状态；父记录或子记录没有删除时间时，会恢复全部已删除的子记录并记录警告
+func Cascade() RestoreOption {
+	return func(c *restoreConfig) {
+		c.cascade = true
+	}
+}
+
+// CascadeTolerance 设置级联恢复时删除时间的容差，默认 1 秒
+func CascadeTolerance(tolerance time.Duration) RestoreOption {
+	return func(c *restoreConfig) {
+		c.tolerance = tolerance
+	}
+}
+
+// CascadeStats 在级联恢复结束后写入各子表恢复的行数和警告
+func CascadeStats(stats *RestoreStats) RestoreOption {
+	return func(c *restoreConfig) {
+		c.stats = stats
+	}
+}
+
+// RestoreStats 为级联恢复的结果
+type RestoreStats struct {
+	// Children 为各子表恢复的行数
+	Children map[string]int64
+	// Warnings 记录因缺少删除时间而恢复了全部已删除子记录的表
+	Warnings []string
+}
+
+// cascadeRestore 在父记录恢复之前逐个恢复其级联删除的子记录
+func cascadeRestore(tx *gorm.DB, value interface{}, config restoreConfig) error {
+	s, sd, err := parseDeleteClause(tx, value)
+	if err != nil {
+		return err
+	}
+
+	stats := config.stats
+	if stats == nil {
+		stats = &RestoreStats{}
+	}
+	if stats.Children == nil {
+		stats.Children = map[string]int64{}
+	}
+
+	reflectValue := reflect.Indirect(reflect.ValueOf(value))
+	switch reflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < reflectValue.Len(); i++ {
+			if err := cascadeRestoreParent(tx, s, sd, reflect.Indirect(reflectValue.Index(i)), config, stats); err != nil {
+				return err
+			}
+		}
+		return nil
+	case reflect.Struct:
+		return cascadeRestoreParent(tx, s, sd, reflectValue, config, stats)
+	default:
+		return gorm.ErrInvalidValue
+	}
+}
+
+// cascadeRestoreParent 读取父记录当前的删除时间，再按该时间恢复子记录
+func cascadeRestoreParent(tx *gorm.DB, s *schema.Schema, sd SoftDeleteDeleteClause, parent reflect.Value, config restoreConfig, stats *RestoreStats) error {
+	conds := []clause.Expression{
+		deletedExpr(sd.Field, sd.Flag, clause.Column{Table: clause.CurrentTable, Name: sd.Field.DBName}),
+	}
+	for _, field := range s.PrimaryFields {
+		value, zero := field.ValueOf(tx.Statement.Context, parent)
+		if zero {
+			return gorm.ErrMissingWhereClause
+		}
+		conds = append(conds, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: value})
+	}
+
+	var deletedAt *time.Time
+	if field := sd.deletedAtField(); field != nil {
+		stored := reflect.New(s.ModelType)
+		result := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Table(s.Table).Clauses(clause.Select{Columns: []clause.Column{{Name: field.DBName}}}, clause.Where{Exprs: conds}).
+			Limit(1).Find(stored.Interface())
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if t, ok := sd.deletedTime(tx.Statement.Context, field, stored.Elem()); ok {
+			deletedAt = &t
+		}
+	}
+
+	return restoreChildren(tx, s, conds, deletedAt, config, cascadeState{path: []*schema.Schema{s}}, stats)
+}
+
+// restoreChildren 自下而上恢复子记录：子查询依赖各层仍处于删除状态，因此先恢复更深的层级
+func restoreChildren(tx *gorm.DB, s *schema.Schema, parentConds []clause.Expression, deletedAt *time.Time, config restoreConfig, state cascadeState, stats *RestoreStats) error {
+	if state.depth >= configOf(tx).CascadeDepth {
+		return nil
+	}
+
+	for _, rel := range s.Relationships.Relations {
+		if !isCascadeRelation(rel) || rel.FieldSchema == s || state.visited(rel.FieldSchema) {
+			continue
+		}
+
+		childSd, _ := lookUpDeleteClause(rel.FieldSchema)
+		var (
+			primaryKeys []clause.Column
+			foreignKeys []interface{}
+			conds       = []clause.Expression{
+				deletedExpr(childSd.Field, childSd.Flag, clause.Column{Table: clause.CurrentTable, Name: childSd.Field.DBName}),
+			}
+		)
+		for _, ref := range rel.References {
+			if ref.OwnPrimaryKey {
+				primaryKeys = append(primaryKeys, clause.Column{Table: clause.CurrentTable, Name: ref.PrimaryKey.DBName})
+				foreignKeys = append(foreignKeys, clause.Column{Table: clause.CurrentTable, Name: ref.ForeignKey.DBName})
+			} else if ref.PrimaryValue != "" {
+				conds = append(conds, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: ref.ForeignKey.DBName}, Value: ref.PrimaryValue})
+			}
+		}
+
+		parents := tx.Session(&gorm.Session{NewDB: true}).Table(s.Table).
+			Clauses(clause.Select{Columns: primaryKeys}, clause.Where{Exprs: parentConds})
+		conds = append(conds, clause.Expr{SQL: "? IN (?)", Vars: []interface{}{foreignKeys, parents}})
+
+		if field := childSd.deletedAtField(); deletedAt != nil && field != nil {
+			column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+			conds = append(conds,
+				clause.Gte{Column: column, Value: childSd.deletedAtOf(deletedAt.Add(-config.tolerance))},
+				clause.Lte{Column: column, Value: childSd.deletedAtOf(deletedAt.Add(config.tolerance))},
+			)
+		} else {
+			stats.Warnings = append(stats.Warnings, fmt.Sprintf("soft_delete: no deletion time to compare for %s, restored all deleted rows", rel.FieldSchema.Table))
+		}
+
+		next := cascadeState{depth: state.depth + 1, path: append(append([]*schema.Schema{}, state.path...), rel.FieldSchema)}
+		if err := restoreChildren(tx, rel.FieldSchema, conds, deletedAt, config, next, stats); err != nil {
+			return err
+		}
+
+		child := reflect.New(rel.FieldSchema.ModelType).Interface()
+		result := tx.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Model(child).Omit(clause.Associations).
+			Clauses(clause.Where{Exprs: conds}, RestoreClause{}).UpdateColumns(map[string]interface{}{})
+		if result.Error != nil {
+			return result.Error
+		}
+		stats.Children[rel.FieldSchema.Table] += result.RowsAffected
+	}
+	return nil
+}
+
+// deletedTime 将 deletedAtField 中存储的删除时间转换为 time.Time
+func (sd SoftDeleteDeleteClause) deletedTime(ctx context.Context, field *schema.Field, value reflect.Value) (time.Time, bool) {
+	v, zero := field.ValueOf(ctx, value)
+	if zero {
+		return time.Time{}, false
+	}
+
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case *time.Time:
+		return *t, true
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return sd.unixToTime(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return sd.unixToTime(int64(rv.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		return sd.unixToTime(int64(rv.Float())), true
+	}
+	return time.Time{}, false
+}
+
+func (sd SoftDeleteDeleteClause) unixToTime(n int64) time.Time {
+	switch sd.TimeType {
+	case schema.UnixNanosecond:
+		return time.Unix(0, n)
+	case schema.UnixMillisecond:
+		return time.UnixMilli(n)
+	default:
+		return time.Unix(n, 0)
+	}
+}
@@ -0,0 +1,178 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Parent struct {
+	ID        uint
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+	Children  []Child      `gorm:"softDeleteCascade"`
+	Profile   ParentDetail `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type Child struct {
+	ID        uint
+	ParentID  uint
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+	Toys      []Toy `gorm:"softDeleteCascade"`
+}
+
+type ParentDetail struct {
+	ID        uint
+	ParentID  uint
+	Note      string
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+type Toy struct {
+	ID        uint
+	ChildID   uint
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+func seedFamily(t *testing.T, db *gorm.DB) Parent {
+	t.Helper()
+	parent := Parent{
+		Children: []Child{{Toys: []Toy{{}, {}}}, {}},
+		Profile:  ParentDetail{Note: "n"},
+	}
+	if err := db.Create(&parent).Error; err != nil {
+		t.Fatal(err)
+	}
+	return parent
+}
+
+func activeCounts(db *gorm.DB) (children, details, toys int64) {
+	db.Model(&Child{}).Count(&children)
+	db.Model(&ParentDetail{}).Count(&details)
+	db.Model(&Toy{}).Count(&toys)
+	return
+}
+
+// has many、has one 以及下一层的子记录与父记录一起软删除
+func TestCascadeDelete(t *testing.T) {
+	db := openDB(t, &Parent{}, &Child{}, &ParentDetail{}, &Toy{})
+	parent := seedFamily(t, db)
+	other := seedFamily(t, db)
+
+	if err := db.Delete(&parent).Error; err != nil {
+		t.Fatal(err)
+	}
+	children, details, toys := activeCounts(db)
+	if children != 2 || details != 1 || toys != 2 {
+		t.Errorf("active children/details/toys = %d/%d/%d, want 2/1/2", children, details, toys)
+	}
+	if n := countRows(t, db, "children"); n != 4 {
+		t.Errorf("children rows = %d, cascade should soft delete", n)
+	}
+
+	var kept []Child
+	db.Where("parent_id = ?", other.ID).Find(&kept)
+	if len(kept) != 2 {
+		t.Errorf("children of other parent = %+v", kept)
+	}
+}
+
+// CascadeDepth 限制级联的层数，0 关闭级联
+func TestCascadeDepth(t *testing.T) {
+	db := openDB(t, &Parent{}, &Child{}, &ParentDetail{}, &Toy{})
+	config := DefaultConfig()
+	config.CascadeDepth = 1
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	parent := seedFamily(t, db)
+	db.Delete(&parent)
+
+	children, details, toys := activeCounts(db)
+	if children != 0 || details != 0 || toys != 2 {
+		t.Errorf("active children/details/toys = %d/%d/%d, want 0/0/2", children, details, toys)
+	}
+}
+
+type Node struct {
+	ID       uint
+	ParentID *uint
+	Deleted  DeletedAt `gorm:"softDelete:flag"`
+	Others   []Other   `gorm:"softDeleteCascade"`
+}
+
+type Other struct {
+	ID      uint
+	NodeID  uint
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+	Nodes   []Node    `gorm:"foreignKey:ParentID;softDeleteCascade"`
+}
+
+// Node、Other 互相级联时不会无限递归
+func TestCascadeCycle(t *testing.T) {
+	db := openDB(t, &Node{}, &Other{})
+	node := Node{Others: []Other{{}}}
+	db.Create(&node)
+	if err := db.Delete(&node).Error; err != nil {
+		t.Fatal(err)
+	}
+	var others int64
+	db.Model(&Other{}).Count(&others)
+	if others != 0 {
+		t.Errorf("active others = %d, want 0", others)
+	}
+}
+
+// 与父记录一起删除的子记录随父记录恢复，之前单独删除的子记录保持删除
+func TestCascadeRestore(t *testing.T) {
+	db := openDB(t, &Parent{}, &Child{}, &ParentDetail{}, &Toy{})
+	parent := seedFamily(t, db)
+
+	at := func(t time.Time) *gorm.DB {
+		return db.Session(&gorm.Session{NowFunc: func() time.Time { return t }})
+	}
+	at(testNow.Add(-time.Hour)).Delete(&parent.Children[1])
+	at(testNow).Delete(&parent)
+
+	var stats RestoreStats
+	n, err := Restore(db, &parent, Cascade(), CascadeStats(&stats))
+	if err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	children, details, toys := activeCounts(db)
+	if children != 1 || details != 1 || toys != 2 {
+		t.Errorf("active children/details/toys = %d/%d/%d, want 1/1/2", children, details, toys)
+	}
+	if stats.Children["children"] != 1 || stats.Children["toys"] != 2 || len(stats.Warnings) != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	var early Child
+	db.Unscoped().First(&early, parent.Children[1].ID)
+	if !early.Deleted {
+		t.Error("child deleted earlier was restored")
+	}
+}
+
+// 没有删除时间时恢复全部已删除的子记录，并在 RestoreStats 中给出警告
+func TestCascadeRestoreWithoutDeletedAt(t *testing.T) {
+	db := openDB(t, &Node{}, &Other{})
+	node := Node{Others: []Other{{}, {}}}
+	db.Create(&node)
+	db.Delete(&node.Others[0])
+	db.Delete(&node)
+
+	var stats RestoreStats
+	if _, err := Restore(db, &node, Cascade(), CascadeStats(&stats)); err != nil {
+		t.Fatal(err)
+	}
+	var others int64
+	db.Model(&Other{}).Count(&others)
+	if others != 2 || len(stats.Warnings) != 1 {
+		t.Errorf("active others = %d, warnings = %v", others, stats.Warnings)
+	}
+}
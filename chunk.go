@@ -0,0 +1,99 @@
+package soft_delete
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// chunkConnPool 使 gorm:delete 执行第一批时得到的 RowsAffected 包含其余批次的行数，不需要在 db 上注册回调。
+// 执行一次后恢复语句原来的 ConnPool
+type chunkConnPool struct {
+	gorm.ConnPool
+	stmt *gorm.Statement
+	rows int64
+}
+
+func (p *chunkConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	p.stmt.ConnPool = p.ConnPool
+	result, err := p.ConnPool.ExecContext(ctx, query, args...)
+	if err != nil {
+		return result, err
+	}
+	return chunkResult{Result: result, rows: p.rows}, nil
+}
+
+// chunkTxPool 在事务中替换 ConnPool，gorm 出错时不执行第一批，仍能通过它提交或回滚事务
+type chunkTxPool struct {
+	*chunkConnPool
+	tx gorm.TxCommitter
+}
+
+func (p *chunkTxPool) Commit() error {
+	return p.tx.Commit()
+}
+
+func (p *chunkTxPool) Rollback() error {
+	return p.tx.Rollback()
+}
+
+type chunkResult struct {
+	sql.Result
+	rows int64
+}
+
+func (r chunkResult) RowsAffected() (int64, error) {
+	n, err := r.Result.RowsAffected()
+	return n + r.rows, err
+}
+
+// canChunk 判断语句能否分为多条执行：DryRun 只生成 SQL，RETURNING 需要扫描一条语句的结果，LIMIT 会作用于每一批。
+// 没有通过 Use 设置时按 DefaultConfig 的 KeyBatchSize 分批
+func canChunk(stmt *gorm.Statement) bool {
+	_, returning := stmt.Clauses["RETURNING"]
+	return !stmt.DB.DryRun && !returning && !hasClause(stmt, "LIMIT")
+}
+
+// execChunks 在第一批的语句构建完成后，依次构建并执行其余批次，再重新构建第一批交给 gorm:delete 执行。
+// 其余批次与 gorm 使用同一个 ConnPool，在调用方或 gorm 默认的事务中执行，任何一批出错时 gorm 不再执行第一批。
+// 其余批次的行数由 chunkConnPool 加到第一批的结果中
+func (k *keyCondition) execChunks(stmt *gorm.Statement, clauses []string) {
+	if len(k.conds) < 2 {
+		return
+	}
+
+	var total int64
+	for k.current = 1; k.current < len(k.conds) && stmt.Error == nil; k.current++ {
+		stmt.SQL.Reset()
+		stmt.Vars = nil
+		stmt.Build(clauses...)
+
+		begin, sql := time.Now(), stmt.SQL.String()
+		var rows int64
+		result, err := stmt.ConnPool.ExecContext(stmt.Context, sql, stmt.Vars...)
+		if err == nil {
+			rows, _ = result.RowsAffected()
+			total += rows
+		}
+		stmt.DB.Logger.Trace(stmt.Context, begin, func() (string, int64) {
+			return stmt.DB.Dialector.Explain(sql, stmt.Vars...), rows
+		}, err)
+		stmt.AddError(err)
+	}
+	k.current = 0
+
+	stmt.SQL.Reset()
+	stmt.Vars = nil
+	stmt.Build(clauses...)
+	if stmt.Error != nil {
+		return
+	}
+	pool := &chunkConnPool{ConnPool: stmt.ConnPool, stmt: stmt, rows: total}
+	if tx, ok := stmt.ConnPool.(gorm.TxCommitter); ok {
+		stmt.ConnPool = &chunkTxPool{chunkConnPool: pool, tx: tx}
+	} else {
+		stmt.ConnPool = pool
+	}
+}
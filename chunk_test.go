@@ -0,0 +1,150 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type ChunkUser struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+// sqliteMaxVariables 为 SQLite 一条语句中参数个数的上限
+const sqliteMaxVariables = 32766
+
+func seedChunkUsers(t *testing.T, db *gorm.DB, n int) []ChunkUser {
+	t.Helper()
+	users := make([]ChunkUser, n)
+	if err := db.CreateInBatches(&users, 5000).Error; err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return users
+}
+
+func TestChunkedDeleteOverVariableLimit(t *testing.T) {
+	db := openDB(t, &ChunkUser{})
+	if err := Use(db, DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	n := sqliteMaxVariables + 1000
+	users := seedChunkUsers(t, db, n)
+
+	tx := db.Delete(&users)
+	if tx.Error != nil {
+		t.Fatalf("delete: %v", tx.Error)
+	}
+	if tx.RowsAffected != int64(n) {
+		t.Errorf("RowsAffected = %d, want %d", tx.RowsAffected, n)
+	}
+	var active int64
+	db.Model(&ChunkUser{}).Count(&active)
+	if active != 0 {
+		t.Errorf("%d rows still active", active)
+	}
+}
+
+func TestChunkedDeleteInTransaction(t *testing.T) {
+	db := openDB(t, &ChunkUser{})
+	config := DefaultConfig()
+	config.KeyBatchSize = 2
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	users := seedChunkUsers(t, db, 5)
+
+	var executed []string
+	db.Callback().Delete().After("gorm:delete").Register("test:rows", func(tx *gorm.DB) {
+		executed = append(executed, tx.Statement.SQL.String())
+	})
+
+	rollback := errors.New("rollback")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&users)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected != 5 {
+			t.Errorf("RowsAffected = %d, want 5", result.RowsAffected)
+		}
+		var active int64
+		tx.Model(&ChunkUser{}).Count(&active)
+		if active != 0 {
+			t.Errorf("%d rows active inside transaction", active)
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatalf("transaction: %v", err)
+	}
+	if len(executed) != 1 {
+		t.Errorf("delete callbacks ran %d times, want 1", len(executed))
+	}
+
+	var active int64
+	db.Model(&ChunkUser{}).Count(&active)
+	if active != 5 {
+		t.Errorf("active = %d after rollback, want 5", active)
+	}
+	// 第一批交给 gorm 执行，执行后恢复原来的 ConnPool
+	assertContains(t, executed[0], "`chunk_users`.`id` IN (?,?)")
+}
+
+// 没有调用 Use 的 db 同样按默认的 KeyBatchSize 分批，RowsAffected 包含所有批次
+func TestDeleteWithoutUseChunks(t *testing.T) {
+	db := openDB(t, &ChunkUser{})
+	users := seedChunkUsers(t, db, 2500)
+
+	recorder := &sqlRecorder{Interface: db.Logger}
+	tx := db.Session(&gorm.Session{Logger: recorder}).Delete(&users)
+	if tx.Error != nil {
+		t.Fatalf("delete: %v", tx.Error)
+	}
+	if tx.RowsAffected != 2500 {
+		t.Errorf("RowsAffected = %d, want 2500", tx.RowsAffected)
+	}
+	if len(recorder.sqls) != 3 {
+		t.Errorf("executed %d statements, want 3", len(recorder.sqls))
+	}
+	if _, ok := tx.Statement.ConnPool.(*chunkConnPool); ok {
+		t.Error("ConnPool left wrapped after delete")
+	}
+	var active int64
+	db.Model(&ChunkUser{}).Count(&active)
+	if active != 0 {
+		t.Errorf("%d rows still active", active)
+	}
+}
+
+// 第一批没有执行时，事务仍能通过替换后的 ConnPool 回滚
+func TestChunkedDeleteErrorRollsBack(t *testing.T) {
+	db := openDB(t, &ChunkUser{})
+	config := DefaultConfig()
+	config.KeyBatchSize = 2
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	users := seedChunkUsers(t, db, 5)
+
+	failure := errors.New("failure")
+	db.Callback().Delete().Replace("gorm:delete", func(delete func(*gorm.DB)) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			for _, c := range tx.Statement.Schema.DeleteClauses {
+				tx.Statement.AddClause(c)
+			}
+			tx.AddError(failure)
+			delete(tx)
+		}
+	}(db.Callback().Delete().Get("gorm:delete")))
+
+	if err := db.Delete(&users).Error; !errors.Is(err, failure) {
+		t.Fatalf("delete error = %v", err)
+	}
+	var active int64
+	db.Model(&ChunkUser{}).Count(&active)
+	if active != 5 {
+		t.Errorf("active = %d, want 5 after rollback", active)
+	}
+}
@@ -0,0 +1,83 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ClockUser struct {
+	ID        uint
+	Name      string
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+	UpdatedAt time.Time
+}
+
+// clockDB 返回当前时间由 clock 决定的 db
+func clockDB(t *testing.T, clock *time.Time, models ...interface{}) *gorm.DB {
+	t.Helper()
+	return openDB(t, models...).Session(&gorm.Session{NowFunc: func() time.Time { return *clock }})
+}
+
+// 删除、恢复、清理截止时间和过期判断都使用 NowFunc，不读取本地时钟
+func TestFrozenClock(t *testing.T) {
+	clock := testNow
+	db := clockDB(t, &clock, &ClockUser{}, &UnixUser{}, &LoginSession{})
+
+	user := ClockUser{Name: "a"}
+	db.Create(&user)
+	unix := UnixUser{Name: "a"}
+	db.Create(&unix)
+
+	deletedAt := testNow.Add(time.Hour)
+	clock = deletedAt
+	db.Delete(&user)
+	db.Delete(&unix)
+	var got ClockUser
+	db.Unscoped().First(&got, user.ID)
+	if got.DeletedAt == nil || !got.DeletedAt.Equal(deletedAt) || !got.UpdatedAt.Equal(deletedAt) {
+		t.Errorf("after delete deleted_at = %v, updated_at = %v, want %v", got.DeletedAt, got.UpdatedAt, deletedAt)
+	}
+	var gotUnix UnixUser
+	db.Unscoped().First(&gotUnix, unix.ID)
+	if int64(gotUnix.DeletedAt) != deletedAt.Unix() {
+		t.Errorf("unix deleted_at = %d, want %d", gotUnix.DeletedAt, deletedAt.Unix())
+	}
+
+	restoredAt := deletedAt.Add(time.Hour)
+	clock = restoredAt
+	if _, err := Restore(db, &ClockUser{ID: user.ID}); err != nil {
+		t.Fatal(err)
+	}
+	got = ClockUser{}
+	db.First(&got, user.ID)
+	if got.DeletedAt != nil || !got.UpdatedAt.Equal(restoredAt) {
+		t.Errorf("after restore deleted_at = %v, updated_at = %v, want nil and %v", got.DeletedAt, got.UpdatedAt, restoredAt)
+	}
+
+	// 截止时间为 NowFunc 减去保留期，删除时间早于截止时间的记录才被清理
+	db.Delete(&got)
+	retention := 24 * time.Hour
+	clock = restoredAt.Add(retention)
+	if n, err := Purge(db, &ClockUser{}, Older(retention)); err != nil || n != 0 {
+		t.Errorf("purge at the cutoff = %d, %v, want 0", n, err)
+	}
+	clock = restoredAt.Add(retention + time.Second)
+	if n, err := Purge(db, &ClockUser{}, Older(retention)); err != nil || n != 1 {
+		t.Errorf("purge after the cutoff = %d, %v, want 1", n, err)
+	}
+
+	session := LoginSession{Token: "a", ExpiresAt: expiresAt(testNow.Add(time.Minute))}
+	db.Create(&session)
+	clock = testNow
+	var sessions []LoginSession
+	if db.Find(&sessions); len(sessions) != 1 || session.ExpiresAt.ExpiredAt(clock) {
+		t.Errorf("sessions before expiry = %+v, want one", sessions)
+	}
+	clock = testNow.Add(time.Minute)
+	if db.Find(&sessions); len(sessions) != 0 || !session.ExpiresAt.ExpiredAt(clock) {
+		t.Errorf("sessions at expiry = %+v, want none", sessions)
+	}
+}
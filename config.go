@@ -0,0 +1,89 @@
+package soft_delete
+
+import (
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+const configPluginName = "soft_delete:config"
+
+// Config 为一个 *gorm.DB 上软删除的行为，通过 Use 设置后不可修改。
+// 同一进程中的多个 *gorm.DB 可以使用不同的 Config，没有设置的 db 使用 DefaultConfig
+//
+//	config := soft_delete.DefaultConfig()
+//	config.ValueMode = soft_delete.ValueInt
+//	config.CascadeDepth = 1
+//	soft_delete.Use(db, config)
+type Config struct {
+	// ValueMode 为 ValueInt 时 bool 标记位在条件和写入中以 0/1 绑定
+	ValueMode ValueMode
+	// CascadeDepth 为级联软删除、恢复的最大层数，0 表示关闭级联
+	CascadeDepth int
+	// KeyBatchSize 为按主键删除、恢复、清理时一条语句中的主键数，超过时分为多条语句执行。
+	// 没有通过 Use 设置 Config 的 db 使用 SetKeyBatchSize 设置的值，默认 1000
+	KeyBatchSize int
+	// HookMode 决定软删除时调用模型的哪些钩子，见 SetHookMode
+	HookMode HookMode
+	// Strict 使 Restore 默认带有 Strict 选项
+	Strict bool
+	// Events 的处理函数对 db 上注册的每个 Events 插件都生效，nil 时只调用插件自身的处理函数
+	Events *Events
+
+	// joinTables 记录通过 SetupJoinTable 注册的连接表 schema，Use 时为每个 db 新建
+	joinTables *sync.Map
+}
+
+// defaultJoinTables 为没有通过 Use 设置 Config 的 db 注册的连接表
+var defaultJoinTables sync.Map
+
+// DefaultConfig 返回由包级变量决定的默认配置：SetValueMode、SetCascadeDepth、SetKeyBatchSize 设置的值，
+// 以及 OnDelete、OnRestore 添加的全局处理函数
+func DefaultConfig() Config {
+	return Config{
+		ValueMode:    valueMode,
+		CascadeDepth: cascadeDepth,
+		KeyBatchSize: keyBatchSize,
+		Events:       globalEvents,
+		joinTables:   &defaultJoinTables,
+	}
+}
+
+// configPlugin 以 gorm 插件的形式将 Config 保存在 db.Config.Plugins 中，语句通过 stmt.DB 读取
+type configPlugin struct {
+	config Config
+}
+
+func (p *configPlugin) Name() string {
+	return configPluginName
+}
+
+func (p *configPlugin) Initialize(db *gorm.DB) error {
+	if p.config.HookMode != HookDelete {
+		return SetHookMode(db, p.config.HookMode)
+	}
+	return nil
+}
+
+// Use 为 db 设置软删除的配置，config 在调用时复制，之后修改不影响 db。
+// 应在使用 db 执行语句、调用 SetupJoinTable 之前调用，每个 db 只能调用一次
+func Use(db *gorm.DB, config Config) error {
+	if config.KeyBatchSize <= 0 {
+		config.KeyBatchSize = defaultKeyBatchSize
+	}
+	if config.CascadeDepth < 0 {
+		config.CascadeDepth = 0
+	}
+	config.joinTables = new(sync.Map)
+	return db.Use(&configPlugin{config: config})
+}
+
+// configOf 返回 db 的配置，没有通过 Use 设置时为 DefaultConfig
+func configOf(db *gorm.DB) Config {
+	if db != nil && db.Config != nil {
+		if p, ok := db.Config.Plugins[configPluginName].(*configPlugin); ok {
+			return p.config
+		}
+	}
+	return DefaultConfig()
+}
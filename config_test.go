@@ -0,0 +1,104 @@
+package soft_delete
+
+import (
+	"sync"
+	"testing"
+)
+
+// 两个配置不同的 db 并发使用，使用 -race 运行
+func TestConfigPerDB(t *testing.T) {
+	boolDB := openDB(t, &User{})
+	intDB := openDB(t)
+	config := DefaultConfig()
+	config.ValueMode = ValueInt
+	if err := Use(intDB, config); err != nil {
+		t.Fatal(err)
+	}
+	intDB.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, deleted INTEGER NOT NULL DEFAULT 0)")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			user := User{Name: "bool"}
+			if err := boolDB.Create(&user).Error; err != nil {
+				t.Error(err)
+				return
+			}
+			if err := boolDB.Delete(&user).Error; err != nil {
+				t.Error(err)
+			}
+			boolDB.Find(&[]User{})
+		}()
+		go func() {
+			defer wg.Done()
+			user := User{Name: "int"}
+			if err := intDB.Create(&user).Error; err != nil {
+				t.Error(err)
+				return
+			}
+			if err := intDB.Delete(&user).Error; err != nil {
+				t.Error(err)
+			}
+			intDB.Find(&[]User{})
+		}()
+	}
+	wg.Wait()
+
+	// ValueInt 的 db 插入、删除都写入整数
+	var values []interface{}
+	intDB.Table("users").Distinct().Pluck("typeof(deleted)", &values)
+	if len(values) != 1 || values[0] != "integer" {
+		t.Errorf("stored types = %v, want [integer]", values)
+	}
+}
+
+// 插入时的默认值按 db 的配置绑定
+func TestConfigColumnDefault(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+	tx := db.Create(&User{Name: "a"})
+	if vars := tx.Statement.Vars; len(vars) != 2 || vars[1] != false {
+		t.Errorf("Vars = %#v, want [a false]", vars)
+	}
+
+	db = dryRunDB(t, "sqlite")
+	config := DefaultConfig()
+	config.ValueMode = ValueInt
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	user := User{Name: "a"}
+	tx = db.Create(&user)
+	if vars := tx.Statement.Vars; len(vars) != 2 || vars[1] != int64(0) {
+		t.Errorf("ValueInt Vars = %#v, want [a 0]", vars)
+	}
+	if user.Deleted.IsDeleted() {
+		t.Errorf("Deleted = %v in memory", user.Deleted)
+	}
+}
+
+// SetupJoinTable 注册的连接表只对该 db 生效
+func TestConfigJoinTablesPerDB(t *testing.T) {
+	first, second := openDB(t), openDB(t)
+	if err := Use(first, DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if err := Use(second, DefaultConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetupJoinTable(first, &Member{}, "Tags", &MemberTag{}); err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(m *sync.Map) (n int) {
+		m.Range(func(_, _ interface{}) bool { n++; return true })
+		return
+	}
+	if n := count(configOf(first).joinTables); n != 1 {
+		t.Errorf("first join tables = %d, want 1", n)
+	}
+	if n := count(configOf(second).joinTables); n != 0 {
+		t.Errorf("second join tables = %d, want 0", n)
+	}
+}
@@ -0,0 +1,252 @@
+package soft_delete
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrRestoreConflict 为恢复的记录与未删除的记录违反唯一约束，Restore 使用 CheckConflicts 时返回
+//
+//	var conflict *soft_delete.ErrRestoreConflict
+//	if errors.As(err, &conflict) {
+//		log.Printf("%v taken by %v", conflict.Columns, conflict.PrimaryKey)
+//	}
+type ErrRestoreConflict struct {
+	Table string
+	// Columns 为冲突的唯一列
+	Columns []string
+	// PrimaryKey 为占用了唯一值的未删除记录的主键，联合主键时为列名到值的 map
+	PrimaryKey interface{}
+}
+
+func (e *ErrRestoreConflict) Error() string {
+	return fmt.Sprintf("soft_delete: restoring %s conflicts on (%s) with active record %v", e.Table, strings.Join(e.Columns, ", "), e.PrimaryKey)
+}
+
+type conflictConfig struct {
+	// sets 为检查的唯一列，为空时取模型的唯一字段和唯一索引
+	sets [][]string
+	// column 非空时冲突不返回错误，而是修改被恢复记录的该列
+	column string
+	suffix string
+}
+
+// CheckConflicts 在恢复之前检查被恢复的记录是否与未删除的记录违反唯一约束，冲突时返回 *ErrRestoreConflict。
+// columns 为要检查的唯一列组，不传时使用模型中 unique 字段和 uniqueIndex 索引，其中的软删除相关列会被忽略
+//
+//	soft_delete.Restore(db, &user, soft_delete.CheckConflicts([]string{"email"}))
+func CheckConflicts(columns ...[]string) RestoreOption {
+	return func(c *restoreConfig) {
+		if c.conflicts == nil {
+			c.conflicts = &conflictConfig{}
+		}
+		c.conflicts.sets = append(c.conflicts.sets, columns...)
+	}
+}
+
+// ResolveConflicts 检查唯一约束冲突，冲突的列组包含 column 时不返回错误，而是修改被恢复记录的 column 后再恢复：
+// suffix 非空时在原值后追加 suffix，为空时置为 NULL
+//
+//	soft_delete.Restore(db, &user, soft_delete.ResolveConflicts("email", ".restored"))
+func ResolveConflicts(column, suffix string) RestoreOption {
+	return func(c *restoreConfig) {
+		if c.conflicts == nil {
+			c.conflicts = &conflictConfig{}
+		}
+		c.conflicts.column, c.conflicts.suffix = column, suffix
+	}
+}
+
+// checkConflicts 按数据库中被恢复记录的当前值检查冲突，value 中可能只有主键
+func checkConflicts(tx *gorm.DB, value interface{}, config *conflictConfig) error {
+	s, sd, err := parseDeleteClause(tx, value)
+	if err != nil {
+		return err
+	}
+	sets := uniqueSets(s, sd)
+	if len(config.sets) > 0 {
+		if sets, err = dbNames(s, config.sets...); err != nil {
+			return err
+		}
+	}
+	resolveColumn := ""
+	if config.column != "" {
+		names, err := dbNames(s, []string{config.column})
+		if err != nil {
+			return err
+		}
+		resolveColumn = names[0][0]
+	}
+
+	_, keys := schema.GetIdentityFieldValuesMap(tx.Statement.Context, reflect.ValueOf(value), s.PrimaryFields)
+	if len(sets) == 0 || len(keys) == 0 {
+		return nil
+	}
+	column, values := schema.ToQueryValues(clause.CurrentTable, s.PrimaryFieldDBNames, keys)
+
+	rows := reflect.New(reflect.SliceOf(s.ModelType))
+	if err := tx.Session(&gorm.Session{NewDB: true}).Model(reflect.New(s.ModelType).Interface()).Scopes(OnlyDeleted).
+		Clauses(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}}).Find(rows.Interface()).Error; err != nil {
+		return err
+	}
+
+	for i := 0; i < rows.Elem().Len(); i++ {
+		row := rows.Elem().Index(i)
+		for _, set := range sets {
+			blocker, err := findConflict(tx, s, row, set)
+			if err != nil {
+				return err
+			}
+			if !blocker.IsValid() {
+				continue
+			}
+			if resolveColumn == "" || !containsColumn(set, resolveColumn) {
+				return &ErrRestoreConflict{Table: s.Table, Columns: set, PrimaryKey: primaryKeyOf(tx, s, blocker)}
+			}
+			if err := resolveConflict(tx, s, value, row, s.LookUpField(resolveColumn), config.suffix); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// findConflict 返回与 row 在 set 上取值相同的另一条未删除记录，set 中有 NULL 时不会冲突
+func findConflict(tx *gorm.DB, s *schema.Schema, row reflect.Value, set []string) (reflect.Value, error) {
+	exprs := make([]clause.Expression, 0, len(set)+1)
+	for _, name := range set {
+		field := s.LookUpField(name)
+		value, _ := field.ValueOf(tx.Statement.Context, row)
+		if value == nil || reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil() {
+			return reflect.Value{}, nil
+		}
+		exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: value})
+	}
+
+	self := make([]clause.Expression, 0, len(s.PrimaryFields))
+	for _, pk := range s.PrimaryFields {
+		value, _ := pk.ValueOf(tx.Statement.Context, row)
+		self = append(self, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Value: value})
+	}
+	exprs = append(exprs, clause.Not(clause.And(self...)))
+
+	blocker := reflect.New(s.ModelType)
+	err := tx.Session(&gorm.Session{NewDB: true}).Model(blocker.Interface()).Clauses(clause.Where{Exprs: exprs}).Take(blocker.Interface()).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return reflect.Value{}, nil
+	}
+	return blocker.Elem(), err
+}
+
+// resolveConflict 修改被恢复记录的列，并同步到 value 中主键相同的记录
+func resolveConflict(tx *gorm.DB, s *schema.Schema, value interface{}, row reflect.Value, field *schema.Field, suffix string) error {
+	var resolved interface{}
+	if suffix != "" {
+		current, _ := field.ValueOf(tx.Statement.Context, row)
+		resolved = fmt.Sprint(reflect.Indirect(reflect.ValueOf(current)).Interface()) + suffix
+	}
+
+	if err := tx.Session(&gorm.Session{NewDB: true}).Model(row.Addr().Interface()).Clauses(Skip{}).
+		UpdateColumn(field.DBName, resolved).Error; err != nil {
+		return err
+	}
+
+	rowKeys, _ := schema.GetIdentityFieldValuesMap(tx.Statement.Context, row, s.PrimaryFields)
+	valueKeys, _ := schema.GetIdentityFieldValuesMap(tx.Statement.Context, reflect.ValueOf(value), s.PrimaryFields)
+	for key := range rowKeys {
+		for _, rv := range valueKeys[key] {
+			if rv.CanAddr() {
+				if err := field.Set(tx.Statement.Context, rv, resolved); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// dbNames 将列组中的字段名或列名转换为列名
+func dbNames(s *schema.Schema, sets ...[]string) ([][]string, error) {
+	result := make([][]string, len(sets))
+	for i, set := range sets {
+		result[i] = make([]string, len(set))
+		for j, name := range set {
+			field := s.LookUpField(name)
+			if field == nil {
+				return nil, fmt.Errorf("soft_delete: column %q not found in schema %s", name, s.Name)
+			}
+			result[i][j] = field.DBName
+		}
+	}
+	return result, nil
+}
+
+// uniqueSets 返回模型中 unique 字段和 uniqueIndex 索引的列组，忽略软删除相关的列和包含主键的列组
+func uniqueSets(s *schema.Schema, sd SoftDeleteDeleteClause) [][]string {
+	ignored := map[string]bool{sd.Field.DBName: true}
+	for _, field := range sd.companionFields() {
+		ignored[field.DBName] = true
+	}
+	for _, name := range s.PrimaryFieldDBNames {
+		ignored[name] = true
+	}
+
+	var sets [][]string
+	add := func(fields []*schema.Field) {
+		set := make([]string, 0, len(fields))
+		for _, field := range fields {
+			if field == nil || field.PrimaryKey {
+				return
+			}
+			if !ignored[field.DBName] {
+				set = append(set, field.DBName)
+			}
+		}
+		if len(set) > 0 {
+			sets = append(sets, set)
+		}
+	}
+
+	for _, field := range s.Fields {
+		if field.Unique {
+			add([]*schema.Field{field})
+		}
+	}
+	for _, index := range s.ParseIndexes() {
+		if index.Class == "UNIQUE" {
+			fields := make([]*schema.Field, len(index.Fields))
+			for i, option := range index.Fields {
+				fields[i] = option.Field
+			}
+			add(fields)
+		}
+	}
+	return sets
+}
+
+func primaryKeyOf(tx *gorm.DB, s *schema.Schema, row reflect.Value) interface{} {
+	if len(s.PrimaryFields) == 1 {
+		value, _ := s.PrimaryFields[0].ValueOf(tx.Statement.Context, row)
+		return value
+	}
+	key := make(map[string]interface{}, len(s.PrimaryFields))
+	for _, pk := range s.PrimaryFields {
+		key[pk.DBName], _ = pk.ValueOf(tx.Statement.Context, row)
+	}
+	return key
+}
+
+func containsColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
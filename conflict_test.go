@@ -0,0 +1,81 @@
+package soft_delete
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// Customer 的唯一索引包含标记位，已删除的记录与未删除的记录可以有相同的邮箱，恢复时才会冲突
+type Customer struct {
+	ID      uint
+	Email   string    `gorm:"uniqueIndex:idx_customer_email"`
+	Deleted DeletedAt `gorm:"softDelete:flag;uniqueIndex:idx_customer_email"`
+}
+
+func TestRestoreWithoutConflict(t *testing.T) {
+	db := openDB(t, &Customer{})
+	c := Customer{Email: "a@example.com"}
+	db.Create(&c)
+	db.Delete(&c)
+
+	n, err := Restore(db, &Customer{ID: c.ID}, CheckConflicts())
+	if err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+}
+
+func TestRestoreConflict(t *testing.T) {
+	db := openDB(t, &Customer{})
+	old := Customer{Email: "a@example.com"}
+	db.Create(&old)
+	db.Delete(&old)
+	taken := Customer{Email: "a@example.com"}
+	if err := db.Create(&taken).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for name, opt := range map[string]RestoreOption{
+		"unique index": CheckConflicts(),
+		"explicit":     CheckConflicts([]string{"Email"}),
+	} {
+		_, err := Restore(db, &Customer{ID: old.ID}, opt)
+		var conflict *ErrRestoreConflict
+		if !errors.As(err, &conflict) {
+			t.Fatalf("%s: err = %v, want *ErrRestoreConflict", name, err)
+		}
+		want := &ErrRestoreConflict{Table: "customers", Columns: []string{"email"}, PrimaryKey: taken.ID}
+		if !reflect.DeepEqual(conflict, want) {
+			t.Errorf("%s: conflict = %+v, want %+v", name, conflict, want)
+		}
+	}
+
+	var deleted Customer
+	db.Scopes(OnlyDeleted).First(&deleted, old.ID)
+	if deleted.ID != old.ID {
+		t.Error("conflicting record should stay deleted")
+	}
+}
+
+func TestRestoreResolveConflict(t *testing.T) {
+	db := openDB(t, &Customer{})
+	old := Customer{Email: "a@example.com"}
+	db.Create(&old)
+	db.Delete(&old)
+	db.Create(&Customer{Email: "a@example.com"})
+
+	restored := Customer{ID: old.ID}
+	n, err := Restore(db, &restored, ResolveConflicts("email", ".restored"))
+	if err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	if restored.Email != "a@example.com.restored" {
+		t.Errorf("Email = %q in memory", restored.Email)
+	}
+
+	var found Customer
+	db.First(&found, old.ID)
+	if found.Email != "a@example.com.restored" {
+		t.Errorf("Email = %q in database", found.Email)
+	}
+}
@@ -0,0 +1,36 @@
+package soft_delete
+
+import "context"
+
+type actorKey struct{}
+
+// WithActor 在 context 中记录执行删除的操作人，配置了 DeletedByField 时会写入该字段
+func WithActor(ctx context.Context, actor interface{}) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext 返回 WithActor 记录的操作人
+func ActorFromContext(ctx context.Context) (interface{}, bool) {
+	if ctx == nil {
+		return nil, false
+	}
+	actor := ctx.Value(actorKey{})
+	return actor, actor != nil
+}
+
+type includeDeletedKey struct{}
+
+// IncludeDeletedContext 返回的 context 上执行的查询都会包含已软删除的记录，删除仍然是软删除
+//
+//	db.WithContext(soft_delete.IncludeDeletedContext(ctx)).Find(&users)
+func IncludeDeletedContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, includeDeletedKey{}, true)
+}
+
+func isIncludeDeleted(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	include, _ := ctx.Value(includeDeletedKey{}).(bool)
+	return include
+}
@@ -0,0 +1,88 @@
+package soft_delete
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type ActorUser struct {
+	ID        uint
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedByField:DeletedBy"`
+	DeletedBy string
+}
+
+// 不同操作人的删除写入各自的 deleted_by，没有操作人时不修改该列
+func TestDeletedByFromContext(t *testing.T) {
+	db := openDB(t, &ActorUser{})
+	alice, bob, nobody := ActorUser{}, ActorUser{}, ActorUser{DeletedBy: "keep"}
+	for _, row := range []*ActorUser{&alice, &bob, &nobody} {
+		db.Create(row)
+	}
+
+	db.WithContext(WithActor(context.Background(), "alice")).Delete(&alice)
+	db.WithContext(WithActor(context.Background(), "bob")).Delete(&bob)
+	db.Delete(&nobody)
+	if alice.DeletedBy != "alice" || bob.DeletedBy != "bob" {
+		t.Errorf("in-memory deleted_by = %q, %q", alice.DeletedBy, bob.DeletedBy)
+	}
+
+	var rows []ActorUser
+	db.Unscoped().Order("id").Find(&rows)
+	if len(rows) != 3 || rows[0].DeletedBy != "alice" || rows[1].DeletedBy != "bob" || rows[2].DeletedBy != "keep" {
+		t.Errorf("rows = %+v", rows)
+	}
+	for _, row := range rows {
+		if !row.Deleted {
+			t.Errorf("row %d not deleted", row.ID)
+		}
+	}
+}
+
+func TestActorFromContext(t *testing.T) {
+	if _, ok := ActorFromContext(context.Background()); ok {
+		t.Error("empty context has an actor")
+	}
+	if actor, ok := ActorFromContext(WithActor(context.Background(), 42)); !ok || actor != 42 {
+		t.Errorf("actor = %v, %v", actor, ok)
+	}
+}
+
+// 共享同一个 db 的两个 goroutine 按各自的 context 得到不同的结果，删除不受影响
+func TestIncludeDeletedContext(t *testing.T) {
+	db := openDB(t, &User{})
+	seedUsers(t, db)
+
+	var wg sync.WaitGroup
+	counts := make([]int, 2)
+	for i, ctx := range []context.Context{context.Background(), IncludeDeletedContext(context.Background())} {
+		wg.Add(1)
+		go func(i int, ctx context.Context) {
+			defer wg.Done()
+			for n := 0; n < 20; n++ {
+				var users []User
+				if err := db.WithContext(ctx).Find(&users).Error; err != nil {
+					t.Error(err)
+					return
+				}
+				if counts[i] != 0 && counts[i] != len(users) {
+					t.Errorf("goroutine %d saw %d then %d rows", i, counts[i], len(users))
+				}
+				counts[i] = len(users)
+			}
+		}(i, ctx)
+	}
+	wg.Wait()
+	if counts[0] != 2 || counts[1] != 3 {
+		t.Errorf("counts = %v, want [2 3]", counts)
+	}
+
+	user := User{Name: "d"}
+	db.Create(&user)
+	if err := db.WithContext(IncludeDeletedContext(context.Background())).Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if countRows(t, db, "users") != 4 {
+		t.Error("Delete with IncludeDeletedContext removed the row")
+	}
+}
@@ -0,0 +1,26 @@
+package soft_delete
+
+import "gorm.io/gorm"
+
+// Counts 为 Count 的统计结果
+type Counts struct {
+	Active  int64
+	Deleted int64
+	Total   int64
+}
+
+// Count 统计未删除与已删除的记录数，db 上已有的条件同样生效
+//
+//	counts, err := soft_delete.Count(db.Where("tenant_id = ?", tenantID), &User{})
+func Count(db *gorm.DB, model interface{}) (counts Counts, err error) {
+	tx := db.Model(model).Session(&gorm.Session{})
+
+	if err = tx.Scopes(WithDeleted).Count(&counts.Total).Error; err != nil {
+		return
+	}
+	if err = tx.Scopes(OnlyDeleted).Count(&counts.Deleted).Error; err != nil {
+		return
+	}
+	counts.Active = counts.Total - counts.Deleted
+	return
+}
@@ -0,0 +1,53 @@
+package soft_delete
+
+import "testing"
+
+func TestCount(t *testing.T) {
+	db := openDB(t, &User{}, &NullUser{})
+	seedUsers(t, db)
+
+	counts, err := Count(db, &User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != (Counts{Active: 2, Deleted: 1, Total: 3}) {
+		t.Errorf("counts = %+v", counts)
+	}
+
+	// 已有的条件同样生效
+	counts, err = Count(db.Where("name <> ?", "a"), &User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != (Counts{Active: 1, Deleted: 1, Total: 2}) {
+		t.Errorf("counts with condition = %+v", counts)
+	}
+
+	// 表别名
+	counts, err = Count(db.Table("users AS u").Where("u.name <> ?", "c"), &User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != (Counts{Active: 1, Deleted: 1, Total: 2}) {
+		t.Errorf("counts with alias = %+v", counts)
+	}
+}
+
+func TestCountNullDefault(t *testing.T) {
+	db := openDB(t, &NullUser{})
+	users := []NullUser{{Name: "a"}, {Name: "b"}}
+	db.Create(&users)
+	db.Delete(&users[0])
+
+	counts, err := Count(db, &NullUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != (Counts{Active: 1, Deleted: 1, Total: 2}) {
+		t.Errorf("counts = %+v", counts)
+	}
+
+	var n int64
+	assertContains(t, sqlOf(t, dryRun(db).Model(&NullUser{}).Scopes(OnlyDeleted).Count(&n)), "IS NOT NULL")
+	assertContains(t, sqlOf(t, dryRun(db).Model(&NullUser{}).Count(&n)), "IS NULL")
+}
@@ -0,0 +1,90 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// SoftDeleteCreateClause 保证插入的记录为未删除：Select 限定列或以 map 创建时，INSERT 中没有标记列，
+// 没有数据库默认值的表会写入 NULL，插入后立即被过滤掉。此时为每一行补上未删除的值。
+// 调用方显式设置了标记列时保持不变，Unscoped 插入不会添加本子句
+type SoftDeleteCreateClause struct {
+	Field *schema.Field
+}
+
+func (sd SoftDeleteCreateClause) Name() string {
+	return ClauseCreate
+}
+
+func (sd SoftDeleteCreateClause) Build(clause.Builder) {
+}
+
+func (sd SoftDeleteCreateClause) MergeClause(c *clause.Clause) {
+	c.Expression = sd
+}
+
+// ModifyStatement 此时 VALUES 还未生成，为其设置 Builder，在构建时补上标记列
+func (sd SoftDeleteCreateClause) ModifyStatement(stmt *gorm.Statement) {
+	recordClause(stmt, sd)
+	sd.applyOnConflictRestore(stmt)
+
+	active := activeValue(sd.Field, true)
+	if active == nil {
+		return
+	}
+
+	c := stmt.Clauses["VALUES"]
+	c.Name = "VALUES"
+	c.Builder = func(c clause.Clause, builder clause.Builder) {
+		c.Builder = nil
+		if values, ok := c.Expression.(clause.Values); ok && !hasColumn(values.Columns, sd.Field.DBName) {
+			c.Expression = sd.withActive(values, active)
+			if stmt, ok := builder.(*gorm.Statement); ok {
+				assignField(stmt, sd.Field, active)
+			}
+		}
+		c.Build(builder)
+	}
+	stmt.Clauses["VALUES"] = c
+}
+
+// withActive 返回加上标记列的 VALUES，不修改 values 原有的切片
+func (sd SoftDeleteCreateClause) withActive(values clause.Values, active interface{}) clause.Values {
+	columns := make([]clause.Column, 0, len(values.Columns)+1)
+	columns = append(columns, values.Columns...)
+	columns = append(columns, clause.Column{Name: sd.Field.DBName})
+
+	rows := make([][]interface{}, len(values.Values))
+	for i, row := range values.Values {
+		rows[i] = make([]interface{}, 0, len(row)+1)
+		rows[i] = append(rows[i], row...)
+		rows[i] = append(rows[i], sqlValue(active))
+	}
+	return clause.Values{Columns: columns, Values: rows}
+}
+
+func hasColumn(columns []clause.Column, name string) bool {
+	for _, column := range columns {
+		if column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func flagCreateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{SoftDeleteCreateClause{Field: f}}
+}
+
+func (DeletedAt) CreateClauses(f *schema.Field) []clause.Interface {
+	return flagCreateClauses(f)
+}
+
+func (ActiveFlag) CreateClauses(f *schema.Field) []clause.Interface {
+	return flagCreateClauses(f)
+}
+
+func (Field[T]) CreateClauses(f *schema.Field) []clause.Interface {
+	return flagCreateClauses(f)
+}
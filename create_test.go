@@ -0,0 +1,66 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// openNoDefaultDB 返回 users 表没有数据库默认值的 db，INSERT 中没有标记列时会写入 NULL
+func openNoDefaultDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openDB(t)
+	if err := db.Exec("CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT, `name` text, `deleted` numeric)").Error; err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// 插入中没有标记列时补上未删除的值，插入后立即可见
+func TestCreateActive(t *testing.T) {
+	db := openNoDefaultDB(t)
+
+	if err := db.Model(&User{}).Create(map[string]interface{}{"name": "map"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Select("name").Create(&User{Name: "select"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	batch := []User{{Name: "b1"}, {Name: "b2"}, {Name: "b3"}}
+	if err := db.Select("name").CreateInBatches(&batch, 2).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var n int64
+	db.Model(&User{}).Count(&n)
+	if n != 5 {
+		t.Errorf("visible = %d, want 5", n)
+	}
+	var nulls int64
+	db.Table("users").Where("deleted IS NULL").Count(&nulls)
+	if nulls != 0 {
+		t.Errorf("%d rows inserted with NULL", nulls)
+	}
+}
+
+// 显式插入已删除的记录时保持调用方的值
+func TestCreateDeleted(t *testing.T) {
+	db := openNoDefaultDB(t)
+
+	if err := db.Unscoped().Create(&User{Name: "a", Deleted: true}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&User{}).Create(map[string]interface{}{"name": "b", "deleted": true}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var n int64
+	db.Model(&User{}).Count(&n)
+	if n != 0 {
+		t.Errorf("visible = %d, want 0", n)
+	}
+	db.Scopes(OnlyDeleted).Model(&User{}).Count(&n)
+	if n != 2 {
+		t.Errorf("deleted = %d, want 2", n)
+	}
+}
@@ -0,0 +1,105 @@
+package soft_delete
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// bindColumnDefault 使没有声明 default 的标记列为 NOT NULL，并以未删除的值为默认值，
+// 迁移时生成 NOT NULL DEFAULT false，未指定标记的插入也写入未删除的值。
+// 声明了 default（包括 default:null）时保持不变。插入时写入的默认值与条件一样在构建语句时
+// 按 db 的 Config 和数据库绑定，ValueInt 的 db 写入 0 而不是 false；迁移时的默认值由 bindMigrationDefault 决定
+func bindColumnDefault(f *schema.Field, values flagValues) {
+	if f.HasDefaultValue || values.active == nil {
+		return
+	}
+	f.NotNull = !values.nullable
+	f.HasDefaultValue = true
+	f.DefaultValue = fmt.Sprint(values.active)
+	f.DefaultValueInterface = sqlValue(values.active)
+}
+
+// bindMigrationDefault 使迁移生成的默认值与 db 绑定的标记值一致：gorm 的 Migrator 以 DefaultValueInterface 生成 DEFAULT，
+// 无法得知 db 的 Config，以整数绑定的 db 在这里换为整数，生成 DEFAULT 0 而不是 false。
+// schema 按 gorm.Open 缓存，与 Use 设置的 Config 一一对应，替换后插入时绑定的值不变
+func bindMigrationDefault(db *gorm.DB, f *schema.Field) {
+	if active, ok := f.DefaultValueInterface.(boolValue); ok && intFlags(db) {
+		f.DefaultValueInterface = boolInt(bool(active))
+		f.DefaultValue = fmt.Sprint(f.DefaultValueInterface)
+	}
+}
+
+// boolInt 返回 bool 对应的整数 1/0
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// flagDBDataType 按数据库和字段的取值返回标记列的类型：bool 取值在 MySQL 为 TINYINT(1)、
+// PostgreSQL 为 BOOLEAN、SQL Server 为 BIT、Oracle 为 NUMBER(1)，整数取值为各数据库的小整数类型。
+// ValueInt 的 db 写入整数，bool 取值同样使用整数类型。声明了 type 标签或无法确定时返回空，由 gorm 决定
+func flagDBDataType(db *gorm.DB, f *schema.Field) string {
+	bindMigrationDefault(db, f)
+	if _, ok := f.TagSettings["TYPE"]; ok {
+		return ""
+	}
+
+	active := flagValuesOf(f).active
+	if b, ok := active.(bool); ok && configOf(db).ValueMode == ValueInt && !numericBool(db) {
+		active = boolInt(b)
+	}
+	switch active.(type) {
+	case bool:
+		switch db.Dialector.Name() {
+		case "mysql":
+			return "TINYINT(1)"
+		case "postgres":
+			return "BOOLEAN"
+		case "sqlserver":
+			return "BIT"
+		case "oracle":
+			return "NUMBER(1)"
+		}
+	case int64:
+		switch db.Dialector.Name() {
+		case "mysql", "sqlserver":
+			return "TINYINT"
+		case "postgres":
+			return "SMALLINT"
+		case "sqlite":
+			return "INTEGER"
+		case "oracle":
+			return "NUMBER(3)"
+		}
+	}
+	return ""
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface 接口
+func (DeletedAt) GormDataType() string {
+	return string(schema.Bool)
+}
+
+// GormDBDataType 实现 migrator.GormDataTypeInterface 接口
+func (DeletedAt) GormDBDataType(db *gorm.DB, f *schema.Field) string {
+	return flagDBDataType(db, f)
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface 接口
+func (ActiveFlag) GormDataType() string {
+	return string(schema.Bool)
+}
+
+// GormDBDataType 实现 migrator.GormDataTypeInterface 接口
+func (ActiveFlag) GormDBDataType(db *gorm.DB, f *schema.Field) string {
+	return flagDBDataType(db, f)
+}
+
+// GormDBDataType 实现 migrator.GormDataTypeInterface 接口，类型由 T 的取值决定
+func (Field[T]) GormDBDataType(db *gorm.DB, f *schema.Field) string {
+	return flagDBDataType(db, f)
+}
@@ -0,0 +1,117 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// fieldOf 解析 model 并返回名为 name 的字段，取值在解析时绑定
+func fieldOf(t *testing.T, db *gorm.DB, model interface{}, name string) *schema.Field {
+	t.Helper()
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		t.Fatal(err)
+	}
+	f := stmt.Schema.LookUpField(name)
+	if f == nil {
+		t.Fatalf("field %s not found", name)
+	}
+	return f
+}
+
+// 标记列的类型按数据库和取值决定，声明 type 标签时由 gorm 决定
+func TestGormDBDataType(t *testing.T) {
+	tests := []struct {
+		dialect string
+		model   interface{}
+		field   string
+		want    string
+	}{
+		{"mysql", &User{}, "Deleted", "TINYINT(1)"},
+		{"postgres", &User{}, "Deleted", "BOOLEAN"},
+		{"sqlserver", &User{}, "Deleted", "BIT"},
+		{"oracle", &User{}, "Deleted", "NUMBER(1)"},
+		{"sqlite", &User{}, "Deleted", ""},
+		{"postgres", &ActiveUser{}, "IsActive", "BOOLEAN"},
+		{"mysql", &LegacyUser{}, "Deleted", "TINYINT"},
+		{"postgres", &LegacyUser{}, "Deleted", "SMALLINT"},
+		{"sqlite", &LegacyUser{}, "Deleted", "INTEGER"},
+		{"postgres", &Int16User{}, "Deleted", ""},
+	}
+	for _, tt := range tests {
+		db := dryRunDB(t, tt.dialect)
+		if got := flagDBDataType(db, fieldOf(t, db, tt.model, tt.field)); got != tt.want {
+			t.Errorf("%s %T = %q, want %q", tt.dialect, tt.model, got, tt.want)
+		}
+	}
+}
+
+// 没有声明 default 的标记列迁移为 NOT NULL 并以未删除的值为默认值
+func TestMigrateColumnDefault(t *testing.T) {
+	db := openDB(t, &User{}, &NullUser{}, &LegacyUser{})
+
+	tests := []struct {
+		model    interface{}
+		nullable bool
+		def      string
+		hasDef   bool
+	}{
+		{&User{}, false, "false", true},
+		{&LegacyUser{}, false, "1", true},
+		{&NullUser{}, true, "", false},
+	}
+	for _, tt := range tests {
+		types, err := db.Migrator().ColumnTypes(tt.model)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ct := range types {
+			if ct.Name() != "deleted" {
+				continue
+			}
+			if nullable, _ := ct.Nullable(); nullable != tt.nullable {
+				t.Errorf("%T nullable = %v", tt.model, nullable)
+			}
+			def, ok := ct.DefaultValue()
+			if ok != tt.hasDef || (ok && def != tt.def) {
+				t.Errorf("%T default = %q, %v, want %q", tt.model, def, ok, tt.def)
+			}
+		}
+	}
+}
+
+// 迁移生成的列类型和默认值按 db 的 Config 决定：ValueInt 的 PostgreSQL 为 SMALLINT DEFAULT 0，与插入时绑定的 0 一致
+func TestMigrateColumnPerConfig(t *testing.T) {
+	tests := []struct {
+		dialect string
+		mode    ValueMode
+		want    string
+	}{
+		{"postgres", ValueBool, "`deleted` BOOLEAN NOT NULL DEFAULT false"},
+		{"postgres", ValueInt, "`deleted` SMALLINT NOT NULL DEFAULT 0"},
+		{"mysql", ValueInt, "`deleted` TINYINT NOT NULL DEFAULT 0"},
+		{"sqlserver", ValueBool, "`deleted` BIT NOT NULL DEFAULT 0"},
+	}
+	for _, tt := range tests {
+		db, recorder := dryRunIndexDB(t, tt.dialect)
+		config := DefaultConfig()
+		config.ValueMode = tt.mode
+		if err := Use(db, config); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Migrator().CreateTable(&User{}); err != nil {
+			t.Fatal(err)
+		}
+		if len(recorder.sqls) == 0 {
+			t.Fatalf("%s mode %d: no statement", tt.dialect, tt.mode)
+		}
+		assertContains(t, recorder.sqls[0], tt.want)
+
+		tx := db.Create(&User{Name: "a"})
+		if want := sqlValue(false).(boolValue).GormValue(tx.Statement.Context, db).Vars[0]; tx.Statement.Vars[1] != want {
+			t.Errorf("%s mode %d: insert Vars = %#v, want default %#v", tt.dialect, tt.mode, tx.Statement.Vars, want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package soft_delete
+
+import "testing"
+
+// HistoryEntry 的查询默认包含已删除的记录
+type HistoryEntry struct {
+	ID        uint
+	Note      string
+	CompanyID uint
+	Company   Company
+	Deleted   DeletedAt `gorm:"softDelete:flag,DisableDefaultScope"`
+}
+
+type HistoryCompany struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag,DisableDefaultScope"`
+}
+
+type Contractor struct {
+	ID        uint
+	CompanyID uint
+	Company   HistoryCompany
+	Deleted   DeletedAt `gorm:"softDelete:flag"`
+}
+
+func TestDisableDefaultScope(t *testing.T) {
+	db := openDB(t, &Company{}, &HistoryEntry{})
+	entries := []HistoryEntry{{Note: "a", Company: Company{Name: "a"}}, {Note: "b", Company: Company{Name: "b"}}}
+	db.Create(&entries)
+	// 删除照常写入标记
+	if err := db.Delete(&entries[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !entries[1].Deleted.IsDeleted() {
+		t.Fatal("entry not marked deleted")
+	}
+	db.Delete(&entries[1].Company)
+
+	var found []HistoryEntry
+	db.Joins("Company").Order("history_entries.id").Find(&found)
+	if len(found) != 2 {
+		t.Fatalf("found %+v, want both entries", found)
+	}
+	// 关联的模型没有声明，仍然过滤
+	if found[0].Company.ID == 0 || found[1].Company.ID != 0 {
+		t.Errorf("companies = %+v, %+v", found[0].Company, found[1].Company)
+	}
+
+	var n int64
+	db.Model(&HistoryEntry{}).Clauses(Active{}).Count(&n)
+	if n != 1 {
+		t.Errorf("Active = %d, want 1", n)
+	}
+	db.Model(&HistoryEntry{}).Scopes(OnlyDeleted).Count(&n)
+	if n != 1 {
+		t.Errorf("OnlyDeleted = %d, want 1", n)
+	}
+
+	// 更新仍然只修改未删除的记录
+	if tx := db.Model(&HistoryEntry{}).Where("1 = 1").Update("note", "x"); tx.RowsAffected != 1 {
+		t.Errorf("updated %d rows, want 1", tx.RowsAffected)
+	}
+}
+
+func TestDisableDefaultScopeJoined(t *testing.T) {
+	db := openDB(t, &HistoryCompany{}, &Contractor{})
+	contractors := []Contractor{{Company: HistoryCompany{Name: "a"}}, {Company: HistoryCompany{Name: "b"}}, {Company: HistoryCompany{Name: "c"}}}
+	db.Create(&contractors)
+	db.Delete(&contractors[1].Company)
+	db.Delete(&contractors[2])
+
+	var found []Contractor
+	db.Joins("Company").Order("contractors.id").Find(&found)
+	if len(found) != 2 {
+		t.Fatalf("found %+v, want the two active contractors", found)
+	}
+	// 声明了 DisableDefaultScope 的关联不过滤
+	if found[1].Company.Name != "b" {
+		t.Errorf("deleted company not loaded: %+v", found[1].Company)
+	}
+
+	sql := sqlOf(t, dryRun(db).Joins("Company").Find(&found))
+	assertContains(t, sql, "`contractors`.`deleted` =")
+	assertNotContains(t, sql, "`Company`.`deleted` =")
+}
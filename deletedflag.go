@@ -0,0 +1,173 @@
+package soft_delete
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DeletedFlag 以小整数表示删除状态，适用于 TINYINT UNSIGNED 等整数状态列：查询过滤 deleted = 0，
+// 删除时写入 1，恢复时写回 0。两个值可以通过标签修改，未删除之外的值都视为不可见，可以为以后的状态保留：
+//
+//	Deleted soft_delete.DeletedFlag `gorm:"softDelete:flag,DeletedValue:9"`
+//
+// 内存中的 0 总是表示未删除，ActiveValue 不为 0 时读写会交换 0 与 ActiveValue，其他值与数据库中相同
+type DeletedFlag uint8
+
+// deletedFlagValues 为 DeletedFlag 默认的取值
+func deletedFlagValues() flagValues {
+	return flagValues{active: int64(0), deleted: int64(1)}
+}
+
+// IsDeleted 判断记录是否不处于未删除状态
+func (d DeletedFlag) IsDeleted() bool {
+	return d != 0
+}
+
+// IsActive 判断记录是否未删除
+func (d DeletedFlag) IsActive() bool {
+	return d == 0
+}
+
+// String 返回 "deleted" 或 "active"
+func (d DeletedFlag) String() string {
+	return stateString(d.IsDeleted())
+}
+
+// MarkActive 将标记设为未删除，只修改内存中的值
+func (d *DeletedFlag) MarkActive() {
+	*d = 0
+}
+
+// 实现 driver.Valuer 接口
+func (d DeletedFlag) Value() (driver.Value, error) {
+	return int64(d), nil
+}
+
+// 实现 sql.Scanner 接口，兼容整数、字节和字符串，NULL 视为未删除
+func (d *DeletedFlag) Scan(value interface{}) error {
+	var n uint64
+	switch v := value.(type) {
+	case nil:
+		*d = 0
+		return nil
+	case DeletedFlag:
+		*d = v
+		return nil
+	case bool:
+		if v {
+			n = 1
+		}
+	case []byte:
+		return d.scanString(string(v))
+	case string:
+		return d.scanString(v)
+	default:
+		switch rv := reflect.ValueOf(value); rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.Int() < 0 {
+				return fmt.Errorf("invalid value for DeletedFlag: %v", value)
+			}
+			n = uint64(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = rv.Uint()
+		case reflect.Float32, reflect.Float64:
+			n = uint64(rv.Float())
+		default:
+			return fmt.Errorf("invalid data type for DeletedFlag: %T", value)
+		}
+	}
+	if n > 255 {
+		return fmt.Errorf("invalid value for DeletedFlag: %v", value)
+	}
+	*d = DeletedFlag(n)
+	return nil
+}
+
+func (d *DeletedFlag) scanString(s string) error {
+	n, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return fmt.Errorf("invalid value for DeletedFlag: %q", s)
+	}
+	*d = DeletedFlag(n)
+	return nil
+}
+
+// bindDeletedFlag 绑定字段的取值，ActiveValue 不为 0 时在读写时交换 0 与 ActiveValue
+func bindDeletedFlag(f *schema.Field) {
+	if _, loaded := fieldFlagValues.Load(f); loaded {
+		return
+	}
+	bindFlagValues(f, deletedFlagValues(), nil)
+
+	var active DeletedFlag
+	if err := active.Scan(flagValuesOf(f).active); err != nil || active == 0 {
+		return
+	}
+	swap := func(d DeletedFlag) DeletedFlag {
+		switch d {
+		case 0:
+			return active
+		case active:
+			return 0
+		}
+		return d
+	}
+
+	valueOf := f.ValueOf
+	f.ValueOf = func(ctx context.Context, v reflect.Value) (interface{}, bool) {
+		value, zero := valueOf(ctx, v)
+		if d, ok := value.(DeletedFlag); ok {
+			return swap(d), zero
+		}
+		return value, zero
+	}
+
+	set := f.Set
+	f.Set = func(ctx context.Context, v reflect.Value, value interface{}) error {
+		var d DeletedFlag
+		if p, ok := value.(*DeletedFlag); ok && p != nil {
+			d = *p
+		} else if err := d.Scan(value); err != nil {
+			return set(ctx, v, value)
+		}
+		return set(ctx, v, swap(d))
+	}
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface 接口
+func (DeletedFlag) GormDataType() string {
+	return string(schema.Uint)
+}
+
+// GormDBDataType 实现 migrator.GormDataTypeInterface 接口，MySQL 为 TINYINT UNSIGNED
+func (DeletedFlag) GormDBDataType(db *gorm.DB, f *schema.Field) string {
+	if _, ok := f.TagSettings["TYPE"]; !ok && db.Dialector.Name() == "mysql" {
+		return "TINYINT UNSIGNED"
+	}
+	return flagDBDataType(db, f)
+}
+
+func (DeletedFlag) QueryClauses(f *schema.Field) []clause.Interface {
+	bindDeletedFlag(f)
+	return flagQueryClauses(f, deletedFlagValues(), nil)
+}
+
+func (DeletedFlag) UpdateClauses(f *schema.Field) []clause.Interface {
+	return flagUpdateClauses(f)
+}
+
+func (DeletedFlag) DeleteClauses(f *schema.Field) []clause.Interface {
+	bindDeletedFlag(f)
+	return flagDeleteClauses(f, deletedFlagValues(), nil)
+}
+
+func (DeletedFlag) CreateClauses(f *schema.Field) []clause.Interface {
+	return flagCreateClauses(f)
+}
@@ -0,0 +1,180 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type TinyUser struct {
+	ID      uint
+	Name    string
+	Deleted DeletedFlag `gorm:"softDelete:flag,DeletedValue:9"`
+}
+
+type DefaultTinyUser struct {
+	ID      uint
+	Name    string
+	Deleted DeletedFlag `gorm:"softDelete:flag"`
+}
+
+type ShiftedTinyUser struct {
+	ID      uint
+	Name    string
+	Deleted DeletedFlag `gorm:"softDelete:flag,ActiveValue:5,DeletedValue:9"`
+}
+
+// rawFlags 返回表中按主键排序的标记列原始值
+func rawFlags(t *testing.T, db *gorm.DB, table string) []int64 {
+	t.Helper()
+	var raw []int64
+	if err := db.Table(table).Order("id").Pluck("deleted", &raw).Error; err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func equalInts(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 0 为未删除、9 为已删除，其他值保留给以后的状态，同样不可见
+func TestDeletedFlag(t *testing.T) {
+	db := openDB(t, &TinyUser{})
+	db.Create(&[]TinyUser{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+
+	sql := sqlOf(t, dryRun(db).Find(&[]TinyUser{}))
+	assertContains(t, sql, "`tiny_users`.`deleted` = ?")
+
+	user := TinyUser{ID: 2}
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Deleted != 9 || !user.Deleted.IsDeleted() {
+		t.Errorf("in-memory flag = %d, want 9", user.Deleted)
+	}
+	db.Exec("UPDATE tiny_users SET deleted = 3 WHERE id = 3")
+	if raw := rawFlags(t, db, "tiny_users"); !equalInts(raw, []int64{0, 9, 3}) {
+		t.Fatalf("stored values = %v, want [0 9 3]", raw)
+	}
+
+	var users []TinyUser
+	db.Find(&users)
+	if len(users) != 1 || users[0].Name != "a" || !users[0].Deleted.IsActive() {
+		t.Errorf("active = %+v", users)
+	}
+	db.Scopes(OnlyDeleted).Order("id").Find(&users)
+	if len(users) != 2 || users[0].Deleted != 9 || users[1].Deleted != 3 {
+		t.Errorf("deleted = %+v", users)
+	}
+
+	if n, err := Restore(db, &TinyUser{ID: 2}); err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	if raw := rawFlags(t, db, "tiny_users"); !equalInts(raw, []int64{0, 0, 3}) {
+		t.Errorf("stored values after restore = %v, want [0 0 3]", raw)
+	}
+}
+
+// 没有标签时删除写入 1；ActiveValue 不为 0 时内存中的 0 仍表示未删除
+func TestDeletedFlagValues(t *testing.T) {
+	db := openDB(t, &DefaultTinyUser{}, &ShiftedTinyUser{})
+	db.Create(&[]DefaultTinyUser{{Name: "a"}, {Name: "b"}})
+	db.Delete(&DefaultTinyUser{ID: 2})
+	if raw := rawFlags(t, db, "default_tiny_users"); !equalInts(raw, []int64{0, 1}) {
+		t.Errorf("default stored values = %v, want [0 1]", raw)
+	}
+
+	db.Create(&[]ShiftedTinyUser{{Name: "a"}, {Name: "b"}})
+	db.Delete(&ShiftedTinyUser{ID: 2})
+	if raw := rawFlags(t, db, "shifted_tiny_users"); !equalInts(raw, []int64{5, 9}) {
+		t.Errorf("shifted stored values = %v, want [5 9]", raw)
+	}
+	var users []ShiftedTinyUser
+	db.Find(&users)
+	if len(users) != 1 || users[0].Deleted != 0 || !users[0].Deleted.IsActive() {
+		t.Errorf("shifted active = %+v", users)
+	}
+	var deleted ShiftedTinyUser
+	db.Unscoped().First(&deleted, 2)
+	if deleted.Deleted != 9 {
+		t.Errorf("shifted deleted = %d, want 9", deleted.Deleted)
+	}
+}
+
+// DeletedFlag 与 DeletedAt 的模型在同一个 db 中各自按自己的取值过滤
+func TestDeletedFlagWithDeletedAt(t *testing.T) {
+	db := openDB(t, &TinyUser{}, &User{})
+	db.Create(&[]TinyUser{{Name: "a"}, {Name: "b"}})
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}})
+	db.Delete(&TinyUser{ID: 1})
+	db.Delete(&User{ID: 2})
+
+	var tiny []TinyUser
+	var users []User
+	db.Find(&tiny)
+	db.Find(&users)
+	if len(tiny) != 1 || tiny[0].Name != "b" || len(users) != 1 || users[0].Name != "a" {
+		t.Errorf("tiny = %+v, users = %+v", tiny, users)
+	}
+	if raw := rawFlags(t, db, "tiny_users"); !equalInts(raw, []int64{9, 0}) {
+		t.Errorf("tiny stored values = %v", raw)
+	}
+}
+
+func TestDeletedFlagScan(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    DeletedFlag
+		wantErr bool
+	}{
+		{nil, 0, false},
+		{int64(9), 9, false},
+		{uint8(3), 3, false},
+		{true, 1, false},
+		{[]byte("9"), 9, false},
+		{"0", 0, false},
+		{float64(9), 9, false},
+		{int64(-1), 0, true},
+		{int64(256), 0, true},
+		{"x", 0, true},
+		{struct{}{}, 0, true},
+	}
+	for _, tt := range tests {
+		var d DeletedFlag
+		err := d.Scan(tt.value)
+		if (err != nil) != tt.wantErr || (!tt.wantErr && d != tt.want) {
+			t.Errorf("Scan(%#v) = %d, %v, want %d, error %v", tt.value, d, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// MySQL 迁移为 TINYINT UNSIGNED，默认值为未删除的值
+func TestDeletedFlagDataType(t *testing.T) {
+	mysql := dryRunDB(t, "mysql")
+	if got := (DeletedFlag(0)).GormDBDataType(mysql, fieldOf(t, mysql, &TinyUser{}, "Deleted")); got != "TINYINT UNSIGNED" {
+		t.Errorf("mysql type = %q", got)
+	}
+
+	db := openDB(t, &ShiftedTinyUser{})
+	types, err := db.Migrator().ColumnTypes(&ShiftedTinyUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ct := range types {
+		if ct.Name() != "deleted" {
+			continue
+		}
+		if def, ok := ct.DefaultValue(); !ok || def != "5" {
+			t.Errorf("default = %q, %v, want 5", def, ok)
+		}
+	}
+}
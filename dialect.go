@@ -0,0 +1,60 @@
+package soft_delete
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// boolValue 为写入 SQL 的 bool 标记值，构建语句时按数据库决定绑定的参数：
+// SQL Server 的 BIT、Oracle 的 NUMBER(1) 以及 Config.ValueMode 为 ValueInt 的 db 绑定 1/0，
+// 其他数据库绑定 bool，MySQL、SQLite 的驱动本身会将 bool 转换为 1/0
+type boolValue bool
+
+// GormValue 实现 gorm.Valuer 接口
+func (v boolValue) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	if intFlags(db) {
+		return clause.Expr{SQL: "?", Vars: []interface{}{boolInt(bool(v))}}
+	}
+	return clause.Expr{SQL: "?", Vars: []interface{}{bool(v)}}
+}
+
+// GormValue 实现 gorm.Valuer 接口，使写入 DeletedAt 字段时与条件中的标记值一致
+func (b DeletedAt) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return boolValue(b).GormValue(ctx, db)
+}
+
+// intFlags 判断 db 是否以整数 1/0 绑定 bool 标记：数据库没有 bool 类型或 Config 为 ValueInt
+func intFlags(db *gorm.DB) bool {
+	return numericBool(db) || configOf(db).ValueMode == ValueInt
+}
+
+// numericBool 判断数据库是否没有 bool 类型，只能以整数 1/0 表示
+func numericBool(db *gorm.DB) bool {
+	if db == nil || db.Dialector == nil {
+		return false
+	}
+	switch db.Dialector.Name() {
+	case "sqlserver", "oracle":
+		return true
+	}
+	return false
+}
+
+// sqlValue 将写入条件、SET 的标记值转换为按数据库绑定的值，非 bool 值原样返回。
+// 赋给模型字段的值仍使用原值
+func sqlValue(value interface{}) interface{} {
+	if b, ok := value.(bool); ok {
+		return boolValue(b)
+	}
+	return value
+}
+
+// rawValue 为 sqlValue 的逆转换，用于 Explain 等需要原值的地方
+func rawValue(value interface{}) interface{} {
+	if b, ok := value.(boolValue); ok {
+		return bool(b)
+	}
+	return value
+}
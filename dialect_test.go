@@ -0,0 +1,52 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// 各数据库的标记值：SQL Server、Oracle 绑定 1/0，其他数据库绑定 bool
+func TestFlagValuesPerDialect(t *testing.T) {
+	tests := []struct {
+		dialect         string
+		active, deleted interface{}
+	}{
+		{"sqlite", false, true},
+		{"mysql", false, true},
+		{"postgres", false, true},
+		{"sqlserver", int64(0), int64(1)},
+		{"oracle", int64(0), int64(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect, func(t *testing.T) {
+			db := dryRunDB(t, tt.dialect)
+			statements := []struct {
+				name string
+				tx   *gorm.DB
+				want []interface{}
+			}{
+				{"Find", db.Find(&[]User{}), []interface{}{tt.active}},
+				{"OnlyDeleted", db.Scopes(OnlyDeleted).Find(&[]User{}), []interface{}{tt.active}},
+				{"Delete", db.Delete(&User{ID: 1}), []interface{}{tt.deleted, uint(1), tt.active}},
+				{"Update", db.Model(&User{ID: 1}).Update("name", "a"), []interface{}{"a", tt.active, uint(1)}},
+				{"Create", db.Create(&User{Name: "a", Deleted: true}), []interface{}{"a", tt.deleted}},
+			}
+			for _, s := range statements {
+				sql := sqlOf(t, s.tx)
+				assertNotContains(t, sql, "true", "false")
+				vars := s.tx.Statement.Vars
+				if len(vars) != len(s.want) {
+					t.Errorf("%s: Vars = %#v, want %#v", s.name, vars, s.want)
+					continue
+				}
+				for i := range vars {
+					if vars[i] != s.want[i] {
+						t.Errorf("%s: Vars = %#v, want %#v", s.name, vars, s.want)
+						break
+					}
+				}
+			}
+		})
+	}
+}
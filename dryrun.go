@@ -0,0 +1,31 @@
+package soft_delete
+
+import "gorm.io/gorm"
+
+// ExplainDelete 以 DryRun 执行 db.Delete(value, conds...)，返回软删除改写后的 SQL 和参数，不访问数据库。
+// 语句经过与实际执行相同的子句和回调，主键超过 KeyBatchSize 时实际执行会分为多条语句，这里返回未分批的一条；
+// 级联删除子记录的语句不包含在内
+//
+//	sql, vars, err := soft_delete.ExplainDelete(db, &User{ID: 1})
+func ExplainDelete(db *gorm.DB, value interface{}, conds ...interface{}) (string, []interface{}, error) {
+	return explainSQL(dryRun(db).Delete(value, conds...))
+}
+
+// ExplainQuery 以 DryRun 执行 db.Find(dest, conds...)，返回添加了过滤条件的 SQL 和参数，不访问数据库
+//
+//	sql, vars, err := soft_delete.ExplainQuery(db.Where("name = ?", "a"), &[]User{})
+func ExplainQuery(db *gorm.DB, dest interface{}, conds ...interface{}) (string, []interface{}, error) {
+	return explainSQL(dryRun(db).Find(dest, conds...))
+}
+
+// dryRun 返回只生成 SQL 的会话，不开启默认事务
+func dryRun(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true})
+}
+
+func explainSQL(tx *gorm.DB) (string, []interface{}, error) {
+	if tx.Error != nil {
+		return "", nil, tx.Error
+	}
+	return tx.Statement.SQL.String(), tx.Statement.Vars, nil
+}
@@ -0,0 +1,66 @@
+package soft_delete
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Explain 的结果与实际执行时记录的 SQL 一致
+func TestExplainMatchesExecution(t *testing.T) {
+	db := pinNow(openDB(t, &User{}, &RestoreUser{}))
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}})
+	db.Create(&[]RestoreUser{{Name: "a"}, {Name: "b"}})
+	ctx := WithActor(context.Background(), "admin")
+
+	tests := []struct {
+		name    string
+		explain func(db *gorm.DB) (string, []interface{}, error)
+		run     func(db *gorm.DB) error
+	}{
+		{"delete by primary key", func(db *gorm.DB) (string, []interface{}, error) {
+			return ExplainDelete(db, &User{ID: 1})
+		}, func(db *gorm.DB) error {
+			return db.Delete(&User{ID: 1}).Error
+		}},
+		{"delete with conditions", func(db *gorm.DB) (string, []interface{}, error) {
+			return ExplainDelete(db.Where("name = ?", "b"), &User{})
+		}, func(db *gorm.DB) error {
+			return db.Where("name = ?", "b").Delete(&User{}).Error
+		}},
+		{"delete with companion fields", func(db *gorm.DB) (string, []interface{}, error) {
+			return ExplainDelete(db.WithContext(ctx), &RestoreUser{}, 1)
+		}, func(db *gorm.DB) error {
+			return db.WithContext(ctx).Delete(&RestoreUser{}, 1).Error
+		}},
+		{"query", func(db *gorm.DB) (string, []interface{}, error) {
+			return ExplainQuery(db.Where("name <> ?", "c"), &[]User{})
+		}, func(db *gorm.DB) error {
+			return db.Where("name <> ?", "c").Find(&[]User{}).Error
+		}},
+		{"only deleted", func(db *gorm.DB) (string, []interface{}, error) {
+			return ExplainQuery(db.Scopes(OnlyDeleted), &[]User{}, "id = ?", 1)
+		}, func(db *gorm.DB) error {
+			return db.Scopes(OnlyDeleted).Find(&[]User{}, "id = ?", 1).Error
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars, err := tt.explain(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			explained := db.Dialector.Explain(sql, vars...)
+
+			recorder := &sqlRecorder{Interface: logger.Discard}
+			if err := tt.run(db.Session(&gorm.Session{Logger: recorder})); err != nil {
+				t.Fatal(err)
+			}
+			if len(recorder.sqls) != 1 || recorder.sqls[0] != explained {
+				t.Errorf("executed %q\nexplained %q", recorder.sqls, explained)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+)
+
+type DeletionMark struct {
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+type AuditInfo struct {
+	CreatedBy string
+	Mark      DeletionMark `gorm:"embedded;embeddedPrefix:in_"`
+}
+
+// EmbeddedUser 的标记字段嵌套两层，列名为 audit_in_deleted
+type EmbeddedUser struct {
+	ID    uint
+	Name  string
+	Audit AuditInfo `gorm:"embedded;embeddedPrefix:audit_"`
+}
+
+// EmbeddedPtrUser 以指针嵌入，删除时由 gorm 分配结构体
+type EmbeddedPtrUser struct {
+	ID    uint
+	Audit *AuditInfo `gorm:"embedded;embeddedPrefix:audit_"`
+}
+
+func TestEmbeddedPrefix(t *testing.T) {
+	db := pinNow(openDB(t, &EmbeddedUser{}))
+	if !db.Migrator().HasColumn(&EmbeddedUser{}, "audit_in_deleted") {
+		t.Fatal("column audit_in_deleted not migrated")
+	}
+	users := []EmbeddedUser{{Name: "a"}, {Name: "b"}}
+	db.Create(&users)
+
+	if err := db.Delete(&users[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+	mark := users[1].Audit.Mark
+	if !mark.Deleted.IsDeleted() || mark.DeletedAt == nil || !mark.DeletedAt.Equal(testNow) {
+		t.Errorf("Mark = %+v in memory after delete", mark)
+	}
+
+	var active []EmbeddedUser
+	db.Find(&active)
+	if len(active) != 1 || active[0].Name != "a" {
+		t.Errorf("active = %+v", active)
+	}
+	var deleted EmbeddedUser
+	if err := db.Scopes(OnlyDeleted).First(&deleted).Error; err != nil || deleted.Name != "b" {
+		t.Errorf("deleted = %+v, %v", deleted, err)
+	}
+	if n, err := Restore(db, &EmbeddedUser{ID: users[1].ID}); err != nil || n != 1 {
+		t.Errorf("Restore = %d, %v", n, err)
+	}
+
+	dry := dryRunDB(t, "sqlite")
+	assertContains(t, sqlOf(t, dry.Find(&[]EmbeddedUser{})), "`embedded_users`.`audit_in_deleted` =")
+	sql := sqlOf(t, dry.Delete(&EmbeddedUser{ID: 1}))
+	assertContains(t, sql, "`audit_in_deleted`=", "`audit_in_deleted_at`=")
+	assertNotContains(t, sql, "`deleted`")
+}
+
+func TestEmbeddedPointerPrefix(t *testing.T) {
+	db := openDB(t, &EmbeddedPtrUser{})
+	user := EmbeddedPtrUser{}
+	db.Create(&user)
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Audit == nil || !user.Audit.Mark.Deleted.IsDeleted() {
+		t.Errorf("Audit = %+v in memory after delete", user.Audit)
+	}
+	var n int64
+	db.Model(&EmbeddedPtrUser{}).Count(&n)
+	if n != 0 {
+		t.Errorf("active = %d after delete", n)
+	}
+}
+
+func BenchmarkEmbeddedQueryClause(b *testing.B) {
+	db := dryRunDB(b, "sqlite")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db.Find(&[]EmbeddedUser{})
+	}
+}
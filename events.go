@@ -0,0 +1,313 @@
+package soft_delete
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	eventsDeleteCallbackName  = "soft_delete:events_delete"
+	eventsRestoreCallbackName = "soft_delete:events_restore"
+
+	cascadeEventsKey = "soft_delete:cascade_events"
+)
+
+// Event 为一次软删除或恢复。已知主键时每条记录一个事件，PrimaryKeys 为主键列到值；
+// 否则整条语句一个事件，Conditions 为展开参数后的 WHERE 条件
+type Event struct {
+	Table       string
+	PrimaryKeys map[string]interface{}
+	Conditions  string
+	Actor       interface{}
+	Time        time.Time
+}
+
+// EventHandler 处理软删除或恢复事件，其中的 panic 会被恢复并记录日志，不影响数据库操作
+type EventHandler func(ctx context.Context, e Event)
+
+// Events 保存事件处理函数，作为 gorm 插件注册后，软删除和恢复成功后调用：
+//
+//	events := soft_delete.NewEvents()
+//	events.OnDelete(func(ctx context.Context, e soft_delete.Event) { cache.Invalidate(e.Table, e.PrimaryKeys) })
+//	db.Use(events)
+//
+// 在事务中执行时，包括 gorm 的 Transaction、Begin 开启的事务，事件在事务提交后调用，回滚时丢弃
+type Events struct {
+	mu        sync.RWMutex
+	onDelete  []EventHandler
+	onRestore []EventHandler
+}
+
+// globalEvents 为 DefaultConfig 的 Events，对没有通过 Use 设置 Config 或使用 DefaultConfig 的 db 生效
+var globalEvents = NewEvents()
+
+func NewEvents() *Events {
+	return &Events{}
+}
+
+// OnDelete 添加全局的软删除事件处理函数，对 Config.Events 为 DefaultConfig 的值、注册了 Events 的 db 生效
+func OnDelete(handler EventHandler) {
+	globalEvents.OnDelete(handler)
+}
+
+// OnRestore 添加全局的恢复事件处理函数，生效范围与 OnDelete 相同
+func OnRestore(handler EventHandler) {
+	globalEvents.OnRestore(handler)
+}
+
+func (e *Events) OnDelete(handler EventHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onDelete = append(e.onDelete, handler)
+}
+
+func (e *Events) OnRestore(handler EventHandler) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onRestore = append(e.onRestore, handler)
+}
+
+func (e *Events) handlers(action string) []EventHandler {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if action == ActionRestore {
+		return append([]EventHandler(nil), e.onRestore...)
+	}
+	return append([]EventHandler(nil), e.onDelete...)
+}
+
+// Name 实现 gorm.Plugin 接口
+func (e *Events) Name() string {
+	return "soft_delete:events"
+}
+
+// Initialize 实现 gorm.Plugin 接口，回调位于 gorm 默认事务提交之后。
+// db 的连接池被包装，开启的事务暂存其中的事件，提交后调用
+func (e *Events) Initialize(db *gorm.DB) error {
+	if _, ok := db.ConnPool.(eventPool); !ok {
+		db.ConnPool = eventPool{ConnPool: db.ConnPool}
+		db.Statement.ConnPool = db.ConnPool
+	}
+	if err := db.Callback().Delete().After("gorm:commit_or_rollback_transaction").Register(eventsDeleteCallbackName, func(tx *gorm.DB) {
+		if isSoftDeleting(tx.Statement) {
+			e.emit(tx, ActionDelete)
+		}
+	}); err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:commit_or_rollback_transaction").Register(eventsRestoreCallbackName, func(tx *gorm.DB) {
+		if isRestoring(tx.Statement) {
+			e.emit(tx, ActionRestore)
+		}
+	})
+}
+
+func (e *Events) emit(db *gorm.DB, action string) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || db.RowsAffected == 0 {
+		return
+	}
+
+	var handlers []EventHandler
+	if shared := configOf(db).Events; shared != nil && shared != e {
+		handlers = shared.handlers(action)
+	}
+	handlers = append(handlers, e.handlers(action)...)
+	if len(handlers) == 0 {
+		return
+	}
+
+	event := Event{Table: stmt.Table, Time: stmt.DB.NowFunc()}
+	event.Actor, _ = ActorFromContext(stmt.Context)
+
+	var events []Event
+	for _, keys := range affectedKeys(stmt) {
+		ev := event
+		ev.PrimaryKeys = keys
+		events = append(events, ev)
+	}
+	if len(events) == 0 {
+		event.Conditions = renderWhere(stmt)
+		events = append(events, event)
+	}
+
+	children, _ := stmt.Settings.Load(cascadeEventsKey)
+	fire := func() {
+		for _, ev := range events {
+			for _, handler := range handlers {
+				callHandler(db, handler, ev)
+			}
+		}
+		if children, ok := children.(*eventBuffer); ok {
+			children.flush()
+		}
+	}
+	if buffer, ok := stmt.Context.Value(eventBufferKey{}).(*eventBuffer); ok {
+		buffer.add(fire)
+		return
+	}
+	if tx, ok := eventTxOf(stmt.ConnPool); ok {
+		tx.buffer.add(fire)
+		return
+	}
+	fire()
+}
+
+func callHandler(db *gorm.DB, handler EventHandler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			db.Logger.Error(db.Statement.Context, "soft_delete: event handler panic: %v", r)
+		}
+	}()
+	handler(db.Statement.Context, e)
+}
+
+// eventBufferKey 为级联删除时暂存子记录事件的 context 键
+type eventBufferKey struct{}
+
+// eventBuffer 暂存事务或级联删除中的事件，提交后调用
+type eventBuffer struct {
+	mu      sync.Mutex
+	pending []func()
+}
+
+func (b *eventBuffer) add(fire func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = append(b.pending, fire)
+}
+
+func (b *eventBuffer) len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// truncate 丢弃 n 之后的事件，用于回滚的嵌套事务
+func (b *eventBuffer) truncate(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pending = b.pending[:n]
+}
+
+func (b *eventBuffer) flush() {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	b.mu.Unlock()
+
+	for _, fire := range pending {
+		fire()
+	}
+}
+
+// Transaction 与 db.Transaction 相同。在事务中嵌套调用时，fc 返回错误回滚到保存点后，
+// 其中的软删除和恢复事件也被丢弃；直接嵌套 gorm 的 Transaction 时，这些事件仍在外层事务提交后调用
+func Transaction(db *gorm.DB, fc func(tx *gorm.DB) error, opts ...*sql.TxOptions) error {
+	tx, ok := eventTxOf(db.Statement.ConnPool)
+	if !ok {
+		return db.Transaction(fc, opts...)
+	}
+
+	mark := tx.buffer.len()
+	err := db.Transaction(fc, opts...)
+	if err != nil {
+		tx.buffer.truncate(mark)
+	}
+	return err
+}
+
+// eventPool 包装注册了 Events 的 db 的连接池，开启的事务为 eventTx
+type eventPool struct {
+	gorm.ConnPool
+}
+
+// BeginTx 实现 gorm.ConnPoolBeginner 接口
+func (p eventPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		tx  gorm.ConnPool
+		err error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		return nil, gorm.ErrInvalidTransaction
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &eventTx{ConnPool: tx, pool: p}, nil
+}
+
+// GetDBConn 实现 gorm.GetDBConnector 接口，db.DB() 仍然返回原来的 *sql.DB
+func (p eventPool) GetDBConn() (*sql.DB, error) {
+	switch pool := p.ConnPool.(type) {
+	case *sql.DB:
+		return pool, nil
+	case gorm.GetDBConnector:
+		return pool.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// eventTx 为 eventPool 开启的事务，暂存其中的事件，提交成功后调用，回滚时丢弃
+type eventTx struct {
+	gorm.ConnPool
+	pool   eventPool
+	buffer eventBuffer
+}
+
+// eventTxOf 返回连接所在的 eventTx，PrepareStmt 时 eventTx 包装在 gorm.PreparedStmtTX 中
+func eventTxOf(pool gorm.ConnPool) (*eventTx, bool) {
+	switch tx := pool.(type) {
+	case *eventTx:
+		return tx, true
+	case *gorm.PreparedStmtTX:
+		return eventTxOf(tx.Tx)
+	}
+	return nil, false
+}
+
+func (tx *eventTx) Commit() error {
+	committer, ok := tx.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	if err := committer.Commit(); err != nil {
+		tx.buffer.truncate(0)
+		return err
+	}
+	tx.buffer.flush()
+	return nil
+}
+
+func (tx *eventTx) Rollback() error {
+	tx.buffer.truncate(0)
+	committer, ok := tx.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	return committer.Rollback()
+}
+
+// StmtContext 实现 gorm.Tx 接口，用于 PrepareStmt
+func (tx *eventTx) StmtContext(ctx context.Context, stmt *sql.Stmt) *sql.Stmt {
+	if t, ok := tx.ConnPool.(interface {
+		StmtContext(context.Context, *sql.Stmt) *sql.Stmt
+	}); ok {
+		return t.StmtContext(ctx, stmt)
+	}
+	return stmt
+}
+
+// GetDBConn 实现 gorm.GetDBConnector 接口
+func (tx *eventTx) GetDBConn() (*sql.DB, error) {
+	return tx.pool.GetDBConn()
+}
@@ -0,0 +1,203 @@
+package soft_delete
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// eventRecorder 记录收到的事件
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *eventRecorder) handle(ctx context.Context, e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+}
+
+func (r *eventRecorder) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+// openEventsDB 返回注册了 Events 的 db，删除和恢复事件都记录在 recorder 中
+func openEventsDB(t *testing.T) (*gorm.DB, *eventRecorder) {
+	t.Helper()
+	db := openDB(t, &User{})
+	recorder := &eventRecorder{}
+	events := NewEvents()
+	events.OnDelete(recorder.handle)
+	events.OnRestore(recorder.handle)
+	if err := db.Use(events); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+	return db, recorder
+}
+
+// 每条删除的记录一个事件，处理函数的 panic 不影响删除
+func TestEvents(t *testing.T) {
+	db, recorder := openEventsDB(t)
+
+	var panics int
+	events := db.Config.Plugins["soft_delete:events"].(*Events)
+	events.OnDelete(func(ctx context.Context, e Event) {
+		panics++
+		panic("boom")
+	})
+
+	ctx := WithActor(context.Background(), "alice")
+	if err := db.WithContext(ctx).Delete(&[]User{{ID: 1}, {ID: 2}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if recorder.len() != 2 || panics != 2 {
+		t.Fatalf("events = %+v, panics = %d", recorder.events, panics)
+	}
+	for i, e := range recorder.events {
+		if e.Table != "users" || e.PrimaryKeys["id"] != uint(i+1) || e.Actor != "alice" {
+			t.Errorf("event %d = %+v", i, e)
+		}
+	}
+
+	db.Delete(&User{ID: 1})
+	if recorder.len() != 2 {
+		t.Errorf("delete of deleted row fired %d events", recorder.len()-2)
+	}
+	Restore(db, &User{ID: 1})
+	if recorder.len() != 3 {
+		t.Errorf("restore fired %d events", recorder.len()-2)
+	}
+}
+
+// gorm 的 Transaction、Begin 中的事件在提交后调用，回滚时丢弃
+func TestEventsTransaction(t *testing.T) {
+	db, recorder := openEventsDB(t)
+	errRollback := errors.New("rollback")
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tx.Delete(&User{ID: 1})
+		if recorder.len() != 0 {
+			t.Error("event fired before commit")
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) || recorder.len() != 0 {
+		t.Fatalf("rollback = %v, events = %d", err, recorder.len())
+	}
+
+	tx := db.Begin()
+	tx.Delete(&User{ID: 1})
+	tx.Rollback()
+	if recorder.len() != 0 {
+		t.Fatalf("Begin/Rollback fired %d events", recorder.len())
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return tx.Delete(&User{ID: 1}).Error
+	})
+	if err != nil || recorder.len() != 1 {
+		t.Fatalf("commit = %v, events = %d", err, recorder.len())
+	}
+
+	tx = db.Session(&gorm.Session{PrepareStmt: true}).Begin()
+	tx.Delete(&User{ID: 2})
+	if recorder.len() != 1 {
+		t.Error("PrepareStmt event fired before commit")
+	}
+	if err := tx.Commit().Error; err != nil {
+		t.Fatal(err)
+	}
+	if recorder.len() != 2 {
+		t.Errorf("PrepareStmt commit events = %d", recorder.len())
+	}
+	if _, err := db.DB(); err != nil {
+		t.Errorf("DB() = %v", err)
+	}
+}
+
+// 嵌套的 Transaction 回滚到保存点时丢弃其中的事件，外层提交后调用其余事件
+func TestEventsNestedTransaction(t *testing.T) {
+	db, recorder := openEventsDB(t)
+
+	err := Transaction(db, func(tx *gorm.DB) error {
+		tx.Delete(&User{ID: 1})
+		Transaction(tx, func(tx *gorm.DB) error {
+			tx.Delete(&User{ID: 2})
+			return errors.New("rollback")
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recorder.len() != 1 || recorder.events[0].PrimaryKeys["id"] != uint(1) {
+		t.Errorf("events = %+v", recorder.events)
+	}
+	var n int64
+	db.Model(&User{}).Count(&n)
+	if n != 2 {
+		t.Errorf("active = %d, want 2", n)
+	}
+}
+
+// Config.Events 的处理函数对使用该 Config 的 db 生效，NewEvents 的处理函数只对注册的 db 生效
+func TestEventsPerDB(t *testing.T) {
+	var shared eventRecorder
+	config := DefaultConfig()
+	config.Events = NewEvents()
+	config.Events.OnDelete(shared.handle)
+
+	first, firstRecorder := openEventsDB(t)
+	second, secondRecorder := openEventsDB(t)
+	third, thirdRecorder := openEventsDB(t)
+	for _, db := range []*gorm.DB{first, second} {
+		if err := Use(db, config); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first.Delete(&User{ID: 1})
+	if firstRecorder.len() != 1 || secondRecorder.len() != 0 || shared.len() != 1 {
+		t.Errorf("events = %d, %d, shared %d", firstRecorder.len(), secondRecorder.len(), shared.len())
+	}
+	second.Delete(&User{ID: 1})
+	if secondRecorder.len() != 1 || shared.len() != 2 {
+		t.Errorf("events = %d, shared %d", secondRecorder.len(), shared.len())
+	}
+	third.Delete(&User{ID: 1})
+	if thirdRecorder.len() != 1 || shared.len() != 2 {
+		t.Errorf("events = %d, shared %d", thirdRecorder.len(), shared.len())
+	}
+}
+
+// OnDelete 添加的全局处理函数对使用 DefaultConfig 的 db 生效
+func TestEventsGlobal(t *testing.T) {
+	var global eventRecorder
+	OnDelete(global.handle)
+	t.Cleanup(func() {
+		globalEvents.mu.Lock()
+		globalEvents.onDelete = nil
+		globalEvents.mu.Unlock()
+	})
+
+	db, recorder := openEventsDB(t)
+	isolated, _ := openEventsDB(t)
+	config := DefaultConfig()
+	config.Events = nil
+	if err := Use(isolated, config); err != nil {
+		t.Fatal(err)
+	}
+
+	db.Delete(&User{ID: 1})
+	isolated.Delete(&User{ID: 1})
+	if recorder.len() != 1 || global.len() != 1 {
+		t.Errorf("events = %d, global %d", recorder.len(), global.len())
+	}
+}
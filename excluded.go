@@ -0,0 +1,72 @@
+package soft_delete
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	excludedCallbackName = "soft_delete:excluded_deleted"
+	excludedDeletedKey   = "soft_delete:excluded_deleted"
+)
+
+// WarnExcludedUpdates 注册回调，UPDATE 没有更新任何行时检查条件是否匹配了已删除的记录，
+// 有则记录警告，数量可以通过 ExcludedDeleted 读取。检查需要额外的一次查询，只在没有更新任何行时执行
+//
+//	soft_delete.WarnExcludedUpdates(db)
+//	result := db.Model(&user).Update("note", "x")
+//	if result.RowsAffected == 0 && soft_delete.ExcludedDeleted(result) > 0 {
+//		// 记录已被删除，需要时使用 soft_delete.UpdateDeleted
+//	}
+func WarnExcludedUpdates(db *gorm.DB) error {
+	if db.Callback().Update().Get(excludedCallbackName) != nil {
+		return nil
+	}
+	return db.Callback().Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").Register(excludedCallbackName, checkExcludedDeleted)
+}
+
+// ExcludedDeleted 返回上一次 UPDATE 因过滤条件排除的已删除记录数，需要先调用 WarnExcludedUpdates
+func ExcludedDeleted(db *gorm.DB) int64 {
+	if n, ok := db.Statement.Settings.Load(excludedDeletedKey); ok {
+		return n.(int64)
+	}
+	return 0
+}
+
+func checkExcludedDeleted(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || db.DryRun || db.RowsAffected != 0 || stmt.Schema == nil || isRestoring(stmt) {
+		return
+	}
+	if _, ok := stmt.Clauses[softDeleteEnabledClauseName]; !ok {
+		return
+	}
+	sd, ok := stmt.Clauses[ClauseUpdate].Expression.(SoftDeleteUpdateClause)
+	if !ok {
+		return
+	}
+
+	// 去掉未删除的过滤条件，按原来的条件只查询已删除的记录
+	where, _ := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	active := activeExpr(sd.Field, sd.Flag, sd.queryClause().filterColumn(stmt))
+	exprs := make([]clause.Expression, 0, len(where.Exprs))
+	for _, expr := range where.Exprs {
+		if expr != active {
+			exprs = append(exprs, expr)
+		}
+	}
+	if len(exprs) == 0 {
+		return
+	}
+
+	var count int64
+	if err := db.Session(&gorm.Session{NewDB: true, Context: stmt.Context}).Model(reflect.New(stmt.Schema.ModelType).Interface()).
+		Table(stmt.Table).Clauses(clause.Where{Exprs: exprs}).Scopes(OnlyDeleted).Count(&count).Error; err != nil || count == 0 {
+		return
+	}
+
+	stmt.Settings.Store(excludedDeletedKey, count)
+	db.Logger.Warn(stmt.Context, "soft_delete: update of %s matched %d soft-deleted rows and updated none, use UpdateDeleted to update them", stmt.Table, count)
+}
@@ -0,0 +1,40 @@
+package soft_delete
+
+import "testing"
+
+func TestUpdateDeleted(t *testing.T) {
+	db := openDB(t, &User{})
+	seedUsers(t, db)
+	if err := WarnExcludedUpdates(db); err != nil {
+		t.Fatal(err)
+	}
+
+	if tx := db.Model(&User{ID: 1}).Update("name", "a2"); tx.RowsAffected != 1 || ExcludedDeleted(tx) != 0 {
+		t.Errorf("update active = %d, excluded %d", tx.RowsAffected, ExcludedDeleted(tx))
+	}
+
+	tx := db.Model(&User{ID: 2}).Update("name", "b2")
+	if tx.RowsAffected != 0 || ExcludedDeleted(tx) != 1 {
+		t.Errorf("update deleted = %d, excluded %d", tx.RowsAffected, ExcludedDeleted(tx))
+	}
+
+	tx = UpdateDeleted(db).Model(&User{ID: 2}).Update("name", "b2")
+	if tx.Error != nil || tx.RowsAffected != 1 {
+		t.Errorf("UpdateDeleted = %d, %v", tx.RowsAffected, tx.Error)
+	}
+	var got User
+	db.Unscoped().First(&got, 2)
+	if got.Name != "b2" || !got.Deleted.IsDeleted() {
+		t.Errorf("updated deleted row = %+v", got)
+	}
+
+	// 查询仍然过滤
+	var users []User
+	UpdateDeleted(db).Find(&users)
+	if len(users) != 2 {
+		t.Errorf("UpdateDeleted Find = %d rows, want 2", len(users))
+	}
+	if tx := db.Model(&User{ID: 9}).Update("name", "x"); ExcludedDeleted(tx) != 0 {
+		t.Errorf("missing row excluded %d", ExcludedDeleted(tx))
+	}
+}
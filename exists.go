@@ -0,0 +1,53 @@
+package soft_delete
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Exists 判断是否有匹配的未删除记录，条件与 First 相同：model 的主键值、conds 以及 db 上已有的条件。
+// 查询为 SELECT 1 ... LIMIT 1，经过正常的查询回调，不读取整行
+//
+//	ok, err := soft_delete.Exists(db, &User{}, "email = ? AND tenant_id = ?", email, tenantID)
+func Exists(db *gorm.DB, model interface{}, conds ...interface{}) (bool, error) {
+	return exists(db, model, false, conds...)
+}
+
+// ExistsDeleted 判断是否有匹配的已删除记录，模型没有软删除字段时返回 ErrMissingSoftDeleteField
+func ExistsDeleted(db *gorm.DB, model interface{}, conds ...interface{}) (bool, error) {
+	return exists(db, model, true, conds...)
+}
+
+func exists(db *gorm.DB, model interface{}, deleted bool, conds ...interface{}) (bool, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return false, err
+	}
+	if _, ok := lookUpDeleteClause(stmt.Schema); deleted && !ok {
+		// 归档的模型查询已删除记录时读取归档表
+		if _, archived := archiveTableOf(stmt.Schema); !archived {
+			return false, ErrMissingSoftDeleteField
+		}
+	}
+
+	tx := db.Session(&gorm.Session{}).Model(model)
+	if deleted {
+		tx = tx.Scopes(OnlyDeleted)
+	}
+	if rv := reflect.Indirect(reflect.ValueOf(model)); rv.Kind() == reflect.Struct {
+		_, queryValues := schema.GetIdentityFieldValuesMap(db.Statement.Context, rv, stmt.Schema.PrimaryFields)
+		if column, values := schema.ToQueryValues(clause.CurrentTable, stmt.Schema.PrimaryFieldDBNames, queryValues); len(values) > 0 {
+			tx = tx.Where(clause.IN{Column: column, Values: values})
+		}
+	}
+	if len(conds) > 0 {
+		tx = tx.Where(conds[0], conds[1:]...)
+	}
+
+	var found []int
+	err := tx.Select("1").Limit(1).Find(&found).Error
+	return len(found) > 0, err
+}
@@ -0,0 +1,74 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExists(t *testing.T) {
+	db := openDB(t, &User{})
+	users := seedUsers(t, db)
+
+	tests := []struct {
+		name    string
+		model   interface{}
+		conds   []interface{}
+		active  bool
+		deleted bool
+	}{
+		{"active by primary key", &User{ID: users[0].ID}, nil, true, false},
+		{"deleted by primary key", &User{ID: users[1].ID}, nil, false, true},
+		{"missing", &User{ID: 100}, nil, false, false},
+		{"condition", &User{}, []interface{}{"name = ?", "b"}, false, true},
+		{"composite condition", &User{}, []interface{}{"name = ? OR name = ?", "b", "c"}, true, true},
+		{"map condition", &User{}, []interface{}{map[string]interface{}{"name": "a"}}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := Exists(db, tt.model, tt.conds...)
+			if err != nil || active != tt.active {
+				t.Errorf("Exists = %v, %v, want %v", active, err, tt.active)
+			}
+			deleted, err := ExistsDeleted(db, tt.model, tt.conds...)
+			if err != nil || deleted != tt.deleted {
+				t.Errorf("ExistsDeleted = %v, %v, want %v", deleted, err, tt.deleted)
+			}
+		})
+	}
+
+	// 已有的条件同样生效
+	if ok, _ := Exists(db.Where("name = ?", "c"), &User{ID: users[0].ID}); ok {
+		t.Error("Exists should combine with conditions on db")
+	}
+
+	sql := sqlOf(t, dryRunDB(t, "sqlite").Model(&User{}).Select("1").Limit(1).Find(&[]int{}))
+	assertContains(t, sql, "SELECT 1 FROM `users`", "`users`.`deleted` =", "LIMIT 1")
+}
+
+func TestExistsCompositePrimaryKey(t *testing.T) {
+	db := openDB(t, &OrderLine{})
+	lines := []OrderLine{{OrderID: 1, ProductID: 1}, {OrderID: 1, ProductID: 2}}
+	db.Create(&lines)
+	db.Delete(&lines[1])
+
+	if ok, err := Exists(db, &OrderLine{OrderID: 1, ProductID: 1}); err != nil || !ok {
+		t.Errorf("Exists(1, 1) = %v, %v", ok, err)
+	}
+	if ok, err := Exists(db, &OrderLine{OrderID: 1, ProductID: 2}); err != nil || ok {
+		t.Errorf("Exists(1, 2) = %v, %v", ok, err)
+	}
+	if ok, err := ExistsDeleted(db, &OrderLine{OrderID: 1, ProductID: 2}); err != nil || !ok {
+		t.Errorf("ExistsDeleted(1, 2) = %v, %v", ok, err)
+	}
+}
+
+func TestExistsDeletedWithoutSoftDelete(t *testing.T) {
+	db := openDB(t, &Plain{})
+	if _, err := ExistsDeleted(db, &Plain{}); !errors.Is(err, ErrMissingSoftDeleteField) {
+		t.Errorf("err = %v, want ErrMissingSoftDeleteField", err)
+	}
+	db.Create(&Plain{})
+	if ok, err := Exists(db, &Plain{}); err != nil || !ok {
+		t.Errorf("Exists = %v, %v", ok, err)
+	}
+}
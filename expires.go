@@ -0,0 +1,123 @@
+package soft_delete
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ExpiresAt 使记录在过期时间之后自动从查询中消失，不需要后台任务：查询和更新过滤 expires_at IS NULL OR expires_at > 当前时间，
+// 当前时间取 gorm.Config 的 NowFunc。删除写入当前时间，即立即过期；Restore 清空过期时间。
+// 可以与 DeletedAt 等软删除字段用在同一个模型上，此时 Delete、Restore 由另一个字段处理，两个过滤条件同时生效
+//
+//	ExpiresAt soft_delete.ExpiresAt
+type ExpiresAt sql.NullTime
+
+// IsDeleted 判断记录按本地时钟是否已过期
+func (e ExpiresAt) IsDeleted() bool {
+	return e.ExpiredAt(time.Now())
+}
+
+// ExpiredAt 判断记录在 now 时是否已过期，用于以 NowFunc 等指定的时钟判断
+func (e ExpiresAt) ExpiredAt(now time.Time) bool {
+	return e.Valid && !e.Time.After(now)
+}
+
+// IsActive 判断记录按本地时钟是否未过期
+func (e ExpiresAt) IsActive() bool {
+	return !e.IsDeleted()
+}
+
+// String 返回 "deleted" 或 "active"
+func (e ExpiresAt) String() string {
+	return stateString(e.IsDeleted())
+}
+
+// 实现 sql.Scanner 接口
+func (e *ExpiresAt) Scan(value interface{}) error {
+	return (*sql.NullTime)(e).Scan(value)
+}
+
+// 实现 driver.Valuer 接口，没有过期时间时为 NULL
+func (e ExpiresAt) Value() (driver.Value, error) {
+	if !e.Valid {
+		return nil, nil
+	}
+	return e.Time, nil
+}
+
+// 实现 json.Marshaler 接口，没有过期时间时为 null
+func (e ExpiresAt) MarshalJSON() ([]byte, error) {
+	if !e.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(e.Time)
+}
+
+// 实现 json.Unmarshaler 接口
+func (e *ExpiresAt) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*e = ExpiresAt{}
+		return nil
+	}
+	err := json.Unmarshal(data, &e.Time)
+	e.Valid = err == nil
+	return err
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface 接口
+func (ExpiresAt) GormDataType() string {
+	return string(schema.Time)
+}
+
+func (ExpiresAt) QueryClauses(f *schema.Field) []clause.Interface {
+	fieldFlagValues.LoadOrStore(f, flagValues{expires: true})
+	return []clause.Interface{SoftDeleteQueryClause{Field: f, Flag: true}.withQuerySettings()}
+}
+
+func (ExpiresAt) UpdateClauses(f *schema.Field) []clause.Interface {
+	return flagUpdateClauses(f)
+}
+
+func (ExpiresAt) DeleteClauses(f *schema.Field) []clause.Interface {
+	fieldFlagValues.LoadOrStore(f, flagValues{expires: true})
+	softDeleteClause := SoftDeleteDeleteClause{Field: f, Flag: true, Expires: true}
+	return []clause.Interface{softDeleteClause.withCompanionFields(parseSettings(f))}
+}
+
+// expiryExpr 为 ExpiresAt 的过滤条件，当前时间在构建时取，同一语句再次执行时使用新的时间
+type expiryExpr struct {
+	column  clause.Column
+	expired bool
+}
+
+func (e expiryExpr) Build(builder clause.Builder) {
+	now := time.Now()
+	if stmt, ok := builder.(*gorm.Statement); ok {
+		now = stmt.DB.NowFunc()
+	}
+	if e.expired {
+		clause.And(clause.Neq{Column: e.column, Value: nil}, clause.Lte{Column: e.column, Value: now}).Build(builder)
+		return
+	}
+	clause.Or(clause.Eq{Column: e.column, Value: nil}, clause.Gt{Column: e.column, Value: now}).Build(builder)
+}
+
+// yields 判断删除子句是否让给模型中的其他软删除字段，ExpiresAt 与其他字段同时存在时由后者处理删除和恢复
+func (sd SoftDeleteDeleteClause) yields() bool {
+	if !sd.Expires {
+		return false
+	}
+	for _, c := range sd.Field.Schema.DeleteClauses {
+		if other, ok := c.(SoftDeleteDeleteClause); ok && !other.Expires {
+			return true
+		}
+	}
+	_, ok := ruleOf(sd.Field.Schema)
+	return ok
+}
@@ -0,0 +1,93 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoginSession struct {
+	ID        uint
+	Token     string
+	ExpiresAt ExpiresAt
+}
+
+type Invite struct {
+	ID        uint
+	Code      string
+	Deleted   DeletedAt `gorm:"softDelete:flag"`
+	ExpiresAt ExpiresAt
+}
+
+func expiresAt(t time.Time) ExpiresAt {
+	return ExpiresAt{Time: t, Valid: true}
+}
+
+func TestExpiresAtFilter(t *testing.T) {
+	db := pinNow(openDB(t, &LoginSession{}))
+	db.Create(&[]LoginSession{
+		{Token: "past", ExpiresAt: expiresAt(testNow.Add(-time.Minute))},
+		{Token: "future", ExpiresAt: expiresAt(testNow.Add(time.Minute))},
+		{Token: "never"},
+	})
+
+	var found []LoginSession
+	db.Order("id").Find(&found)
+	if len(found) != 2 || found[0].Token != "future" || found[1].Token != "never" {
+		t.Errorf("Find = %+v", found)
+	}
+
+	// 时钟前进后未来的记录同样过期
+	later := db.Session(&gorm.Session{NowFunc: func() time.Time { return testNow.Add(time.Hour) }})
+	found = nil
+	later.Find(&found)
+	if len(found) != 1 || found[0].Token != "never" {
+		t.Errorf("Find after an hour = %+v", found)
+	}
+}
+
+func TestExpiresAtDelete(t *testing.T) {
+	db := pinNow(openDB(t, &LoginSession{}))
+	s := LoginSession{Token: "a"}
+	db.Create(&s)
+	if err := db.Delete(&s).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !s.ExpiresAt.ExpiredAt(testNow) {
+		t.Errorf("ExpiresAt = %+v, want expired at %v", s.ExpiresAt, testNow)
+	}
+	var count int64
+	db.Model(&LoginSession{}).Count(&count)
+	if count != 0 {
+		t.Errorf("count = %d after delete", count)
+	}
+}
+
+func TestExpiresAtWithDeletedAt(t *testing.T) {
+	db := pinNow(openDB(t, &Invite{}))
+	invites := []Invite{
+		{Code: "active"},
+		{Code: "expired", ExpiresAt: expiresAt(testNow.Add(-time.Minute))},
+		{Code: "deleted"},
+	}
+	db.Create(&invites)
+	if err := db.Delete(&invites[2]).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var found []Invite
+	db.Find(&found)
+	if len(found) != 1 || found[0].Code != "active" {
+		t.Errorf("Find = %+v", found)
+	}
+	// 删除由 DeletedAt 处理，不写入过期时间
+	var deleted Invite
+	db.Unscoped().First(&deleted, invites[2].ID)
+	if deleted.Deleted.IsActive() || deleted.ExpiresAt.Valid {
+		t.Errorf("deleted = %+v", deleted)
+	}
+
+	sql := sqlOf(t, dryRunDB(t, "sqlite").Find(&[]Invite{}))
+	assertContains(t, sql, "`invites`.`deleted` =", "`invites`.`expires_at` IS NULL OR `invites`.`expires_at` >")
+}
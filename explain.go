@@ -0,0 +1,119 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const decisionClauseName = "soft_delete:decision"
+
+// SkipReason 为语句没有添加过滤条件的原因
+type SkipReason string
+
+const (
+	// SkipNoField 语句没有模型，或模型没有软删除字段
+	SkipNoField SkipReason = "no_soft_delete_field"
+	// SkipUnscoped 语句为 Unscoped
+	SkipUnscoped SkipReason = "unscoped"
+	// SkipClause 语句带有 Skip 子句
+	SkipClause SkipReason = "skip_clause"
+	// SkipWithDeleted 会话使用了 WithDeleted 或 PreloadWithDeleted
+	SkipWithDeleted SkipReason = "with_deleted"
+	// SkipContext context 由 IncludeDeletedContext 创建
+	SkipContext SkipReason = "include_deleted_context"
+	// SkipUpdateDeleted 更新时使用了 UpdateDeleted
+	SkipUpdateDeleted SkipReason = "update_deleted"
+	// SkipDefaultScope 模型的标签声明了 DisableDefaultScope
+	SkipDefaultScope SkipReason = "default_scope_disabled"
+)
+
+// Decision 记录查询、更新语句是否添加了过滤条件
+type Decision struct {
+	Applied bool
+	// Column 为过滤的列，带有表名或别名
+	Column string
+	// Value 为比较的值，ExpiresAt、AsOf 等与时间比较的条件为 nil
+	Value interface{}
+	// OnlyDeleted 为 true 时过滤条件为已删除
+	OnlyDeleted bool
+	// AsOf 为 true 时过滤条件为 AsOf 指定时间的有效区间
+	AsOf bool
+	// Reason 为没有添加过滤条件的原因，Applied 时为空
+	Reason SkipReason
+}
+
+type decisionClause struct {
+	decision Decision
+}
+
+func (decisionClause) Name() string {
+	return decisionClauseName
+}
+
+func (decisionClause) Build(clause.Builder) {
+}
+
+func (d decisionClause) MergeClause(cl *clause.Clause) {
+	cl.Expression = d
+}
+
+// Applied 判断 tx 执行的查询或更新是否添加了软删除的过滤条件
+//
+//	tx := db.Find(&users)
+//	soft_delete.Applied(tx)
+func Applied(tx *gorm.DB) bool {
+	return Explain(tx).Applied
+}
+
+// Explain 返回 tx 执行的查询或更新添加的过滤条件，或没有添加的原因，用于排查记录为何查不到
+func Explain(tx *gorm.DB) Decision {
+	if c, ok := tx.Statement.Clauses[decisionClauseName]; ok {
+		if d, ok := c.Expression.(decisionClause); ok {
+			return d.decision
+		}
+	}
+	return Decision{Reason: SkipNoField}
+}
+
+// recordDecision 在语句中记录过滤的结果，expr 为添加的过滤条件，跳过时为 nil
+func (sd SoftDeleteQueryClause) recordDecision(stmt *gorm.Statement, expr clause.Expression, reason SkipReason) {
+	d := Decision{Reason: reason}
+	if expr != nil {
+		column := sd.filterColumn(stmt)
+		if sd.TimestampColumn != "" {
+			column.Name = sd.TimestampColumn
+		}
+		if column.Table == clause.CurrentTable {
+			column.Table = stmt.Table
+		}
+		d = Decision{Applied: true, Column: column.Table + "." + column.Name, OnlyDeleted: isOnlyDeleted(stmt)}
+		if legacy, ok := expr.(legacyExpr); ok {
+			expr = legacy.flag
+		}
+		switch e := expr.(type) {
+		case clause.Eq:
+			d.Value = rawValue(e.Value)
+		case clause.Neq:
+			d.Value = rawValue(e.Value)
+		case nullableExpr:
+			d.Value = rawValue(e.active)
+		case validAt:
+			d.AsOf = true
+		}
+	}
+	recordClause(stmt, decisionClause{decision: d})
+}
+
+// skipReason 按 applyFilter 判断的顺序返回跳过过滤的原因
+func (sd SoftDeleteQueryClause) skipReason(stmt *gorm.Statement) SkipReason {
+	switch {
+	case sd.DisableDefaultScope:
+		return SkipDefaultScope
+	case isSkipped(stmt):
+		return SkipClause
+	case isWithDeleted(stmt):
+		return SkipWithDeleted
+	default:
+		return SkipContext
+	}
+}
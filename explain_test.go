@@ -0,0 +1,72 @@
+package soft_delete
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type ScopeFreeUser struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag,DisableDefaultScope"`
+}
+
+func TestExplainApplied(t *testing.T) {
+	db := openDB(t, &User{})
+
+	tx := db.Find(&[]User{})
+	if !Applied(tx) {
+		t.Fatal("Find should be filtered")
+	}
+	d := Explain(tx)
+	if d.Column != "users.deleted" || d.Value != false || d.OnlyDeleted || d.Reason != "" {
+		t.Errorf("Find: %+v", d)
+	}
+
+	// 已删除的条件为 deleted <> false，比较的值相同
+	d = Explain(db.Scopes(OnlyDeleted).Find(&[]User{}))
+	if !d.Applied || !d.OnlyDeleted || d.Value != false {
+		t.Errorf("OnlyDeleted: %+v", d)
+	}
+	d = Explain(db.Table("users AS u").Find(&[]User{}))
+	if d.Column != "u.deleted" {
+		t.Errorf("alias: %+v", d)
+	}
+	d = Explain(db.Model(&User{}).Where("id = ?", 1).Update("name", "x"))
+	if !d.Applied || d.Column != "users.deleted" {
+		t.Errorf("Update: %+v", d)
+	}
+}
+
+func TestExplainSkipReasons(t *testing.T) {
+	db := openDB(t, &User{}, &ScopeFreeUser{}, &Plain{})
+	withContext := db.WithContext(IncludeDeletedContext(context.Background()))
+
+	tests := []struct {
+		name string
+		tx   *gorm.DB
+		want SkipReason
+	}{
+		{"no field", db.Find(&[]Plain{}), SkipNoField},
+		{"table without model", db.Table("users").Find(&[]map[string]interface{}{}), SkipNoField},
+		{"Unscoped", db.Unscoped().Find(&[]User{}), SkipUnscoped},
+		{"Skip", db.Clauses(Skip{}).Find(&[]User{}), SkipClause},
+		{"WithDeleted", db.Scopes(WithDeleted).Find(&[]User{}), SkipWithDeleted},
+		{"context", withContext.Find(&[]User{}), SkipContext},
+		{"UpdateDeleted", db.Model(&User{}).Scopes(UpdateDeleted).Where("id = ?", 1).Update("name", "x"), SkipUpdateDeleted},
+		{"Unscoped update", db.Unscoped().Model(&User{}).Where("id = ?", 1).Update("name", "x"), SkipUnscoped},
+		{"DisableDefaultScope", db.Find(&[]ScopeFreeUser{}), SkipDefaultScope},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.tx.Error != nil {
+				t.Fatal(tt.tx.Error)
+			}
+			d := Explain(tt.tx)
+			if d.Applied || Applied(tt.tx) || d.Reason != tt.want {
+				t.Errorf("Decision = %+v, want reason %s", d, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package soft_delete
+
+import (
+	"database/sql/driver"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// QueryOperator 的取值
+const (
+	// OperatorEq 以等于未删除的值过滤
+	OperatorEq = "="
+	// OperatorNeq 以不等于已删除的值过滤，用于除两种状态外还有其他取值的列
+	OperatorNeq = "<>"
+)
+
+// FlagValue 描述一种标记列的取值，只需实现取值即可作为 Field 的类型参数，
+// 查询、更新、删除子句与 DeletedAt 共用。方法在类型的零值上调用
+type FlagValue interface {
+	ActiveValue() driver.Value
+	DeletedValue() driver.Value
+	QueryOperator() string
+}
+
+// Field 是以 T 的取值表示删除状态的软删除字段，true 表示已删除。例如以 int16 的 0/1 表示：
+//
+//	type Int16Flag struct{}
+//
+//	func (Int16Flag) ActiveValue() driver.Value  { return int64(0) }
+//	func (Int16Flag) DeletedValue() driver.Value { return int64(1) }
+//	func (Int16Flag) QueryOperator() string      { return soft_delete.OperatorEq }
+//
+//	Deleted soft_delete.Field[Int16Flag] `gorm:"type:smallint;default:0"`
+//
+// DeletedAt 没有改为 Field 的别名：它的取值在解析模型时取 FlagDeleted、FlagActived 和 SetValueMode，
+// 内存中的 true 也不一定表示已删除；Scan 还接受整数、字符串和 gorm.DeletedAt 的时间戳，并有自己的 JSON、文本编码和按 db 绑定的 GormValue。
+// Go 不允许在 Field[X] 这样的实例化类型上声明方法，改为别名后这些行为只能放进 Field，会改变所有 Field[T] 的读写
+type Field[T FlagValue] bool
+
+func (Field[T]) flagValues() flagValues {
+	var t T
+	return flagValues{active: t.ActiveValue(), deleted: t.DeletedValue(), byDeleted: t.QueryOperator() == OperatorNeq}
+}
+
+func (Field[T]) fromDeleted(deleted bool) interface{} {
+	return Field[T](deleted)
+}
+
+// IsDeleted 判断记录是否已删除
+func (d Field[T]) IsDeleted() bool {
+	return bool(d)
+}
+
+// IsActive 判断记录是否未删除
+func (d Field[T]) IsActive() bool {
+	return !bool(d)
+}
+
+// String 返回 "deleted" 或 "active"
+func (d Field[T]) String() string {
+	return stateString(bool(d))
+}
+
+// MarkDeleted 将标记设为已删除，只修改内存中的值
+func (d *Field[T]) MarkDeleted() {
+	*d = true
+}
+
+// MarkActive 将标记设为未删除，只修改内存中的值
+func (d *Field[T]) MarkActive() {
+	*d = false
+}
+
+func (d Field[T]) QueryClauses(f *schema.Field) []clause.Interface {
+	return flagQueryClauses(f, d.flagValues(), d.fromDeleted)
+}
+
+func (Field[T]) UpdateClauses(f *schema.Field) []clause.Interface {
+	return flagUpdateClauses(f)
+}
+
+func (d Field[T]) DeleteClauses(f *schema.Field) []clause.Interface {
+	return flagDeleteClauses(f, d.flagValues(), d.fromDeleted)
+}
+
+// 实现 driver.Valuer 接口
+func (d Field[T]) Value() (driver.Value, error) {
+	var t T
+	if d {
+		return t.DeletedValue(), nil
+	}
+	return t.ActiveValue(), nil
+}
+
+// 实现 sql.Scanner 接口，NULL 视为未删除
+func (d *Field[T]) Scan(value interface{}) error {
+	if value == nil {
+		*d = false
+		return nil
+	}
+
+	var t T
+	if t.QueryOperator() == OperatorNeq {
+		*d = Field[T](sameValue(value, t.DeletedValue()))
+	} else {
+		*d = Field[T](!sameValue(value, t.ActiveValue()))
+	}
+	return nil
+}
+
+// flagQueryClauses 绑定字段的取值并返回查询子句，各标记类型共用
+func flagQueryClauses(f *schema.Field, defaults flagValues, fromDeleted func(deleted bool) interface{}) []clause.Interface {
+	bindFlagValues(f, defaults, fromDeleted)
+	return []clause.Interface{SoftDeleteQueryClause{Field: f, Flag: true}.withQuerySettings()}
+}
+
+func flagUpdateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{SoftDeleteUpdateClause{Field: f, Flag: true}}
+}
+
+// flagDeleteClauses 绑定字段的取值并返回删除子句，各标记类型共用
+func flagDeleteClauses(f *schema.Field, defaults flagValues, fromDeleted func(deleted bool) interface{}) []clause.Interface {
+	bindFlagValues(f, defaults, fromDeleted)
+	settings := parseSettings(f)
+	softDeleteClause := SoftDeleteDeleteClause{
+		Field:    f,
+		Flag:     true,
+		TimeType: getTimeType(settings),
+	}
+	return []clause.Interface{softDeleteClause.withCompanionFields(settings)}
+}
@@ -0,0 +1,75 @@
+package soft_delete
+
+import (
+	"database/sql/driver"
+	"testing"
+)
+
+type int16Flag struct{}
+
+func (int16Flag) ActiveValue() driver.Value  { return int64(0) }
+func (int16Flag) DeletedValue() driver.Value { return int64(1) }
+func (int16Flag) QueryOperator() string      { return OperatorEq }
+
+type archivedFlag struct{}
+
+func (archivedFlag) ActiveValue() driver.Value  { return "live" }
+func (archivedFlag) DeletedValue() driver.Value { return "archived" }
+func (archivedFlag) QueryOperator() string      { return OperatorNeq }
+
+type Int16User struct {
+	ID      uint
+	Deleted Field[int16Flag] `gorm:"type:smallint;default:0"`
+}
+
+type StateUser struct {
+	ID    uint
+	State Field[archivedFlag] `gorm:"default:live"`
+}
+
+// Field 的取值由类型参数提供，子句与 DeletedAt 共用
+func TestField(t *testing.T) {
+	db := openDB(t, &Int16User{}, &StateUser{})
+	db.Create(&[]Int16User{{}, {}})
+	db.Delete(&Int16User{ID: 1})
+
+	var raw []int64
+	db.Table("int16_users").Order("id").Pluck("deleted", &raw)
+	if len(raw) != 2 || raw[0] != 1 || raw[1] != 0 {
+		t.Errorf("stored = %v, want [1 0]", raw)
+	}
+	var users []Int16User
+	db.Find(&users)
+	if len(users) != 1 || users[0].ID != 2 || users[0].Deleted.IsDeleted() {
+		t.Errorf("active = %+v", users)
+	}
+	var deleted Int16User
+	if err := db.Scopes(OnlyDeleted).First(&deleted).Error; err != nil || !deleted.Deleted.IsDeleted() {
+		t.Errorf("deleted = %+v, %v", deleted, err)
+	}
+
+	db.Create(&[]StateUser{{}, {}})
+	db.Exec("UPDATE state_users SET state = ? WHERE id = ?", "pending", 2)
+	db.Delete(&StateUser{ID: 1})
+	var states []StateUser
+	db.Find(&states)
+	if len(states) != 1 || states[0].ID != 2 || states[0].State.IsDeleted() {
+		t.Errorf("visible = %+v", states)
+	}
+	assertContains(t, sqlOf(t, dryRun(db).Find(&[]StateUser{})), "`state_users`.`state` <> ?")
+}
+
+func TestFieldScan(t *testing.T) {
+	var d Field[int16Flag]
+	for _, tt := range []struct {
+		value   interface{}
+		deleted bool
+	}{{nil, false}, {int64(0), false}, {int64(1), true}, {[]byte("1"), true}} {
+		if err := d.Scan(tt.value); err != nil || d.IsDeleted() != tt.deleted {
+			t.Errorf("Scan(%v) = %v, %v", tt.value, d, err)
+		}
+	}
+	if v, _ := Field[int16Flag](true).Value(); v != int64(1) {
+		t.Errorf("Value = %v", v)
+	}
+}
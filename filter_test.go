@@ -0,0 +1,80 @@
+package soft_delete
+
+import (
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/clause"
+)
+
+// 同一字段的过滤条件只构建一次，各语句共用
+func TestFilterExprsCached(t *testing.T) {
+	db := openDB(t, &User{})
+	field := fieldOf(t, db, &User{}, "Deleted")
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+
+	a := filterExprsOf(field, true, column)
+	if cached, ok := fieldFilterExprs.Load(filterExprsKey{field: field, flag: true}); !ok || cached.(filterExprs) != a {
+		t.Errorf("filter expressions of %s are not cached", field.Name)
+	}
+	// 其他表名的条件不使用缓存
+	if other := filterExprsOf(field, true, clause.Column{Table: "u", Name: field.DBName}); other == a {
+		t.Error("aliased column shares the cached expression")
+	}
+}
+
+// 共用的过滤条件在并发构建语句时只读，需要 go test -race
+func TestFilterExprsConcurrent(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+	want := sqlOf(t, db.Where("name = ?", "a").Find(&[]User{}))
+
+	var wg sync.WaitGroup
+	sqls := make([]string, 16)
+	for i := range sqls {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				tx := db.Where("name = ?", "a").Find(&[]User{})
+				sqls[i] = tx.Statement.SQL.String()
+			}
+		}(i)
+	}
+	wg.Wait()
+	for _, sql := range sqls {
+		if sql != want {
+			t.Errorf("concurrent SQL %q, want %q", sql, want)
+		}
+	}
+}
+
+func BenchmarkQueryClause(b *testing.B) {
+	db := dryRunDB(b, "sqlite")
+	db.Find(&[]User{})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		db.Find(&[]User{})
+	}
+}
+
+func BenchmarkFilterExprs(b *testing.B) {
+	db := dryRunDB(b, "sqlite")
+	stmt := db.Model(&User{}).Statement
+	stmt.Parse(&User{})
+	field := stmt.Schema.LookUpField("Deleted")
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = filterExprsOf(field, true, column)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = filterExprs{active: activeExpr(field, true, column), deleted: deletedExpr(field, true, column)}
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package soft_delete
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const assignSettingKey = "soft_delete:assign"
+
+// Assign 设置 FirstOrRestore 恢复或创建记录时更新的属性，attrs 为结构体或 map
+//
+//	soft_delete.FirstOrRestore(soft_delete.Assign(db, map[string]interface{}{"name": name}), &user, "email = ?", email)
+func Assign(db *gorm.DB, attrs interface{}) *gorm.DB {
+	return db.Set(assignSettingKey, attrs)
+}
+
+func assignsOf(db *gorm.DB) interface{} {
+	attrs, _ := db.Get(assignSettingKey)
+	return attrs
+}
+
+// FirstOrRestore 与 FirstOrCreate 类似，但会找回已删除的记录：有未删除的匹配记录时直接返回；
+// 只有已删除的记录时恢复该记录，并更新 Assign 设置的属性；都没有时创建。
+// 查找和恢复在同一事务中执行，并发创建违反唯一约束时重试一次，此时通常会找到其他请求创建或恢复的记录
+func FirstOrRestore(db *gorm.DB, dest interface{}, conds ...interface{}) error {
+	err := firstOrRestore(db, dest, conds...)
+	if err != nil && isDuplicatedKey(err) {
+		err = firstOrRestore(db, dest, conds...)
+	}
+	return err
+}
+
+func firstOrRestore(db *gorm.DB, dest interface{}, conds ...interface{}) error {
+	attrs := assignsOf(db)
+	return Transaction(db, func(tx *gorm.DB) error {
+		result := tx.Limit(1).Find(dest, conds...)
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+
+		result = tx.Scopes(OnlyDeleted).Limit(1).Find(dest, conds...)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			if attrs != nil {
+				tx = tx.Assign(attrs)
+			}
+			return tx.FirstOrCreate(dest, conds...).Error
+		}
+
+		restored, err := Restore(tx, dest)
+		if err != nil {
+			return err
+		}
+		if restored == 0 {
+			// 已被其他请求恢复，重新读取当前的值
+			return tx.Take(dest).Error
+		}
+		if attrs != nil {
+			return tx.Model(dest).Updates(attrs).Error
+		}
+		return nil
+	})
+}
+
+// isDuplicatedKey 判断错误是否为唯一约束冲突，未开启 TranslateError 时按常见驱动的错误信息判断
+func isDuplicatedKey(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "unique constraint") ||
+		strings.Contains(msg, "duplicate entry") ||
+		strings.Contains(msg, "duplicate key")
+}
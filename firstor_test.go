@@ -0,0 +1,92 @@
+package soft_delete
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+type Subscriber struct {
+	ID      uint
+	Email   string `gorm:"uniqueIndex"`
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+func TestFirstOrRestore(t *testing.T) {
+	db := openDB(t, &Subscriber{})
+	db.Create(&[]Subscriber{{Email: "active@x", Name: "a"}, {Email: "deleted@x", Name: "d"}})
+	db.Delete(&Subscriber{ID: 2})
+
+	var active Subscriber
+	if err := FirstOrRestore(db, &active, "email = ?", "active@x"); err != nil || active.ID != 1 {
+		t.Errorf("active = %+v, %v", active, err)
+	}
+
+	var restored Subscriber
+	err := FirstOrRestore(Assign(db, map[string]interface{}{"name": "again"}), &restored, "email = ?", "deleted@x")
+	if err != nil || restored.ID != 2 || restored.Deleted.IsDeleted() {
+		t.Fatalf("restored = %+v, %v", restored, err)
+	}
+	var got Subscriber
+	db.First(&got, 2)
+	if got.Name != "again" {
+		t.Errorf("assigned name = %q", got.Name)
+	}
+
+	var created Subscriber
+	if err := FirstOrRestore(db, &created, Subscriber{Email: "new@x"}); err != nil || created.ID != 3 {
+		t.Errorf("created = %+v, %v", created, err)
+	}
+	if n := countRows(t, db, "subscribers"); n != 3 {
+		t.Errorf("rows = %d, want 3", n)
+	}
+}
+
+// 两个请求同时恢复同一条记录，都得到该记录且不会创建重复的记录
+func TestFirstOrRestoreConcurrent(t *testing.T) {
+	db := openDB(t, &Subscriber{})
+	// SQLite 的事务由读升级为写时直接返回 SQLITE_BUSY，以单个连接使事务依次执行
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.SetMaxOpenConns(1)
+	}
+	db.Create(&Subscriber{Email: "x@x"})
+	db.Delete(&Subscriber{ID: 1})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	ids := make([]uint, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var s Subscriber
+			errs[i] = FirstOrRestore(db, &s, Subscriber{Email: "x@x"})
+			ids[i] = s.ID
+		}(i)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		t.Fatal(err)
+	}
+	if ids[0] != 1 || ids[1] != 1 {
+		t.Errorf("ids = %v, want [1 1]", ids)
+	}
+	if n := countRows(t, db, "subscribers"); n != 1 {
+		t.Errorf("rows = %d, want 1", n)
+	}
+}
+
+func TestIsDuplicatedKey(t *testing.T) {
+	for msg, want := range map[string]bool{
+		"UNIQUE constraint failed: users.email":     true,
+		"Error 1062: Duplicate entry 'a' for key":   true,
+		"duplicate key value violates unique index": true,
+		"no such table": false,
+	} {
+		if got := isDuplicatedKey(errors.New(msg)); got != want {
+			t.Errorf("isDuplicatedKey(%q) = %v", msg, got)
+		}
+	}
+}
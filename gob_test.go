@@ -0,0 +1,74 @@
+package soft_delete
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"encoding/hex"
+	"testing"
+)
+
+type cachedUser struct {
+	Name    string
+	Deleted DeletedAt
+	Unix    DeletedAtUnix
+	Null    NullDeletedAt
+}
+
+// gobFixture 为引入 NullDeletedAt 时的版本编码的 cachedUser{Name: "a", Deleted: true, Unix: 1690891200, Null: {true, true}}，
+// gob 以 bool、int64 和结构体编码三个类型，实现 BinaryUnmarshaler 或 GobDecoder 后 gob 会以类型不匹配拒绝这些数据
+const gobFixture = "3f7f0301010a6361636865645573657201ff8000010401044e616d65010c00010744656c657465640102000104556e697801040001044e756c6c01ff820000002eff810301010d4e756c6c44656c65746564417401ff820001020104466c6167010200010556616c6964010200000014ff80010161010101fcc991e78001010101010000"
+
+var gobFixtureValue = cachedUser{Name: "a", Deleted: true, Unix: 1690891200, Null: NullDeletedAt{Flag: true, Valid: true}}
+
+// 解码旧版本产生的数据，并且编码结果与旧版本相同
+func TestGobFixture(t *testing.T) {
+	data, _ := hex.DecodeString(gobFixture)
+	var got cachedUser
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got != gobFixtureValue {
+		t.Errorf("decoded %+v, want %+v", got, gobFixtureValue)
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(gobFixtureValue); err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(buf.Bytes()) != gobFixture {
+		t.Errorf("encoded %x, want %s", buf.Bytes(), gobFixture)
+	}
+}
+
+func TestGobRoundTrip(t *testing.T) {
+	for _, v := range []cachedUser{
+		{Name: "active"},
+		{Name: "deleted", Deleted: true, Unix: 1, Null: NullDeletedAt{Valid: true}},
+	} {
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+			t.Fatal(err)
+		}
+		var got cachedUser
+		if err := gob.NewDecoder(&buf).Decode(&got); err != nil || got != v {
+			t.Errorf("round trip %+v = %+v, %v", v, got, err)
+		}
+	}
+}
+
+// gob 按是否实现这些接口选择编码方式，实现任何一个都会改变编码格式
+func TestGobWireFormatUnchanged(t *testing.T) {
+	for name, v := range map[string]interface{}{
+		"DeletedAt":     new(DeletedAt),
+		"DeletedAtUnix": new(DeletedAtUnix),
+		"NullDeletedAt": new(NullDeletedAt),
+	} {
+		if _, ok := v.(encoding.BinaryUnmarshaler); ok {
+			t.Errorf("%s implements encoding.BinaryUnmarshaler", name)
+		}
+		if _, ok := v.(gob.GobDecoder); ok {
+			t.Errorf("%s implements gob.GobDecoder", name)
+		}
+	}
+}
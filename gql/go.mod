@@ -0,0 +1,15 @@
+module github.com/yanqin001/soft_delete/gql
+
+go 1.20
+
+require (
+	github.com/99designs/gqlgen v0.17.36
+	github.com/yanqin001/soft_delete v0.0.0-20261015102848-a1d77940a2ac
+)
+
+require (
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/vektah/gqlparser/v2 v2.5.8 // indirect
+	gorm.io/gorm v1.25.4 // indirect
+)
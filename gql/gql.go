@@ -0,0 +1,146 @@
+// Package gql 为软删除字段提供 gqlgen 的标量，只有引入本包时才依赖 gqlgen。
+// 在 gqlgen.yml 中将标量绑定到本包的同名函数，模型字段仍然使用 soft_delete 的类型：
+//
+//	scalar SoftDeleted
+//	scalar DeletedTime
+//
+//	models:
+//	  SoftDeleted:
+//	    model: github.com/yanqin001/soft_delete/gql.DeletedAt
+//	  DeletedTime:
+//	    model: github.com/yanqin001/soft_delete/gql.DeletedAtUnixTime
+package gql
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/yanqin001/soft_delete"
+)
+
+// MarshalDeletedAt 将标记输出为 Boolean
+func MarshalDeletedAt(b soft_delete.DeletedAt) graphql.Marshaler {
+	return graphql.MarshalBoolean(bool(b))
+}
+
+// UnmarshalDeletedAt 与 JSON 一样接受布尔值、数字和 "true"、"1" 等字符串，null 视为未删除
+func UnmarshalDeletedAt(v interface{}) (soft_delete.DeletedAt, error) {
+	var b soft_delete.DeletedAt
+	switch v := v.(type) {
+	case nil:
+		return b, nil
+	case int:
+		return v != 0, nil
+	case json.Number:
+		return b, b.UnmarshalText([]byte(v))
+	}
+	return b, b.Scan(v)
+}
+
+// MarshalDeletedAtUnix 输出 unix 秒，0 表示未删除
+func MarshalDeletedAtUnix(n soft_delete.DeletedAtUnix) graphql.Marshaler {
+	return graphql.MarshalInt64(int64(n))
+}
+
+// UnmarshalDeletedAtUnix 接受 unix 秒或 RFC 3339 时间
+func UnmarshalDeletedAtUnix(v interface{}) (soft_delete.DeletedAtUnix, error) {
+	n, err := unmarshalEpoch(v, time.Time.Unix)
+	return soft_delete.DeletedAtUnix(n), err
+}
+
+// MarshalDeletedAtUnixTime 输出 RFC 3339 时间，未删除时为 null
+func MarshalDeletedAtUnixTime(n soft_delete.DeletedAtUnix) graphql.Marshaler {
+	return marshalTime(int64(n), func(n int64) time.Time { return time.Unix(n, 0) })
+}
+
+// UnmarshalDeletedAtUnixTime 与 UnmarshalDeletedAtUnix 相同
+func UnmarshalDeletedAtUnixTime(v interface{}) (soft_delete.DeletedAtUnix, error) {
+	return UnmarshalDeletedAtUnix(v)
+}
+
+// MarshalDeletedAtMilli 输出 unix 毫秒，0 表示未删除
+func MarshalDeletedAtMilli(n soft_delete.DeletedAtMilli) graphql.Marshaler {
+	return graphql.MarshalInt64(int64(n))
+}
+
+// UnmarshalDeletedAtMilli 接受 unix 毫秒或 RFC 3339 时间
+func UnmarshalDeletedAtMilli(v interface{}) (soft_delete.DeletedAtMilli, error) {
+	n, err := unmarshalEpoch(v, time.Time.UnixMilli)
+	return soft_delete.DeletedAtMilli(n), err
+}
+
+// MarshalDeletedAtMilliTime 输出 RFC 3339 时间，未删除时为 null
+func MarshalDeletedAtMilliTime(n soft_delete.DeletedAtMilli) graphql.Marshaler {
+	return marshalTime(int64(n), time.UnixMilli)
+}
+
+// UnmarshalDeletedAtMilliTime 与 UnmarshalDeletedAtMilli 相同
+func UnmarshalDeletedAtMilliTime(v interface{}) (soft_delete.DeletedAtMilli, error) {
+	return UnmarshalDeletedAtMilli(v)
+}
+
+// MarshalDeletedAtNano 输出 unix 纳秒，0 表示未删除
+func MarshalDeletedAtNano(n soft_delete.DeletedAtNano) graphql.Marshaler {
+	return graphql.MarshalInt64(int64(n))
+}
+
+// UnmarshalDeletedAtNano 接受 unix 纳秒或 RFC 3339 时间
+func UnmarshalDeletedAtNano(v interface{}) (soft_delete.DeletedAtNano, error) {
+	n, err := unmarshalEpoch(v, time.Time.UnixNano)
+	return soft_delete.DeletedAtNano(n), err
+}
+
+// MarshalDeletedAtNanoTime 输出 RFC 3339 时间，未删除时为 null
+func MarshalDeletedAtNanoTime(n soft_delete.DeletedAtNano) graphql.Marshaler {
+	return marshalTime(int64(n), func(n int64) time.Time { return time.Unix(0, n) })
+}
+
+// UnmarshalDeletedAtNanoTime 与 UnmarshalDeletedAtNano 相同
+func UnmarshalDeletedAtNanoTime(v interface{}) (soft_delete.DeletedAtNano, error) {
+	return UnmarshalDeletedAtNano(v)
+}
+
+// marshalTime 将 unix 时间以 toTime 转换后输出为 RFC 3339 时间，0 输出 null
+func marshalTime(n int64, toTime func(int64) time.Time) graphql.Marshaler {
+	if n == 0 {
+		return graphql.Null
+	}
+	t := toTime(n).UTC()
+	return graphql.WriterFunc(func(w io.Writer) {
+		io.WriteString(w, strconv.Quote(t.Format(time.RFC3339Nano)))
+	})
+}
+
+// unmarshalEpoch 接受数字、数字字符串和 RFC 3339 时间，时间以 epoch 转换为对应的单位，null 和空字符串视为未删除
+func unmarshalEpoch(v interface{}, epoch func(time.Time) int64) (int64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case float64:
+		return int64(v), nil
+	case json.Number:
+		return v.Int64()
+	case string:
+		if v == "" {
+			return 0, nil
+		}
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return n, nil
+		}
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			return 0, fmt.Errorf("invalid deletion time: %q", v)
+		}
+		return epoch(t), nil
+	}
+	return 0, fmt.Errorf("%T is not a deletion time", v)
+}
@@ -0,0 +1,104 @@
+package gql
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+
+	"github.com/yanqin001/soft_delete"
+)
+
+func marshal(m graphql.Marshaler) string {
+	var buf bytes.Buffer
+	m.MarshalGQL(&buf)
+	return buf.String()
+}
+
+func TestDeletedAt(t *testing.T) {
+	if got := marshal(MarshalDeletedAt(true)); got != "true" {
+		t.Errorf("MarshalDeletedAt(true) = %s", got)
+	}
+	if got := marshal(MarshalDeletedAt(false)); got != "false" {
+		t.Errorf("MarshalDeletedAt(false) = %s", got)
+	}
+
+	tests := []struct {
+		in   interface{}
+		want soft_delete.DeletedAt
+	}{
+		{nil, false},
+		{true, true},
+		{false, false},
+		{1, true},
+		{0, false},
+		{json.Number("1"), true},
+		{"true", true},
+		{"0", false},
+	}
+	for _, tt := range tests {
+		got, err := UnmarshalDeletedAt(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("UnmarshalDeletedAt(%#v) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := UnmarshalDeletedAt("maybe"); err == nil {
+		t.Error("UnmarshalDeletedAt(\"maybe\") should fail")
+	}
+}
+
+func TestDeletedAtUnix(t *testing.T) {
+	const ts = 1690891200 // 2023-08-01T12:00:00Z
+	if got := marshal(MarshalDeletedAtUnix(ts)); got != "1690891200" {
+		t.Errorf("MarshalDeletedAtUnix = %s", got)
+	}
+	if got := marshal(MarshalDeletedAtUnixTime(ts)); got != `"2023-08-01T12:00:00Z"` {
+		t.Errorf("MarshalDeletedAtUnixTime = %s", got)
+	}
+	if got := marshal(MarshalDeletedAtUnixTime(0)); got != "null" {
+		t.Errorf("MarshalDeletedAtUnixTime(0) = %s", got)
+	}
+
+	for _, in := range []interface{}{ts, int64(ts), float64(ts), json.Number("1690891200"), "1690891200", "2023-08-01T12:00:00Z"} {
+		got, err := UnmarshalDeletedAtUnixTime(in)
+		if err != nil || got != ts {
+			t.Errorf("UnmarshalDeletedAtUnix(%#v) = %v, %v", in, got, err)
+		}
+	}
+	for _, in := range []interface{}{nil, ""} {
+		if got, err := UnmarshalDeletedAtUnix(in); err != nil || got != 0 {
+			t.Errorf("UnmarshalDeletedAtUnix(%#v) = %v, %v, want 0", in, got, err)
+		}
+	}
+	for _, in := range []interface{}{"yesterday", true} {
+		if _, err := UnmarshalDeletedAtUnix(in); err == nil {
+			t.Errorf("UnmarshalDeletedAtUnix(%#v) should fail", in)
+		}
+	}
+}
+
+func TestDeletedAtMilliAndNano(t *testing.T) {
+	const at = "2023-08-01T12:00:00.5Z"
+	milli, err := UnmarshalDeletedAtMilliTime(at)
+	if err != nil || milli != 1690891200500 {
+		t.Errorf("UnmarshalDeletedAtMilli = %v, %v", milli, err)
+	}
+	if got := marshal(MarshalDeletedAtMilliTime(milli)); got != `"`+at+`"` {
+		t.Errorf("MarshalDeletedAtMilliTime = %s", got)
+	}
+	if got := marshal(MarshalDeletedAtMilli(milli)); got != "1690891200500" {
+		t.Errorf("MarshalDeletedAtMilli = %s", got)
+	}
+
+	nano, err := UnmarshalDeletedAtNanoTime(at)
+	if err != nil || nano != 1690891200500000000 {
+		t.Errorf("UnmarshalDeletedAtNano = %v, %v", nano, err)
+	}
+	if got := marshal(MarshalDeletedAtNanoTime(nano)); got != `"`+at+`"` {
+		t.Errorf("MarshalDeletedAtNanoTime = %s", got)
+	}
+	if got := marshal(MarshalDeletedAtNano(0)); got != "0" {
+		t.Errorf("MarshalDeletedAtNano(0) = %s", got)
+	}
+}
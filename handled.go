@@ -0,0 +1,79 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ClauseEnabled 为添加了未删除过滤条件的语句记录在 stmt.Clauses 中的名称，
+// 与 gorm 的 checkMissingWhereConditions 约定一致，不会改变
+const ClauseEnabled = softDeleteEnabledClauseName
+
+// ClauseHandled 为 MarkHandled 记录在 stmt.Clauses 中的名称
+const ClauseHandled = "soft_delete:handled"
+
+// 软删除记录在 stmt.Settings 中的键，值的类型不属于公开约定，只应判断是否存在
+const (
+	SettingWithDeleted   = withDeletedSettingKey
+	SettingUpdateDeleted = updateDeletedSettingKey
+)
+
+// 软删除注册的回调名称，其他插件可以通过 Before、After 明确与它们的先后顺序：
+//
+//	db.Callback().Delete().After(soft_delete.CallbackArchive).Register("my:encrypt", encrypt)
+//
+// 过滤条件、删除改写为 UPDATE 不是回调，而是 gorm:query、gorm:row、gorm:update、gorm:delete、gorm:create
+// 构建 SQL 时由字段的 QueryClauses 等子句完成，因此在这些回调之前 IsHandled 总是返回 false，
+// 需要判断语句是否由软删除处理的插件应注册在它们之后，或者在自己的子句构建时判断
+const (
+	// CallbackArchive 在 gorm:delete 之前将删除改写为移入归档表，在 gorm:query、gorm:row 之前改为查询归档表
+	CallbackArchive = archiveCallbackName
+	// CallbackAssociation 在 gorm:update 之前将关联解除改写为软删除
+	CallbackAssociation = associationCallbackName
+	// CallbackRules 在各 gorm 回调之前为 Register 注册的表添加软删除子句
+	CallbackRules = rulesCallbackName
+	// CallbackJoinTable 在 gorm:create 之前恢复多对多关联中已软删除的中间表记录
+	CallbackJoinTable = joinTableCallbackName
+	// CallbackAuditDelete、CallbackAuditRestore 在 gorm:delete、gorm:update 之后、提交事务之前写入审计记录
+	CallbackAuditDelete  = auditDeleteCallbackName
+	CallbackAuditRestore = auditRestoreCallbackName
+	// CallbackExcludedDeleted 在 gorm:update 之后、提交事务之前检查没有更新任何行的 UPDATE 是否匹配了已删除的记录
+	CallbackExcludedDeleted = excludedCallbackName
+	// CallbackEventsDelete、CallbackEventsRestore 在提交事务之后发布事件
+	CallbackEventsDelete  = eventsDeleteCallbackName
+	CallbackEventsRestore = eventsRestoreCallbackName
+)
+
+// handledClause 为 MarkHandled 记录的标记
+type handledClause struct{}
+
+func (handledClause) Name() string {
+	return ClauseHandled
+}
+
+func (handledClause) Build(clause.Builder) {
+}
+
+func (c handledClause) MergeClause(cl *clause.Clause) {
+	cl.Expression = c
+}
+
+// MarkHandled 标记语句已按软删除处理，用于自行改写软删除语句的插件，之后 IsHandled 返回 true。
+// 标记不影响软删除自身的过滤和改写
+func MarkHandled(stmt *gorm.Statement) {
+	recordClause(stmt, handledClause{})
+}
+
+// IsHandled 判断语句是否已由软删除处理：添加了软删除的查询、删除、更新、创建子句，或者已调用 MarkHandled
+// 查询因 Unscoped、WithDeleted 等跳过过滤时仍然返回 true，是否添加了过滤条件可以通过 Explain 判断
+func IsHandled(stmt *gorm.Statement) bool {
+	if stmt == nil {
+		return false
+	}
+	for _, name := range []string{ClauseHandled, ClauseQuery, ClauseDelete, ClauseUpdate, ClauseCreate} {
+		if _, ok := stmt.Clauses[name]; ok {
+			return true
+		}
+	}
+	return false
+}
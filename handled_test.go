@@ -0,0 +1,109 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// 软删除子句在执行后的语句中可以按名称找到
+func TestClauseNames(t *testing.T) {
+	db := openDB(t, &User{})
+	dry := dryRun(db)
+
+	tests := []struct {
+		name string
+		tx   *gorm.DB
+	}{
+		{ClauseQuery, dry.Find(&[]User{})},
+		{ClauseDelete, dry.Delete(&User{ID: 1})},
+		{ClauseUpdate, dry.Model(&User{ID: 1}).Update("name", "x")},
+		{ClauseCreate, dry.Create(&User{Name: "x"})},
+	}
+	for _, tt := range tests {
+		c, ok := tt.tx.Statement.Clauses[tt.name]
+		if !ok {
+			t.Errorf("%s not found in %v", tt.name, tt.tx.Statement.Clauses)
+			continue
+		}
+		if named, ok := c.Expression.(interface{ Name() string }); !ok || named.Name() != tt.name {
+			t.Errorf("%s expression = %#v", tt.name, c.Expression)
+		}
+	}
+}
+
+// 重复添加子句只保留一个，普通查询的 SQL 不变
+func TestClauseMerge(t *testing.T) {
+	db := openDB(t, &User{})
+	_, sd, err := parseDeleteClause(db, &User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := SoftDeleteQueryClause{Field: sd.Field, Flag: true}
+	sql := sqlOf(t, dryRun(db).Clauses(query, query).Find(&[]User{}))
+	if sql != "SELECT * FROM `users` WHERE `users`.`deleted` = ?" {
+		t.Errorf("sql = %s", sql)
+	}
+}
+
+// 导出的名称是对外的约定，修改需要同时修改此测试
+func TestExportedKeysStable(t *testing.T) {
+	keys := map[string]string{
+		ClauseEnabled:           "soft_delete_enabled",
+		ClauseHandled:           "soft_delete:handled",
+		ClauseQuery:             "soft_delete:query",
+		ClauseDelete:            "soft_delete:delete",
+		ClauseUpdate:            "soft_delete:update",
+		ClauseCreate:            "soft_delete:create",
+		SettingWithDeleted:      "soft_delete:with_deleted",
+		SettingUpdateDeleted:    "soft_delete:update_deleted",
+		CallbackArchive:         "soft_delete:archive",
+		CallbackAssociation:     "soft_delete:association",
+		CallbackRules:           "soft_delete:rules",
+		CallbackJoinTable:       "soft_delete:join_table",
+		CallbackAuditDelete:     "soft_delete:audit_delete",
+		CallbackAuditRestore:    "soft_delete:audit_restore",
+		CallbackExcludedDeleted: "soft_delete:excluded_deleted",
+		CallbackEventsDelete:    "soft_delete:events_delete",
+		CallbackEventsRestore:   "soft_delete:events_restore",
+	}
+	for got, want := range keys {
+		if got != want {
+			t.Errorf("key = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestIsHandled(t *testing.T) {
+	db := openDB(t, &User{}, &Plain{})
+
+	// 注册在 gorm:query 之后的插件可以判断语句是否由软删除处理
+	var handled []bool
+	db.Callback().Query().After("gorm:query").Register("test:handled", func(tx *gorm.DB) {
+		handled = append(handled, IsHandled(tx.Statement))
+	})
+	db.Find(&[]User{})
+	db.Unscoped().Find(&[]User{})
+	db.Find(&[]Plain{})
+	if len(handled) != 3 || !handled[0] || !handled[1] || handled[2] {
+		t.Errorf("IsHandled = %v, want [true true false]", handled)
+	}
+
+	stmt := db.Session(&gorm.Session{}).Model(&Plain{}).Statement
+	if IsHandled(stmt) {
+		t.Fatal("new statement should not be handled")
+	}
+	MarkHandled(stmt)
+	if !IsHandled(stmt) {
+		t.Error("IsHandled after MarkHandled = false")
+	}
+	if IsHandled(nil) {
+		t.Error("IsHandled(nil) = true")
+	}
+
+	// 标记不影响过滤
+	tx := db.Clauses(handledClause{}).Find(&[]User{})
+	if !Applied(tx) {
+		t.Error("MarkHandled should not disable the filter")
+	}
+}
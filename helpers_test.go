@@ -0,0 +1,105 @@
+package soft_delete
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testNow 为测试中固定的当前时间
+var testNow = time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+// openDB 返回使用临时 SQLite 文件的 db 并迁移 models，每个测试使用独立的数据库和 schema 缓存
+func openDB(t *testing.T, models ...interface{}) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_pragma=busy_timeout(5000)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if len(models) > 0 {
+		if err := db.AutoMigrate(models...); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+	}
+	return db
+}
+
+// namedDialector 以其他数据库的名称包装 SQLite，用于在 DryRun 下检查按数据库生成的 SQL
+type namedDialector struct {
+	gorm.Dialector
+	name string
+}
+
+func (d namedDialector) Name() string {
+	return d.name
+}
+
+// dryRunDB 返回名称为 dialect、只生成 SQL 的 db
+func dryRunDB(t testing.TB, dialect string) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "dryrun.db")
+	db, err := gorm.Open(namedDialector{Dialector: sqlite.Open(dsn), name: dialect}, &gorm.Config{
+		Logger:  logger.Discard,
+		DryRun:  true,
+		NowFunc: func() time.Time { return testNow },
+	})
+	if err != nil {
+		t.Fatalf("open %s: %v", dialect, err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// sqlOf 返回语句生成的 SQL，有错误时失败
+func sqlOf(t *testing.T, tx *gorm.DB) string {
+	t.Helper()
+	if tx.Error != nil {
+		t.Fatalf("unexpected error: %v", tx.Error)
+	}
+	return tx.Statement.SQL.String()
+}
+
+// assertContains 检查 s 包含 want 中的每一项
+func assertContains(t *testing.T, s string, want ...string) {
+	t.Helper()
+	for _, w := range want {
+		if !strings.Contains(s, w) {
+			t.Errorf("%q does not contain %q", s, w)
+		}
+	}
+}
+
+// assertNotContains 检查 s 不包含 unwanted 中的任何一项
+func assertNotContains(t *testing.T, s string, unwanted ...string) {
+	t.Helper()
+	for _, u := range unwanted {
+		if strings.Contains(s, u) {
+			t.Errorf("%q contains %q", s, u)
+		}
+	}
+}
+
+// countRows 返回表中包括已删除在内的记录数
+func countRows(t *testing.T, db *gorm.DB, table string) int64 {
+	t.Helper()
+	var n int64
+	if err := db.Table(table).Count(&n).Error; err != nil {
+		t.Fatalf("count %s: %v", table, err)
+	}
+	return n
+}
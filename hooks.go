@@ -0,0 +1,57 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+)
+
+// HookMode 决定软删除改写为 UPDATE 后调用模型的哪些钩子。语句总是在 gorm 的 Delete 回调链中执行，
+// 注册在 Update 回调链上的插件在任何模式下都不会执行
+type HookMode int
+
+const (
+	// HookDelete 只调用 BeforeDelete、AfterDelete，与物理删除一致，为默认的模式
+	HookDelete HookMode = iota
+	// HookUpdate 只调用 BeforeSave、BeforeUpdate、AfterUpdate、AfterSave，适用于按更新处理软删除的模型
+	HookUpdate
+	// HookBoth 先调用删除的钩子再调用更新的钩子
+	HookBoth
+)
+
+// SetHookMode 替换 db 上 gorm 的 before_delete、after_delete 回调，软删除时按 mode 调用钩子；
+// 物理删除、Unscoped 以及没有软删除字段的模型仍然只调用删除的钩子。
+// 单条、批量删除和级联软删除的子记录都按 mode 调用，子记录的钩子对每个关联调用一次，接收者为零值的模型
+//
+//	soft_delete.SetHookMode(db, soft_delete.HookUpdate)
+func SetHookMode(db *gorm.DB, mode HookMode) error {
+	if err := db.Callback().Delete().Replace("gorm:before_delete", func(tx *gorm.DB) {
+		if mode != HookUpdate || !willSoftDelete(tx.Statement) {
+			callbacks.BeforeDelete(tx)
+		}
+		if mode != HookDelete && willSoftDelete(tx.Statement) {
+			callbacks.BeforeUpdate(tx)
+		}
+	}); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Replace("gorm:after_delete", func(tx *gorm.DB) {
+		if mode != HookUpdate || !willSoftDelete(tx.Statement) {
+			callbacks.AfterDelete(tx)
+		}
+		if mode != HookDelete && willSoftDelete(tx.Statement) {
+			callbacks.AfterUpdate(tx)
+		}
+	})
+}
+
+// willSoftDelete 判断删除语句是否会由删除子句改写为 UPDATE，此时删除子句还未执行
+func willSoftDelete(stmt *gorm.Statement) bool {
+	if stmt.Schema == nil || stmt.Unscoped || isPermanentDelete(stmt) {
+		return false
+	}
+	if _, archived := archiveTableOf(stmt.Schema); archived {
+		return false
+	}
+	_, ok := lookUpDeleteClause(stmt.Schema)
+	return ok
+}
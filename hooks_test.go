@@ -0,0 +1,114 @@
+package soft_delete
+
+import (
+	"reflect"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// hookCalls 记录 Folder、File 的钩子调用顺序
+var hookCalls []string
+
+type Folder struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+	Files   []File    `gorm:"softDeleteCascade"`
+}
+
+type File struct {
+	ID       uint
+	FolderID uint
+	Deleted  DeletedAt `gorm:"softDelete:flag"`
+}
+
+func (Folder) BeforeDelete(*gorm.DB) error { hookCalls = append(hookCalls, "BeforeDelete"); return nil }
+func (Folder) AfterDelete(*gorm.DB) error  { hookCalls = append(hookCalls, "AfterDelete"); return nil }
+func (Folder) BeforeSave(*gorm.DB) error   { hookCalls = append(hookCalls, "BeforeSave"); return nil }
+func (Folder) BeforeUpdate(*gorm.DB) error { hookCalls = append(hookCalls, "BeforeUpdate"); return nil }
+func (Folder) AfterUpdate(*gorm.DB) error  { hookCalls = append(hookCalls, "AfterUpdate"); return nil }
+func (Folder) AfterSave(*gorm.DB) error    { hookCalls = append(hookCalls, "AfterSave"); return nil }
+
+func (File) BeforeDelete(*gorm.DB) error {
+	hookCalls = append(hookCalls, "File.BeforeDelete")
+	return nil
+}
+func (File) AfterDelete(*gorm.DB) error {
+	hookCalls = append(hookCalls, "File.AfterDelete")
+	return nil
+}
+func (File) BeforeUpdate(*gorm.DB) error {
+	hookCalls = append(hookCalls, "File.BeforeUpdate")
+	return nil
+}
+func (File) AfterUpdate(*gorm.DB) error {
+	hookCalls = append(hookCalls, "File.AfterUpdate")
+	return nil
+}
+
+func TestHookMode(t *testing.T) {
+	tests := []struct {
+		mode   HookMode
+		single []string
+		// cascade 为级联删除子记录 File 时调用的钩子，位于父记录的 before 与 after 钩子之间
+		cascade []string
+	}{
+		{HookDelete, []string{"BeforeDelete", "AfterDelete"}, []string{"File.BeforeDelete", "File.AfterDelete"}},
+		{HookUpdate, []string{"BeforeSave", "BeforeUpdate", "AfterUpdate", "AfterSave"}, []string{"File.BeforeUpdate", "File.AfterUpdate"}},
+		{HookBoth, []string{"BeforeDelete", "BeforeSave", "BeforeUpdate", "AfterDelete", "AfterUpdate", "AfterSave"},
+			[]string{"File.BeforeDelete", "File.BeforeUpdate", "File.AfterDelete", "File.AfterUpdate"}},
+	}
+	for _, tt := range tests {
+		db := openDB(t, &Folder{}, &File{})
+		config := DefaultConfig()
+		config.HookMode = tt.mode
+		if err := Use(db, config); err != nil {
+			t.Fatal(err)
+		}
+		folders := []Folder{{Files: []File{{}, {}}}, {}, {}}
+		db.Session(&gorm.Session{SkipHooks: true}).Create(&folders)
+
+		// 单条删除，级联软删除的子记录同样按 mode 调用钩子
+		hookCalls = nil
+		if err := db.Delete(&folders[0]).Error; err != nil {
+			t.Fatal(err)
+		}
+		before := len(tt.single) / 2
+		want := append(append(append([]string{}, tt.single[:before]...), tt.cascade...), tt.single[before:]...)
+		if !reflect.DeepEqual(hookCalls, want) {
+			t.Errorf("mode %d single: hooks = %v, want %v", tt.mode, hookCalls, want)
+		}
+		var files []File
+		db.Find(&files)
+		if len(files) != 0 {
+			t.Errorf("mode %d: files left = %+v", tt.mode, files)
+		}
+
+		// 批量删除按元素调用，没有子记录时级联删除同样调用一次子记录的钩子
+		hookCalls = nil
+		if err := db.Delete(folders[1:]).Error; err != nil {
+			t.Fatal(err)
+		}
+		if want := len(tt.single)*2 + len(tt.cascade); len(hookCalls) != want {
+			t.Errorf("mode %d batch: hooks = %v, want %d calls", tt.mode, hookCalls, want)
+		}
+	}
+}
+
+func TestHookModePermanentDelete(t *testing.T) {
+	db := openDB(t, &Folder{}, &File{})
+	config := DefaultConfig()
+	config.HookMode = HookUpdate
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	folder := Folder{}
+	db.Create(&folder)
+
+	// 物理删除仍然只调用删除的钩子
+	hookCalls = nil
+	db.Unscoped().Delete(&folder)
+	if want := []string{"BeforeDelete", "AfterDelete"}; !reflect.DeepEqual(hookCalls, want) {
+		t.Errorf("hooks = %v, want %v", hookCalls, want)
+	}
+}
@@ -0,0 +1,109 @@
+package soft_delete
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCompositePrimaryKey 按主键列表操作只支持单列主键
+var ErrCompositePrimaryKey = errors.New("soft_delete: operations by ids require a single primary key")
+
+// IDStats 为 RestoreByIDs、PurgeByIDs 的结果
+type IDStats struct {
+	// Requested 为传入的主键数
+	Requested int
+	// Matched 为按主键找到的记录数，包括未删除的记录
+	Matched int64
+	// Affected 为恢复或物理删除的记录数
+	Affected int64
+	// Missing 为表中不存在的主键
+	Missing []interface{}
+}
+
+// RestoreByIDs 按主键列表恢复已删除的记录，主键按 SetKeyBatchSize 分批，在同一事务中执行。
+// 未删除的记录保持不变，不存在的主键在 Missing 中返回
+//
+//	stats, err := soft_delete.RestoreByIDs(db, &User{}, []interface{}{1, 2, 3})
+func RestoreByIDs(db *gorm.DB, model interface{}, ids []interface{}) (IDStats, error) {
+	return byIDs(db, model, ids, func(tx *gorm.DB, newModel func() interface{}, in clause.Expression, _ SoftDeleteDeleteClause) (int64, error) {
+		return RestoreWhere(tx.Model(newModel()).Clauses(clause.Where{Exprs: []clause.Expression{in}}))
+	})
+}
+
+// PurgeByIDs 按主键列表物理删除已软删除的记录，条件中总是带有已删除的过滤，不会误删未删除的记录。
+// 主键按 SetKeyBatchSize 分批，在同一事务中执行，不存在的主键在 Missing 中返回
+//
+//	stats, err := soft_delete.PurgeByIDs(db, &User{}, []interface{}{1, 2, 3})
+func PurgeByIDs(db *gorm.DB, model interface{}, ids []interface{}) (IDStats, error) {
+	return byIDs(db, model, ids, func(tx *gorm.DB, newModel func() interface{}, in clause.Expression, sd SoftDeleteDeleteClause) (int64, error) {
+		deleted := deletedExpr(sd.Field, sd.Flag, clause.Column{Table: clause.CurrentTable, Name: sd.Field.DBName})
+		result := tx.Unscoped().Clauses(purgeClause{}, clause.Where{Exprs: []clause.Expression{in, deleted}}).Delete(newModel())
+		return result.RowsAffected, result.Error
+	})
+}
+
+// byIDs 校验模型并按批次执行 fc，fc 返回该批次影响的行数
+func byIDs(db *gorm.DB, model interface{}, ids []interface{},
+	fc func(tx *gorm.DB, newModel func() interface{}, in clause.Expression, sd SoftDeleteDeleteClause) (int64, error)) (IDStats, error) {
+	stats := IDStats{Requested: len(ids)}
+
+	s, sd, err := parseDeleteClause(db, model)
+	if err != nil {
+		return stats, err
+	}
+	if len(s.PrimaryFields) != 1 {
+		return stats, ErrCompositePrimaryKey
+	}
+	pk := s.PrimaryFields[0]
+	newModel := func() interface{} {
+		return reflect.New(s.ModelType).Interface()
+	}
+
+	err = Transaction(db, func(tx *gorm.DB) error {
+		size := configOf(tx).KeyBatchSize
+		for start := 0; start < len(ids); start += size {
+			end := start + size
+			if end > len(ids) {
+				end = len(ids)
+			}
+			chunk := ids[start:end]
+			in := clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: pk.DBName}, Values: chunk}
+
+			found := reflect.New(reflect.SliceOf(pk.FieldType))
+			if err := tx.Session(&gorm.Session{NewDB: true}).Model(newModel()).Scopes(WithDeleted).
+				Clauses(clause.Where{Exprs: []clause.Expression{in}}).Pluck(pk.DBName, found.Interface()).Error; err != nil {
+				return err
+			}
+			stats.Matched += int64(found.Elem().Len())
+			stats.Missing = append(stats.Missing, missingIDs(chunk, found.Elem())...)
+
+			affected, err := fc(tx.Session(&gorm.Session{NewDB: true}), newModel, in, sd)
+			if err != nil {
+				return err
+			}
+			stats.Affected += affected
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// missingIDs 返回 ids 中不在 found 里的主键，驱动返回的类型可能与传入的不同，按字符串比较
+func missingIDs(ids []interface{}, found reflect.Value) []interface{} {
+	exists := make(map[string]bool, found.Len())
+	for i := 0; i < found.Len(); i++ {
+		exists[fmt.Sprint(found.Index(i).Interface())] = true
+	}
+
+	var missing []interface{}
+	for _, id := range ids {
+		if !exists[fmt.Sprint(id)] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,78 @@
+package soft_delete
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRestoreByIDs(t *testing.T) {
+	db := openDB(t, &User{})
+	users := seedUsers(t, db)
+
+	stats, err := RestoreByIDs(db, &User{}, []interface{}{users[0].ID, users[1].ID, 99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := IDStats{Requested: 3, Matched: 2, Affected: 1, Missing: []interface{}{99}}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+
+	var count int64
+	db.Model(&User{}).Count(&count)
+	if count != 3 {
+		t.Errorf("active = %d, want 3", count)
+	}
+}
+
+func TestPurgeByIDsKeepsActiveRows(t *testing.T) {
+	db := openDB(t, &User{})
+	users := seedUsers(t, db)
+
+	stats, err := PurgeByIDs(db, &User{}, []interface{}{users[0].ID, users[1].ID, users[2].ID, 99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := IDStats{Requested: 4, Matched: 3, Affected: 1, Missing: []interface{}{99}}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("stats = %+v, want %+v", stats, want)
+	}
+	if n := countRows(t, db, "users"); n != 2 {
+		t.Errorf("rows = %d, want 2", n)
+	}
+}
+
+func TestByIDsBatches(t *testing.T) {
+	db := openDB(t, &User{})
+	config := DefaultConfig()
+	config.KeyBatchSize = 2
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	users := []User{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}, {Name: "e"}}
+	db.Create(&users)
+	db.Delete(&users)
+
+	ids := make([]interface{}, len(users))
+	for i, u := range users {
+		ids[i] = u.ID
+	}
+	stats, err := RestoreByIDs(db, &User{}, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Matched != 5 || stats.Affected != 5 || len(stats.Missing) != 0 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestByIDsRequiresSinglePrimaryKey(t *testing.T) {
+	db := openDB(t, &OrderLine{})
+	if _, err := RestoreByIDs(db, &OrderLine{}, []interface{}{1}); !errors.Is(err, ErrCompositePrimaryKey) {
+		t.Errorf("err = %v, want ErrCompositePrimaryKey", err)
+	}
+	if _, err := PurgeByIDs(db, &struct{ ID uint }{}, []interface{}{1}); !errors.Is(err, ErrMissingSoftDeleteField) {
+		t.Errorf("err = %v, want ErrMissingSoftDeleteField", err)
+	}
+}
@@ -0,0 +1,144 @@
+package soft_delete
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// CreatePartialUniqueIndex 创建只约束未删除记录的唯一索引，已删除的记录不再占用唯一值，
+// 例如注销后可以用同一邮箱重新注册。PostgreSQL、SQLite、SQL Server 创建带 WHERE 的部分索引；
+// MySQL 不支持部分索引，添加生成列（未删除时为 1，已删除时为 NULL）并与 columns 建立联合唯一索引。
+// 索引已经存在时不做处理，columns 可以是字段名或列名
+//
+//	soft_delete.CreatePartialUniqueIndex(db, &User{}, "Email")
+func CreatePartialUniqueIndex(db *gorm.DB, model interface{}, columns ...string) error {
+	p, err := parsePartialIndex(db, model, columns)
+	if err != nil {
+		return err
+	}
+
+	migrator := db.Migrator()
+	if migrator.HasIndex(model, p.name) {
+		return nil
+	}
+
+	quote := db.Statement.Quote
+	quoted := make([]string, 0, len(p.columns)+1)
+	for _, column := range p.columns {
+		quoted = append(quoted, quote(column))
+	}
+
+	switch db.Dialector.Name() {
+	case "postgres", "sqlite", "sqlserver":
+		return db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s) WHERE %s",
+			quote(p.name), quote(p.table), strings.Join(quoted, ","), p.activeSQL)).Error
+	case "mysql":
+		if !migrator.HasColumn(model, p.keyColumn) {
+			if err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s TINYINT AS (CASE WHEN %s THEN 1 ELSE NULL END) VIRTUAL",
+				quote(p.table), quote(p.keyColumn), p.activeSQL)).Error; err != nil {
+				return err
+			}
+		}
+		quoted = append(quoted, quote(p.keyColumn))
+		return db.Exec(fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s (%s)",
+			quote(p.name), quote(p.table), strings.Join(quoted, ","))).Error
+	default:
+		return fmt.Errorf("soft_delete: partial unique index is not supported by %s", db.Dialector.Name())
+	}
+}
+
+// DropPartialUniqueIndex 删除 CreatePartialUniqueIndex 创建的索引，MySQL 同时删除生成列
+func DropPartialUniqueIndex(db *gorm.DB, model interface{}, columns ...string) error {
+	p, err := parsePartialIndex(db, model, columns)
+	if err != nil {
+		return err
+	}
+
+	migrator := db.Migrator()
+	if migrator.HasIndex(model, p.name) {
+		if err := migrator.DropIndex(model, p.name); err != nil {
+			return err
+		}
+	}
+	if db.Dialector.Name() == "mysql" && migrator.HasColumn(model, p.keyColumn) {
+		return migrator.DropColumn(model, p.keyColumn)
+	}
+	return nil
+}
+
+type partialIndex struct {
+	table     string
+	name      string
+	columns   []string
+	keyColumn string
+	activeSQL string
+}
+
+func parsePartialIndex(db *gorm.DB, model interface{}, columns []string) (partialIndex, error) {
+	s, sd, err := parseDeleteClause(db, model)
+	if err != nil {
+		return partialIndex{}, err
+	}
+	if len(columns) == 0 {
+		return partialIndex{}, fmt.Errorf("soft_delete: partial unique index requires columns")
+	}
+	if flagValuesOf(sd.Field).expires {
+		return partialIndex{}, fmt.Errorf("soft_delete: partial unique index cannot depend on the current time of ExpiresAt")
+	}
+
+	p := partialIndex{table: s.Table, keyColumn: sd.Field.DBName + "_active_key"}
+	for _, column := range columns {
+		if field := s.LookUpField(column); field != nil {
+			column = field.DBName
+		}
+		p.columns = append(p.columns, column)
+	}
+	p.name = fmt.Sprintf("uidx_%s_%s_active", s.Table, strings.Join(p.columns, "_"))
+	p.activeSQL = activeSQL(db, sd.Field, sd.Flag)
+	return p, nil
+}
+
+// activeSQL 返回未删除条件的 SQL，值直接写入语句：部分索引和生成列的定义中不能使用参数
+func activeSQL(db *gorm.DB, f *schema.Field, flag bool) string {
+	column := db.Statement.Quote(f.DBName)
+	values := flagValuesOf(f)
+	if flag && values.mask != 0 {
+		return bitSQL(db, column, values.mask) + " = 0"
+	}
+	if flag && values.byDeleted {
+		if values.deleted == nil {
+			return column + " IS NOT NULL"
+		}
+		return column + " <> " + sqlLiteral(db, values.deleted)
+	}
+
+	active := activeValue(f, flag)
+	if active == nil {
+		return column + " IS NULL"
+	}
+	return column + " = " + sqlLiteral(db, active)
+}
+
+func sqlLiteral(db *gorm.DB, value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		if numericBool(db) {
+			if v {
+				return "1"
+			}
+			return "0"
+		}
+		return strconv.FormatBool(v)
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05") + "'"
+	default:
+		return fmt.Sprint(v)
+	}
+}
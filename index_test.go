@@ -0,0 +1,116 @@
+package soft_delete
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type Registration struct {
+	ID      uint
+	Email   string
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+// sqlRecorder 记录执行的 SQL
+type sqlRecorder struct {
+	logger.Interface
+	sqls []string
+}
+
+func (r *sqlRecorder) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	sql, _ := fc()
+	r.sqls = append(r.sqls, sql)
+}
+
+// emptyMigrator 在 DryRun 下视为没有任何索引和列，SQLite 的 Migrator 在 DryRun 下无法查询
+type emptyMigrator struct {
+	gorm.Migrator
+}
+
+func (emptyMigrator) HasIndex(interface{}, string) bool  { return false }
+func (emptyMigrator) HasColumn(interface{}, string) bool { return false }
+
+type emptyMigratorDialector struct {
+	namedDialector
+}
+
+func (d emptyMigratorDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return emptyMigrator{d.namedDialector.Migrator(db)}
+}
+
+// dryRunIndexDB 返回名称为 dialect、只生成 SQL 并记录的 db
+func dryRunIndexDB(t *testing.T, dialect string) (*gorm.DB, *sqlRecorder) {
+	db := dryRunDB(t, dialect)
+	db.Dialector = emptyMigratorDialector{db.Dialector.(namedDialector)}
+	recorder := &sqlRecorder{Interface: logger.Discard}
+	db.Logger = recorder
+	return db, recorder
+}
+
+func TestPartialUniqueIndex(t *testing.T) {
+	db := openDB(t, &Registration{})
+	for i := 0; i < 2; i++ {
+		if err := CreatePartialUniqueIndex(db, &Registration{}, "Email"); err != nil {
+			t.Fatalf("create %d: %v", i+1, err)
+		}
+	}
+
+	if err := db.Create(&Registration{Email: "a@x"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&Registration{Email: "a@x"}).Error; err == nil {
+		t.Error("duplicate active email inserted")
+	}
+	db.Delete(&Registration{}, "email = ?", "a@x")
+	for i := 0; i < 2; i++ {
+		if err := db.Create(&Registration{Email: "a@x"}).Error; err != nil {
+			t.Fatalf("re-register %d: %v", i+1, err)
+		}
+		if i == 0 {
+			db.Delete(&Registration{}, "email = ?", "a@x")
+		}
+	}
+
+	if err := DropPartialUniqueIndex(db, &Registration{}, "Email"); err != nil {
+		t.Fatal(err)
+	}
+	if db.Migrator().HasIndex(&Registration{}, "uidx_registrations_email_active") {
+		t.Error("index not dropped")
+	}
+	if err := db.Create(&Registration{Email: "a@x"}).Error; err != nil {
+		t.Errorf("insert after drop: %v", err)
+	}
+}
+
+func TestPartialUniqueIndexSQL(t *testing.T) {
+	tests := []struct {
+		dialect string
+		want    []string
+	}{
+		{"postgres", []string{"CREATE UNIQUE INDEX `uidx_registrations_email_active` ON `registrations` (`email`) WHERE `deleted` = false"}},
+		{"sqlserver", []string{"WHERE `deleted` = 0"}},
+		{"mysql", []string{
+			"ALTER TABLE `registrations` ADD COLUMN `deleted_active_key` TINYINT AS (CASE WHEN `deleted` = false THEN 1 ELSE NULL END) VIRTUAL",
+			"CREATE UNIQUE INDEX `uidx_registrations_email_active` ON `registrations` (`email`,`deleted_active_key`)",
+		}},
+	}
+	for _, tt := range tests {
+		db, recorder := dryRunIndexDB(t, tt.dialect)
+		if err := CreatePartialUniqueIndex(db, &Registration{}, "email"); err != nil {
+			t.Fatalf("%s: %v", tt.dialect, err)
+		}
+		assertContains(t, strings.Join(recorder.sqls, ";"), tt.want...)
+	}
+
+	if db, _ := dryRunIndexDB(t, "clickhouse"); CreatePartialUniqueIndex(db, &Registration{}, "email") == nil {
+		t.Error("unsupported dialect accepted")
+	}
+	if err := CreatePartialUniqueIndex(openDB(t), &Registration{}); err == nil {
+		t.Error("missing columns accepted")
+	}
+}
@@ -0,0 +1,38 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const purgeClauseName = "soft_delete:purge"
+
+// purgeClause 标记 Purge 中物理删除一批记录的语句
+type purgeClause struct{}
+
+func (purgeClause) Name() string {
+	return purgeClauseName
+}
+
+func (purgeClause) Build(clause.Builder) {
+}
+
+func (c purgeClause) MergeClause(cl *clause.Clause) {
+	cl.Expression = c
+}
+
+// IsSoftDelete 判断删除语句是否被改写为软删除，供其他插件在回调中区分语句
+func IsSoftDelete(stmt *gorm.Statement) bool {
+	return isSoftDeleting(stmt)
+}
+
+// IsRestore 判断更新语句是否为恢复
+func IsRestore(stmt *gorm.Statement) bool {
+	return isRestoring(stmt)
+}
+
+// IsPurge 判断删除语句是否为 Purge 中物理删除的一批记录
+func IsPurge(stmt *gorm.Statement) bool {
+	_, ok := stmt.Clauses[purgeClauseName]
+	return ok
+}
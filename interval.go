@@ -0,0 +1,264 @@
+package soft_delete
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const asOfClauseName = "soft_delete:as_of"
+
+// newerAlias 为恢复时检查更新区间的子查询中表的别名
+const newerAlias = "newer"
+
+// ValidTo 以有效区间的结束时间表示删除状态：区间未关闭为未删除，删除时写入当前时间关闭区间。
+// 默认以 NULL 表示未关闭，也可以通过 ActiveValue 使用哨兵时间，读入内存时仍为未关闭（Valid 为 false）。
+// ValidFromField 为区间的开始时间，用于 AsOf；IntervalKey 为区间所属的业务主键，多个以空格分隔，
+// 恢复时存在同一业务主键的更新区间则不会重新打开
+//
+//	ValidFrom time.Time
+//	ValidTo   soft_delete.ValidTo `gorm:"softDelete:ValidFromField:ValidFrom,IntervalKey:SKU,ActiveValue:9999-12-31"`
+type ValidTo sql.NullTime
+
+// IsDeleted 判断区间是否已关闭
+func (v ValidTo) IsDeleted() bool {
+	return v.Valid
+}
+
+// IsActive 判断区间是否未关闭
+func (v ValidTo) IsActive() bool {
+	return !v.Valid
+}
+
+// String 返回 "deleted" 或 "active"
+func (v ValidTo) String() string {
+	return stateString(v.Valid)
+}
+
+// 实现 sql.Scanner 接口
+func (v *ValidTo) Scan(value interface{}) error {
+	return (*sql.NullTime)(v).Scan(value)
+}
+
+// 实现 driver.Valuer 接口，未关闭时为 NULL，使用哨兵时间的字段由 bindValidTo 转换
+func (v ValidTo) Value() (driver.Value, error) {
+	if !v.Valid {
+		return nil, nil
+	}
+	return v.Time, nil
+}
+
+// 实现 json.Marshaler 接口，未关闭时为 null
+func (v ValidTo) MarshalJSON() ([]byte, error) {
+	if !v.Valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(v.Time)
+}
+
+// 实现 json.Unmarshaler 接口
+func (v *ValidTo) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*v = ValidTo{}
+		return nil
+	}
+	err := json.Unmarshal(data, &v.Time)
+	v.Valid = err == nil
+	return err
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface 接口
+func (ValidTo) GormDataType() string {
+	return string(schema.Time)
+}
+
+func (ValidTo) QueryClauses(f *schema.Field) []clause.Interface {
+	bindValidTo(f)
+	return []clause.Interface{SoftDeleteQueryClause{Field: f, Flag: true}.withQuerySettings()}
+}
+
+func (ValidTo) UpdateClauses(f *schema.Field) []clause.Interface {
+	return flagUpdateClauses(f)
+}
+
+func (ValidTo) DeleteClauses(f *schema.Field) []clause.Interface {
+	bindValidTo(f)
+	settings := parseSettings(f)
+	softDeleteClause := SoftDeleteDeleteClause{Field: f, Flag: true, Interval: true}
+	return []clause.Interface{softDeleteClause.withCompanionFields(settings).withInterval(settings)}
+}
+
+func (ValidTo) CreateClauses(f *schema.Field) []clause.Interface {
+	return flagCreateClauses(f)
+}
+
+// bindValidTo 绑定字段未关闭时的值，ActiveValue 为哨兵时间时写入时将未关闭转换为哨兵，读取时将哨兵转换为未关闭
+func bindValidTo(f *schema.Field) {
+	var values flagValues
+	if setting, ok := parseSettings(f)["ACTIVEVALUE"]; ok {
+		values.active = parseSentinel(setting)
+	}
+	if _, loaded := fieldFlagValues.LoadOrStore(f, values); loaded || values.active == nil {
+		return
+	}
+	sentinel := values.active.(time.Time)
+
+	valueOf := f.ValueOf
+	f.ValueOf = func(ctx context.Context, v reflect.Value) (interface{}, bool) {
+		value, zero := valueOf(ctx, v)
+		if validTo, ok := value.(ValidTo); ok && !validTo.Valid {
+			return sentinel, zero
+		}
+		return value, zero
+	}
+
+	set := f.Set
+	f.Set = func(ctx context.Context, v reflect.Value, value interface{}) error {
+		if err := set(ctx, v, value); err != nil {
+			return err
+		}
+		if validTo, ok := f.ReflectValueOf(ctx, v).Addr().Interface().(*ValidTo); ok && validTo.Valid && validTo.Time.Equal(sentinel) {
+			*validTo = ValidTo{}
+		}
+		return nil
+	}
+}
+
+// parseSentinel 解析哨兵时间，支持日期和 RFC3339，按 UTC 解析
+func parseSentinel(s string) interface{} {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t
+		}
+	}
+	return parseFlagValue(s)
+}
+
+// withInterval 按标签中的 ValidFromField、IntervalKey 关联区间的开始时间和业务主键
+func (sd SoftDeleteDeleteClause) withInterval(settings map[string]string) SoftDeleteDeleteClause {
+	if name := settings["VALIDFROMFIELD"]; name != "" {
+		sd.ValidFromFieldName = name
+		sd.ValidFromField = sd.Field.Schema.LookUpField(name)
+	}
+	for _, name := range strings.Fields(settings["INTERVALKEY"]) {
+		sd.IntervalKeyFields = append(sd.IntervalKeyFields, sd.Field.Schema.LookUpField(name))
+	}
+	return sd
+}
+
+type asOfClause struct {
+	t time.Time
+}
+
+func (asOfClause) Name() string {
+	return asOfClauseName
+}
+
+func (asOfClause) Build(clause.Builder) {
+}
+
+func (c asOfClause) MergeClause(cl *clause.Clause) {
+	cl.Expression = c
+}
+
+// AsOf 查询在 t 时刻有效的记录，替换默认的过滤条件：区间在 t 之后才关闭，有 ValidFromField 时还要求在 t 之前开始。
+// 只作用于 ValidTo 字段，其他软删除字段仍按默认过滤
+//
+//	db.Scopes(soft_delete.AsOf(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))).Find(&prices)
+func AsOf(t time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(asOfClause{t: t})
+	}
+}
+
+// asOfExpr 返回语句使用 AsOf 时的过滤条件，没有使用或字段不是 ValidTo 时返回 nil
+func (sd SoftDeleteQueryClause) asOfExpr(stmt *gorm.Statement) clause.Expression {
+	c, ok := stmt.Clauses[asOfClauseName].Expression.(asOfClause)
+	if !ok {
+		return nil
+	}
+	deleteClause, ok := deleteClauseOf(sd.Field)
+	if !ok || !deleteClause.Interval {
+		return nil
+	}
+	return validAt{sd: deleteClause, table: filterTable(stmt), t: c.t}
+}
+
+// validAt 为区间在 t 时刻有效的条件，removeFilter 按类型去掉
+type validAt struct {
+	sd    SoftDeleteDeleteClause
+	table string
+	t     time.Time
+}
+
+func (v validAt) Build(builder clause.Builder) {
+	to := clause.Column{Table: v.table, Name: v.sd.Field.DBName}
+	expr := clause.Or(activeExpr(v.sd.Field, true, to), clause.Gt{Column: to, Value: v.t})
+	if from := v.sd.ValidFromField; from != nil {
+		expr = clause.And(clause.Lte{Column: clause.Column{Table: v.table, Name: from.DBName}, Value: v.t}, expr)
+	}
+	expr.Build(builder)
+}
+
+// notSuperseded 为恢复时区间没有被取代的条件：不存在同一业务主键的其他未关闭区间，
+// 有 ValidFromField 时也不存在开始时间更晚的区间
+type notSuperseded struct {
+	sd SoftDeleteDeleteClause
+}
+
+func (n notSuperseded) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	newer := func(field *schema.Field) clause.Column {
+		return clause.Column{Table: newerAlias, Name: field.DBName}
+	}
+	current := func(field *schema.Field) clause.Column {
+		return clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	}
+
+	exprs := make([]clause.Expression, 0, len(n.sd.IntervalKeyFields)+2)
+	for _, field := range n.sd.IntervalKeyFields {
+		exprs = append(exprs, clause.Eq{Column: newer(field), Value: current(field)})
+	}
+	self := make([]clause.Expression, 0, len(stmt.Schema.PrimaryFields))
+	for _, pk := range stmt.Schema.PrimaryFields {
+		self = append(self, clause.Eq{Column: newer(pk), Value: current(pk)})
+	}
+	exprs = append(exprs, clause.Not(clause.And(self...)))
+
+	superseded := activeExpr(n.sd.Field, true, newer(n.sd.Field))
+	if from := n.sd.ValidFromField; from != nil {
+		superseded = clause.Or(superseded, clause.Gt{Column: newer(from), Value: current(from)})
+	}
+	exprs = append(exprs, superseded)
+
+	builder.WriteString("NOT EXISTS (SELECT 1 FROM ")
+	builder.WriteQuoted(clause.Table{Name: stmt.Table, Alias: newerAlias})
+	builder.WriteString(" WHERE ")
+	clause.And(exprs...).Build(builder)
+	builder.WriteByte(')')
+}
+
+// checkIntervalFields 检查标签中声明的区间字段是否存在
+func (sd SoftDeleteDeleteClause) checkIntervalFields() error {
+	if sd.ValidFromField == nil && sd.ValidFromFieldName != "" {
+		return fmt.Errorf("soft_delete: ValidFromField %q of %s not found in schema %s", sd.ValidFromFieldName, sd.Field.Name, sd.Field.Schema.Name)
+	}
+	for i, field := range sd.IntervalKeyFields {
+		if field == nil {
+			return fmt.Errorf("soft_delete: IntervalKey %q of %s not found in schema %s", strings.Fields(parseSettings(sd.Field)["INTERVALKEY"])[i], sd.Field.Name, sd.Field.Schema.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+)
+
+type Price struct {
+	ID        uint
+	SKU       string
+	Amount    int
+	ValidFrom time.Time
+	ValidTo   ValidTo `gorm:"softDelete:ValidFromField:ValidFrom,IntervalKey:SKU"`
+}
+
+type SentinelPrice struct {
+	ID      uint
+	SKU     string
+	ValidTo ValidTo `gorm:"softDelete:ActiveValue:9999-12-31"`
+}
+
+func TestValidToDeleteClosesInterval(t *testing.T) {
+	db := pinNow(openDB(t, &Price{}))
+	price := Price{SKU: "a", ValidFrom: testNow.Add(-time.Hour)}
+	db.Create(&price)
+
+	if err := db.Delete(&price).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !price.ValidTo.Valid || !price.ValidTo.Time.Equal(testNow) {
+		t.Errorf("ValidTo = %+v, want %v", price.ValidTo, testNow)
+	}
+
+	var count int64
+	db.Model(&Price{}).Count(&count)
+	if count != 0 {
+		t.Errorf("open intervals = %d, want 0", count)
+	}
+	var closed Price
+	db.Scopes(OnlyDeleted).First(&closed, price.ID)
+	if !closed.ValidTo.Time.Equal(testNow) {
+		t.Errorf("ValidTo = %v in database", closed.ValidTo)
+	}
+}
+
+func TestValidToSentinel(t *testing.T) {
+	db := pinNow(openDB(t, &SentinelPrice{}))
+	prices := []SentinelPrice{{SKU: "a"}, {SKU: "b"}}
+	db.Create(&prices)
+
+	var raw time.Time
+	db.Table("sentinel_prices").Select("valid_to").Where("id = ?", prices[0].ID).Scan(&raw)
+	if want := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC); !raw.Equal(want) {
+		t.Errorf("stored valid_to = %v, want sentinel", raw)
+	}
+
+	db.Delete(&prices[1])
+	var found []SentinelPrice
+	db.Find(&found)
+	if len(found) != 1 || found[0].ID != prices[0].ID {
+		t.Fatalf("Find = %+v", found)
+	}
+	// 读入内存时哨兵转换为未关闭
+	if found[0].ValidTo.Valid {
+		t.Errorf("ValidTo = %+v, want open interval", found[0].ValidTo)
+	}
+
+	sql := sqlOf(t, dryRunDB(t, "sqlite").Find(&[]SentinelPrice{}))
+	assertContains(t, sql, "`sentinel_prices`.`valid_to` =")
+	assertNotContains(t, sql, "IS NULL")
+}
+
+func TestAsOf(t *testing.T) {
+	db := openDB(t, &Price{})
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	prices := []Price{
+		{SKU: "a", Amount: 1, ValidFrom: day(1), ValidTo: ValidTo{Time: day(10), Valid: true}},
+		{SKU: "a", Amount: 2, ValidFrom: day(10)},
+		{SKU: "b", Amount: 3, ValidFrom: day(5)},
+	}
+	if err := db.Create(&prices).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		at   time.Time
+		want []int
+	}{
+		{day(2), []int{1}},
+		{day(6), []int{1, 3}},
+		{day(10), []int{2, 3}},
+		{day(20), []int{2, 3}},
+	}
+	for _, tt := range tests {
+		var found []Price
+		if err := db.Scopes(AsOf(tt.at)).Order("id").Find(&found).Error; err != nil {
+			t.Fatal(err)
+		}
+		var amounts []int
+		for _, p := range found {
+			amounts = append(amounts, p.Amount)
+		}
+		if len(amounts) != len(tt.want) {
+			t.Errorf("AsOf(%v) = %v, want %v", tt.at, amounts, tt.want)
+			continue
+		}
+		for i := range amounts {
+			if amounts[i] != tt.want[i] {
+				t.Errorf("AsOf(%v) = %v, want %v", tt.at, amounts, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestValidToRestoreSuperseded(t *testing.T) {
+	db := pinNow(openDB(t, &Price{}))
+	old := Price{SKU: "a", ValidFrom: testNow.Add(-2 * time.Hour)}
+	other := Price{SKU: "b", ValidFrom: testNow.Add(-2 * time.Hour)}
+	db.Create(&old)
+	db.Create(&other)
+	db.Delete(&old)
+	db.Delete(&other)
+	db.Create(&Price{SKU: "a", ValidFrom: testNow})
+
+	if n, err := Restore(db, &Price{ID: old.ID}); err != nil || n != 0 {
+		t.Errorf("Restore superseded = %d, %v, want 0 rows", n, err)
+	}
+	if n, err := Restore(db, &Price{ID: other.ID}); err != nil || n != 1 {
+		t.Errorf("Restore = %d, %v, want 1 row", n, err)
+	}
+}
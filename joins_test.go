@@ -0,0 +1,99 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm/clause"
+)
+
+type Company struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+type Employee struct {
+	ID        uint
+	CompanyID uint
+	Company   Company
+	Deleted   DeletedAt `gorm:"softDelete:flag"`
+}
+
+func TestJoinsFilter(t *testing.T) {
+	db := openDB(t, &Company{}, &Employee{})
+	employees := []Employee{{Company: Company{Name: "a"}}, {Company: Company{Name: "b"}}}
+	db.Create(&employees)
+	db.Delete(&employees[1].Company)
+
+	tests := []struct {
+		name  string
+		find  func(*[]Employee) error
+		count int
+	}{
+		{"left join keeps the employee", func(e *[]Employee) error {
+			return db.Joins("Company").Find(e).Error
+		}, 2},
+		{"inner join drops the employee", func(e *[]Employee) error {
+			return db.InnerJoins("Company").Find(e).Error
+		}, 1},
+		{"inner join with deleted", func(e *[]Employee) error {
+			return db.Scopes(WithDeleted).InnerJoins("Company").Find(e).Error
+		}, 2},
+		{"string join with NotDeleted", func(e *[]Employee) error {
+			return db.Joins("JOIN companies c ON c.id = employees.company_id AND ?", NotDeleted(&Company{}, "c")).Find(e).Error
+		}, 1},
+		{"string join with deleted", func(e *[]Employee) error {
+			return db.Scopes(WithDeleted).Joins("JOIN companies c ON c.id = employees.company_id AND ?", NotDeleted(&Company{}, "c")).Find(e).Error
+		}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var found []Employee
+			if err := tt.find(&found); err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != tt.count {
+				t.Errorf("found %d employees, want %d", len(found), tt.count)
+			}
+		})
+	}
+
+	// LEFT JOIN 的过滤条件在 ON 中，已删除的公司不会被加载
+	var found []Employee
+	db.Joins("Company").Order("employees.id").Find(&found)
+	if found[1].Company.ID != 0 {
+		t.Errorf("deleted company loaded: %+v", found[1].Company)
+	}
+	sql := sqlOf(t, dryRun(db).Joins("Company").Find(&found))
+	assertContains(t, sql, "LEFT JOIN `companies` `Company` ON `employees`.`company_id` = `Company`.`id` AND `Company`.`deleted` = ?")
+}
+
+// 过滤条件使用语句中当前表的别名
+func TestTableAlias(t *testing.T) {
+	for _, dialect := range []string{"sqlite", "postgres"} {
+		t.Run(dialect, func(t *testing.T) {
+			db := dryRunDB(t, dialect)
+			var users []User
+			assertContains(t, sqlOf(t, db.Table("users AS u").Find(&users)), "FROM users AS u WHERE `u`.`deleted` = ?")
+			assertContains(t, sqlOf(t, db.Table("users u").Find(&users)), "`u`.`deleted` = ?")
+			assertContains(t, sqlOf(t, db.Model(&User{}).Find(&users)), "FROM `users` WHERE `users`.`deleted` = ?")
+			assertContains(t, sqlOf(t, db.Clauses(clause.From{Tables: []clause.Table{{Name: "users", Alias: "u"}}}).Find(&users)), "`u`.`deleted` = ?")
+
+			// 子查询作为表时，外层按别名过滤，子查询自己的过滤条件由 WithDeleted 去掉
+			sub := db.Model(&User{}).Scopes(WithDeleted)
+			sql := sqlOf(t, db.Table("(?) AS x", sub).Find(&users))
+			assertContains(t, sql, "AS x WHERE `x`.`deleted` = ?")
+			assertNotContains(t, sql, "`users`.`deleted`")
+		})
+	}
+
+	db := openDB(t, &User{})
+	seedUsers(t, db)
+	var users []User
+	if err := db.Table("users AS u").Where("u.name <> ?", "z").Find(&users).Error; err != nil || len(users) != 2 {
+		t.Errorf("alias query = %d, %v", len(users), err)
+	}
+	if err := db.Table("(?) AS x", db.Model(&User{}).Scopes(WithDeleted)).Find(&users).Error; err != nil || len(users) != 2 {
+		t.Errorf("subquery table = %d, %v", len(users), err)
+	}
+}
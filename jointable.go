@@ -0,0 +1,57 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const joinTableCallbackName = "soft_delete:join_table"
+
+// SetupJoinTable 与 db.SetupJoinTable 相同，并要求自定义连接表带有本包的软删除字段。
+// association 的 Delete、Replace、Clear 会软删除连接表中的记录，关联查询和 Preload 会过滤已删除的记录；
+// 重新 Append 已软删除的关联时恢复原有记录，而不是因主键冲突被忽略
+//
+//	soft_delete.SetupJoinTable(db, &User{}, "Tags", &UserTag{})
+func SetupJoinTable(db *gorm.DB, model interface{}, field string, joinTable interface{}) error {
+	s, _, err := parseDeleteClause(db, joinTable)
+	if err != nil {
+		return err
+	}
+
+	if err := db.SetupJoinTable(model, field, joinTable); err != nil {
+		return err
+	}
+	configOf(db).joinTables.Store(s, true)
+
+	if db.Callback().Create().Get(joinTableCallbackName) == nil {
+		return db.Callback().Create().Before("gorm:create").Register(joinTableCallbackName, relinkJoinTable)
+	}
+	return nil
+}
+
+// relinkJoinTable 将 gorm 写入连接表时的 ON CONFLICT DO NOTHING 改为恢复冲突的记录
+func relinkJoinTable(db *gorm.DB) {
+	stmt := db.Statement
+	if db.Error != nil || stmt.Schema == nil || len(stmt.Schema.PrimaryFields) == 0 {
+		return
+	}
+	if _, ok := configOf(db).joinTables.Load(stmt.Schema); !ok {
+		return
+	}
+
+	onConflict, ok := stmt.Clauses["ON CONFLICT"].Expression.(clause.OnConflict)
+	if !ok || !onConflict.DoNothing {
+		return
+	}
+
+	sd, ok := lookUpDeleteClause(stmt.Schema)
+	if !ok {
+		return
+	}
+
+	columns := make([]clause.Column, 0, len(stmt.Schema.PrimaryFields))
+	for _, field := range stmt.Schema.PrimaryFields {
+		columns = append(columns, clause.Column{Name: field.DBName})
+	}
+	stmt.AddClause(clause.OnConflict{Columns: columns, DoUpdates: sd.restoreSet()})
+}
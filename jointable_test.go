@@ -0,0 +1,91 @@
+package soft_delete
+
+import "testing"
+
+type Member struct {
+	ID   uint
+	Tags []Tag `gorm:"many2many:member_tags"`
+}
+
+type Tag struct {
+	ID   uint
+	Name string
+}
+
+type MemberTag struct {
+	MemberID uint `gorm:"primaryKey"`
+	TagID    uint `gorm:"primaryKey"`
+	Deleted  DeletedAt
+}
+
+func TestSetupJoinTable(t *testing.T) {
+	db := openDB(t)
+	if err := SetupJoinTable(db, &Member{}, "Tags", &MemberTag{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Member{}, &Tag{}); err != nil {
+		t.Fatal(err)
+	}
+	member := Member{Tags: []Tag{{Name: "a"}, {Name: "b"}, {Name: "c"}}}
+	db.Create(&member)
+	firstTag := member.Tags[0].ID
+
+	// Delete 软删除连接表中的记录，关联查询和 Preload 不再返回
+	if err := db.Model(&member).Association("Tags").Delete(&member.Tags[0]); err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, db, "member_tags"); n != 3 {
+		t.Errorf("join rows = %d, want 3", n)
+	}
+	if n := db.Model(&member).Association("Tags").Count(); n != 2 {
+		t.Errorf("association count = %d, want 2", n)
+	}
+	var loaded Member
+	db.Preload("Tags").First(&loaded, member.ID)
+	if len(loaded.Tags) != 2 {
+		t.Errorf("preloaded tags = %+v", loaded.Tags)
+	}
+
+	// Replace、Clear 同样软删除
+	if err := db.Model(&member).Association("Tags").Replace(&member.Tags[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Model(&member).Association("Tags").Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.Model(&member).Association("Tags").Count(); n != 0 {
+		t.Errorf("association count after clear = %d", n)
+	}
+	var links []MemberTag
+	db.Scopes(WithDeleted).Find(&links)
+	if len(links) != 3 {
+		t.Fatalf("links = %+v", links)
+	}
+	for _, link := range links {
+		if !link.Deleted {
+			t.Errorf("link %+v not deleted", link)
+		}
+	}
+
+	// 重新 Append 恢复原有的记录
+	if err := db.Model(&member).Association("Tags").Append(&Tag{ID: firstTag}); err != nil {
+		t.Fatal(err)
+	}
+	if n := db.Model(&member).Association("Tags").Count(); n != 1 {
+		t.Errorf("association count after append = %d, want 1", n)
+	}
+	if n := countRows(t, db, "member_tags"); n != 3 {
+		t.Errorf("join rows after append = %d, want 3", n)
+	}
+}
+
+func TestSetupJoinTableRequiresField(t *testing.T) {
+	type PlainTag struct {
+		MemberID uint `gorm:"primaryKey"`
+		TagID    uint `gorm:"primaryKey"`
+	}
+	db := openDB(t)
+	if err := SetupJoinTable(db, &Member{}, "Tags", &PlainTag{}); err != ErrMissingSoftDeleteField {
+		t.Errorf("SetupJoinTable error = %v", err)
+	}
+}
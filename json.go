@@ -0,0 +1,110 @@
+package soft_delete
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// JSONMode 决定删除状态序列化为 JSON 的形式。DeletedAt 总是序列化为 true/false，
+// 其他形式由 Field 的类型参数实现 JSONModer 指定，或者通过 Model.MarshalDeleted 序列化
+type JSONMode int
+
+const (
+	// JSONBool 序列化为 true/false
+	JSONBool JSONMode = iota
+	// JSONNull 未删除时序列化为 null，已删除时为 true，适用于以是否为空判断删除的客户端
+	JSONNull
+	// JSONTime 未删除时序列化为 null，已删除时为删除时间，没有删除时间时为 true
+	JSONTime
+)
+
+// JSONModer 可以由 Field 的类型参数实现，指定字段序列化为 JSON 的形式
+type JSONModer interface {
+	JSONMode() JSONMode
+}
+
+// Marshal 按 m 序列化删除状态，at 为删除时间，未知时为 nil
+func (m JSONMode) Marshal(deleted bool, at *time.Time) ([]byte, error) {
+	switch {
+	case m == JSONBool:
+		return json.Marshal(deleted)
+	case !deleted:
+		return []byte("null"), nil
+	case m == JSONTime && at != nil:
+		return json.Marshal(at)
+	}
+	return []byte("true"), nil
+}
+
+// 实现 json.Marshaler 接口
+func (b DeletedAt) MarshalJSON() ([]byte, error) {
+	return JSONBool.Marshal(bool(b), nil)
+}
+
+// 实现 json.Unmarshaler 接口，兼容 bool、0/1 等数字、"true"/"false" 等字符串以及 null，
+// JSONTime 序列化的删除时间视为已删除
+func (b *DeletedAt) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*b = DeletedAt(FlagActived)
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = DeletedAt(v)
+	case float64:
+		*b = v != 0
+	case string:
+		if _, err := time.Parse(time.RFC3339Nano, v); err == nil {
+			*b = true
+			return nil
+		}
+		return b.UnmarshalText([]byte(v))
+	default:
+		return fmt.Errorf("invalid JSON value for DeletedAt: %s", data)
+	}
+	return nil
+}
+
+// 实现 encoding.TextMarshaler 接口，用于 URL 查询参数、CSV 等文本格式
+func (b DeletedAt) MarshalText() ([]byte, error) {
+	if b {
+		return []byte("true"), nil
+	}
+	return []byte("false"), nil
+}
+
+// 实现 encoding.TextUnmarshaler 接口，空字符串视为未删除
+func (b *DeletedAt) UnmarshalText(text []byte) error {
+	if len(bytes.TrimSpace(text)) == 0 {
+		*b = DeletedAt(FlagActived)
+		return nil
+	}
+	return b.scanString(string(bytes.TrimSpace(text)))
+}
+
+// 实现 json.Marshaler 接口，T 实现 JSONModer 时按其形式序列化，否则为 true/false
+func (d Field[T]) MarshalJSON() ([]byte, error) {
+	var t T
+	if m, ok := interface{}(t).(JSONModer); ok {
+		return m.JSONMode().Marshal(bool(d), nil)
+	}
+	return JSONBool.Marshal(bool(d), nil)
+}
+
+// 实现 json.Unmarshaler 接口，与 DeletedAt 相同
+func (d *Field[T]) UnmarshalJSON(data []byte) error {
+	return (*DeletedAt)(d).UnmarshalJSON(data)
+}
+
+// MarshalDeleted 按 mode 序列化删除状态，JSONTime 时已删除的记录为 DeletedAtTime
+func (m Model) MarshalDeleted(mode JSONMode) ([]byte, error) {
+	return mode.Marshal(m.Deleted.IsDeleted(), m.DeletedAtTime)
+}
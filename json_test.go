@@ -0,0 +1,199 @@
+package soft_delete
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type nullJSONFlag struct{ int16Flag }
+
+func (nullJSONFlag) JSONMode() JSONMode { return JSONNull }
+
+func TestJSONMode(t *testing.T) {
+	at := testNow
+	tests := []struct {
+		mode    JSONMode
+		deleted bool
+		at      *time.Time
+		want    string
+	}{
+		{JSONBool, false, nil, "false"},
+		{JSONBool, true, &at, "true"},
+		{JSONNull, false, nil, "null"},
+		{JSONNull, true, &at, "true"},
+		{JSONTime, false, &at, "null"},
+		{JSONTime, true, nil, "true"},
+		{JSONTime, true, &at, `"2023-08-01T12:00:00Z"`},
+	}
+	for _, tt := range tests {
+		b, err := tt.mode.Marshal(tt.deleted, tt.at)
+		if err != nil || string(b) != tt.want {
+			t.Errorf("mode %d deleted %v = %s, %v, want %s", tt.mode, tt.deleted, b, err, tt.want)
+		}
+	}
+}
+
+// DeletedAt 总是序列化为 bool，Field 按类型参数的 JSONMode 序列化，互不影响
+func TestMarshalJSON(t *testing.T) {
+	type payload struct {
+		Deleted DeletedAt
+		Null    Field[nullJSONFlag]
+		Plain   Field[int16Flag]
+	}
+	b, err := json.Marshal(payload{})
+	if err != nil || string(b) != `{"Deleted":false,"Null":null,"Plain":false}` {
+		t.Errorf("active = %s, %v", b, err)
+	}
+	b, _ = json.Marshal(payload{Deleted: true, Null: true, Plain: true})
+	if string(b) != `{"Deleted":true,"Null":true,"Plain":true}` {
+		t.Errorf("deleted = %s", b)
+	}
+
+	var got payload
+	if err := json.Unmarshal([]byte(`{"Deleted":true,"Null":null,"Plain":1}`), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Deleted.IsDeleted() || got.Null.IsDeleted() || !got.Plain.IsDeleted() {
+		t.Errorf("round trip = %+v", got)
+	}
+
+	at := testNow
+	m := Model{Deleted: true, DeletedAtTime: &at}
+	b, _ = m.MarshalDeleted(JSONTime)
+	var back DeletedAt
+	if err := json.Unmarshal(b, &back); err != nil || !back.IsDeleted() {
+		t.Errorf("timestamp %s round trip = %v, %v", b, back, err)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data    string
+		deleted bool
+	}{
+		{"true", true},
+		{"false", false},
+		{"1", true},
+		{"0", false},
+		{`"true"`, true},
+		{`"false"`, false},
+		{`"1"`, true},
+		{"null", false},
+		{`"2023-08-01T12:00:00Z"`, true},
+	}
+	for _, tt := range tests {
+		b := DeletedAt(!tt.deleted)
+		if err := json.Unmarshal([]byte(tt.data), &b); err != nil || b.IsDeleted() != tt.deleted {
+			t.Errorf("Unmarshal(%s) = %v, %v", tt.data, b, err)
+		}
+	}
+	var b DeletedAt
+	for _, data := range []string{`"maybe"`, "[]", "{}"} {
+		if err := json.Unmarshal([]byte(data), &b); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded", data)
+		}
+	}
+}
+
+// 文本形式用于 URL 查询参数等表单绑定
+func TestMarshalText(t *testing.T) {
+	form, _ := url.ParseQuery("deleted=true&active=&other=0")
+	for key, want := range map[string]bool{"deleted": true, "active": false, "other": false} {
+		var b DeletedAt
+		if err := b.UnmarshalText([]byte(form.Get(key))); err != nil || b.IsDeleted() != want {
+			t.Errorf("%s = %v, %v", key, b, err)
+		}
+		text, _ := b.MarshalText()
+		var back DeletedAt
+		if back.UnmarshalText(text); back != b {
+			t.Errorf("%s text round trip %s = %v", key, text, back)
+		}
+	}
+}
+
+// deletionState 为各标记类型共有的方法
+type deletionState interface {
+	IsDeleted() bool
+	IsActive() bool
+	String() string
+}
+
+func TestDeletionState(t *testing.T) {
+	tests := []struct {
+		value   deletionState
+		deleted bool
+	}{
+		{DeletedAt(false), false},
+		{DeletedAt(true), true},
+		{ActiveFlag(true), false},
+		{ActiveFlag(false), true},
+		{Field[int16Flag](true), true},
+		{NullDeletedAt{}, false},
+		{NullDeletedAt{Flag: true, Valid: true}, true},
+		{DeletedAtUnix(0), false},
+		{DeletedAtMilli(1690891200000), true},
+		{DeletedToken(7), true},
+		{Status("pending"), false},
+		{StatusDeleted, true},
+		{BitFlag(0b110), false},
+		{BitFlag(0b111), true},
+	}
+	for _, tt := range tests {
+		want := "active"
+		if tt.deleted {
+			want = "deleted"
+		}
+		if tt.value.IsDeleted() != tt.deleted || tt.value.IsActive() == tt.deleted || tt.value.String() != want {
+			t.Errorf("%T(%v): IsDeleted %v, IsActive %v, String %q", tt.value, tt.value, tt.value.IsDeleted(), tt.value.IsActive(), tt.value.String())
+		}
+	}
+}
+
+// 通过标签修改了取值的 Status、BitFlag 按字段判断
+func TestDeletionStateFor(t *testing.T) {
+	db := openDB(t)
+	status := fieldOf(t, db, &LegacyTicket{}, "Status")
+	bit := fieldOf(t, db, &BitUser{}, "Flags")
+	tests := []struct {
+		name    string
+		deleted bool
+		got     bool
+		active  bool
+	}{
+		{"removed", true, Status("removed").IsDeletedFor(status), Status("removed").IsActiveFor(status)},
+		{"deleted", false, StatusDeleted.IsDeletedFor(status), StatusDeleted.IsActiveFor(status)},
+		{"bit 3", true, BitFlag(0b1000).IsDeletedFor(bit), BitFlag(0b1000).IsActiveFor(bit)},
+		{"bit 0", false, BitFlag(0b0001).IsDeletedFor(bit), BitFlag(0b0001).IsActiveFor(bit)},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.deleted || tt.active == tt.deleted {
+			t.Errorf("%s: IsDeletedFor %v, IsActiveFor %v", tt.name, tt.got, tt.active)
+		}
+	}
+}
+
+// MarkDeleted、MarkActive 只修改内存中的值，序列化结果随之变化
+func TestMarkDeleted(t *testing.T) {
+	var b DeletedAt
+	b.MarkDeleted()
+	if data, _ := json.Marshal(b); !b.IsDeleted() || string(data) != "true" {
+		t.Errorf("MarkDeleted = %v, %s", b, data)
+	}
+	b.MarkActive()
+	if data, _ := json.Marshal(b); b.IsDeleted() || string(data) != "false" {
+		t.Errorf("MarkActive = %v, %s", b, data)
+	}
+
+	a := ActiveFlag(true)
+	a.MarkDeleted()
+	if !a.IsDeleted() {
+		t.Error("ActiveFlag.MarkDeleted")
+	}
+	var f Field[int16Flag]
+	f.MarkDeleted()
+	if v, _ := f.Value(); v != int64(1) {
+		t.Errorf("Field.MarkDeleted value = %v", v)
+	}
+}
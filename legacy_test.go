@@ -0,0 +1,106 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DualAccount 同时维护标记列和旧代码读取的 deleted_at
+type DualAccount struct {
+	ID           uint
+	Name         string
+	Deleted      DeletedAt  `gorm:"softDelete:flag,LegacyTimestampField:DeletedAtOld"`
+	DeletedAtOld *time.Time `gorm:"column:deleted_at"`
+}
+
+// OldDualAccount 为仍在运行的旧代码使用的模型
+type OldDualAccount struct {
+	ID        uint
+	Name      string
+	DeletedAt gorm.DeletedAt
+}
+
+func (OldDualAccount) TableName() string { return "dual_accounts" }
+
+type DualOrAccount struct {
+	ID           uint
+	Deleted      DeletedAt  `gorm:"softDelete:flag,LegacyTimestampField:DeletedAtOld,LegacyTimestampMatch:OR"`
+	DeletedAtOld *time.Time `gorm:"column:deleted_at"`
+}
+
+func activeIDs(t *testing.T, db *gorm.DB, model interface{}) []uint {
+	t.Helper()
+	var ids []uint
+	if err := db.Model(model).Order("id").Pluck("id", &ids).Error; err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func equalIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLegacyTimestampDualWrite(t *testing.T) {
+	db := pinNow(openDB(t, &DualAccount{}))
+	accounts := []DualAccount{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	db.Create(&accounts)
+
+	// 新代码删除，新旧代码的读取一致
+	if err := db.Delete(&accounts[0]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if accounts[0].DeletedAtOld == nil || !accounts[0].DeletedAtOld.Equal(testNow) {
+		t.Errorf("DeletedAtOld = %v in memory", accounts[0].DeletedAtOld)
+	}
+	newIDs, oldIDs := activeIDs(t, db, &DualAccount{}), activeIDs(t, db, &OldDualAccount{})
+	if !equalIDs(newIDs, []uint{2, 3}) || !equalIDs(oldIDs, newIDs) {
+		t.Errorf("after new delete: new %v, old %v", newIDs, oldIDs)
+	}
+
+	// 旧代码删除只写 deleted_at，新代码同样看不到
+	if err := db.Delete(&OldDualAccount{ID: 2}).Error; err != nil {
+		t.Fatal(err)
+	}
+	newIDs, oldIDs = activeIDs(t, db, &DualAccount{}), activeIDs(t, db, &OldDualAccount{})
+	if !equalIDs(newIDs, []uint{3}) || !equalIDs(oldIDs, newIDs) {
+		t.Errorf("after old delete: new %v, old %v", newIDs, oldIDs)
+	}
+
+	// 恢复同时清除两列
+	if n, err := Restore(db, &DualAccount{ID: 1}); err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	newIDs, oldIDs = activeIDs(t, db, &DualAccount{}), activeIDs(t, db, &OldDualAccount{})
+	if !equalIDs(newIDs, []uint{1, 3}) || !equalIDs(oldIDs, newIDs) {
+		t.Errorf("after restore: new %v, old %v", newIDs, oldIDs)
+	}
+
+	dry := dryRunDB(t, "sqlite")
+	assertContains(t, sqlOf(t, dry.Find(&[]DualAccount{})), "(`dual_accounts`.`deleted` = ? AND `dual_accounts`.`deleted_at` IS NULL)")
+	assertContains(t, sqlOf(t, dry.Delete(&DualAccount{ID: 1})), "`deleted_at`=")
+}
+
+func TestLegacyTimestampMatchOr(t *testing.T) {
+	db := openDB(t, &DualOrAccount{})
+	db.Create(&[]DualOrAccount{{}, {}})
+	// 只有旧列为已删除的记录在 OR 时仍然可见
+	db.Table("dual_or_accounts").Where("id = ?", 2).Update("deleted_at", testNow)
+
+	if ids := activeIDs(t, db, &DualOrAccount{}); !equalIDs(ids, []uint{1, 2}) {
+		t.Errorf("active = %v", ids)
+	}
+	dry := dryRunDB(t, "sqlite")
+	assertContains(t, sqlOf(t, dry.Find(&[]DualOrAccount{})), "(`dual_or_accounts`.`deleted` = ? OR `dual_or_accounts`.`deleted_at` IS NULL)")
+	assertContains(t, sqlOf(t, dry.Scopes(OnlyDeleted).Find(&[]DualOrAccount{})), "(`dual_or_accounts`.`deleted` <> ? AND `dual_or_accounts`.`deleted_at` IS NOT NULL)")
+}
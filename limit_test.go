@@ -0,0 +1,54 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// withClauses 按方言设置 db 的 UPDATE 和 DELETE 子句，与 gorm 各方言注册回调时一致
+func withClauses(db *gorm.DB, update, delete []string) *gorm.DB {
+	db.Callback().Update().Clauses = update
+	db.Callback().Delete().Clauses = delete
+	return db
+}
+
+func TestDeleteLimitMySQL(t *testing.T) {
+	db := withClauses(dryRunDB(t, "mysql"),
+		[]string{"UPDATE", "SET", "WHERE", "ORDER BY", "LIMIT"},
+		[]string{"DELETE", "FROM", "WHERE", "ORDER BY", "LIMIT"})
+
+	sql := sqlOf(t, db.Where("name = ?", "a").Order("id").Limit(1000).Delete(&User{}))
+	assertContains(t, sql, "UPDATE `users` SET", "ORDER BY id LIMIT 1000")
+}
+
+func TestDeleteLimitFromDeleteClauses(t *testing.T) {
+	// UPDATE 不支持但 DELETE 支持时同样带入，按 UPDATE 的子句顺序插入
+	db := withClauses(dryRunDB(t, "custom"),
+		[]string{"UPDATE", "SET", "WHERE", "RETURNING"},
+		[]string{"DELETE", "FROM", "WHERE", "ORDER BY", "LIMIT", "RETURNING"})
+
+	sql := sqlOf(t, db.Where("name = ?", "a").Limit(10).Delete(&User{}))
+	assertContains(t, sql, "LIMIT 10")
+}
+
+func TestDeleteLimitUnsupported(t *testing.T) {
+	db := withClauses(dryRunDB(t, "postgres"),
+		[]string{"UPDATE", "SET", "FROM", "WHERE", "RETURNING"},
+		[]string{"DELETE", "FROM", "WHERE", "RETURNING"})
+
+	for name, tx := range map[string]*gorm.DB{
+		"LIMIT":    db.Where("name = ?", "a").Limit(1000).Delete(&User{}),
+		"ORDER BY": db.Where("name = ?", "a").Order("id").Delete(&User{}),
+	} {
+		if !errors.Is(tx.Error, ErrUnsupportedClause) {
+			t.Errorf("%s: err = %v, want ErrUnsupportedClause", name, tx.Error)
+		}
+	}
+
+	// Limit(-1) 取消 LIMIT，不生成 SQL，不算不支持
+	if err := db.Where("name = ?", "a").Limit(-1).Delete(&User{}).Error; err != nil {
+		t.Errorf("Limit(-1): %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package soft_delete
+
+import (
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/clause"
+)
+
+func TestRestoreLockedConcurrent(t *testing.T) {
+	db := openDB(t, &User{})
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	user := User{Name: "a"}
+	db.Create(&user)
+	db.Delete(&user)
+
+	var (
+		wg      sync.WaitGroup
+		results = make([]int64, 2)
+		errs    = make([]error, 2)
+	)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = RestoreLocked(db, &User{ID: user.ID})
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if results[0]+results[1] != 1 {
+		t.Errorf("rows affected = %v, want exactly one restore", results)
+	}
+}
+
+func TestRestoreLockedActiveRecord(t *testing.T) {
+	db := openDB(t, &User{})
+	user := User{Name: "a"}
+	db.Create(&user)
+
+	if n, err := RestoreLocked(db, &User{ID: user.ID}); err != nil || n != 0 {
+		t.Errorf("RestoreLocked = %d, %v, want 0 rows", n, err)
+	}
+	if _, err := RestoreLocked(db, &User{}); err == nil {
+		t.Error("RestoreLocked without a primary key should fail")
+	}
+}
+
+func TestDeleteKeepsLocking(t *testing.T) {
+	// 方言的 DELETE 注册了 FOR 时带入改写后的 UPDATE，否则忽略
+	db := withClauses(dryRunDB(t, "custom"),
+		[]string{"UPDATE", "SET", "WHERE"},
+		[]string{"DELETE", "FROM", "WHERE", "FOR"})
+	// SQLite 的方言会去掉 FOR，这里按支持行锁的数据库生成
+	delete(db.ClauseBuilders, "FOR")
+	sql := sqlOf(t, db.Clauses(clause.Locking{Strength: "UPDATE"}).Delete(&User{ID: 1}))
+	assertContains(t, sql, "UPDATE `users` SET", "FOR UPDATE")
+
+	sql = sqlOf(t, dryRunDB(t, "sqlite").Clauses(clause.Locking{Strength: "UPDATE"}).Delete(&User{ID: 1}))
+	assertNotContains(t, sql, "FOR UPDATE")
+}
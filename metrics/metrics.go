@@ -0,0 +1,129 @@
+// Package metrics 以 Prometheus 指标统计软删除、恢复和清理，只有引入本包时才依赖 Prometheus
+//
+//	m := metrics.New()
+//	db.Use(m)
+//	prometheus.MustRegister(m)
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/gorm"
+
+	"github.com/yanqin001/soft_delete"
+)
+
+const (
+	deleteCallbackName = "soft_delete:metrics_delete"
+	updateCallbackName = "soft_delete:metrics_update"
+	startCallbackName  = "soft_delete:metrics_start"
+
+	startKey = "soft_delete:metrics_start"
+)
+
+// Metrics 既是 gorm 插件也是 prometheus.Collector，按表统计：
+//
+//	soft_delete_deletes_total{table}                  软删除语句数
+//	soft_delete_restores_total{table}                 恢复语句数
+//	soft_delete_purges_total{table}                   清理的批数
+//	soft_delete_rows_total{table,action}              各操作影响的行数，action 为 delete、restore、purge
+//	soft_delete_purge_batch_duration_seconds{table}   每批清理的耗时
+type Metrics struct {
+	deletes       *prometheus.CounterVec
+	restores      *prometheus.CounterVec
+	purges        *prometheus.CounterVec
+	rows          *prometheus.CounterVec
+	purgeDuration *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	return &Metrics{
+		deletes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "soft_delete_deletes_total",
+			Help: "Number of soft delete statements.",
+		}, []string{"table"}),
+		restores: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "soft_delete_restores_total",
+			Help: "Number of restore statements.",
+		}, []string{"table"}),
+		purges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "soft_delete_purges_total",
+			Help: "Number of purge batches.",
+		}, []string{"table"}),
+		rows: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "soft_delete_rows_total",
+			Help: "Number of rows affected by soft delete operations.",
+		}, []string{"table", "action"}),
+		purgeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "soft_delete_purge_batch_duration_seconds",
+			Help:    "Duration of purge batches.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"table"}),
+	}
+}
+
+// Name 实现 gorm.Plugin 接口
+func (m *Metrics) Name() string {
+	return "soft_delete:metrics"
+}
+
+// Initialize 实现 gorm.Plugin 接口，只统计提交成功的语句
+func (m *Metrics) Initialize(db *gorm.DB) error {
+	if err := db.Callback().Delete().Before("gorm:delete").Register(startCallbackName, func(tx *gorm.DB) {
+		if soft_delete.IsPurge(tx.Statement) {
+			tx.InstanceSet(startKey, time.Now())
+		}
+	}); err != nil {
+		return err
+	}
+	if err := db.Callback().Delete().After("gorm:commit_or_rollback_transaction").Register(deleteCallbackName, m.afterDelete); err != nil {
+		return err
+	}
+	return db.Callback().Update().After("gorm:commit_or_rollback_transaction").Register(updateCallbackName, m.afterUpdate)
+}
+
+func (m *Metrics) afterDelete(db *gorm.DB) {
+	if db.Error != nil {
+		return
+	}
+
+	stmt := db.Statement
+	switch {
+	case soft_delete.IsSoftDelete(stmt):
+		m.deletes.WithLabelValues(stmt.Table).Inc()
+		m.rows.WithLabelValues(stmt.Table, soft_delete.ActionDelete).Add(float64(db.RowsAffected))
+	case soft_delete.IsPurge(stmt):
+		m.purges.WithLabelValues(stmt.Table).Inc()
+		m.rows.WithLabelValues(stmt.Table, "purge").Add(float64(db.RowsAffected))
+		if start, ok := db.InstanceGet(startKey); ok {
+			m.purgeDuration.WithLabelValues(stmt.Table).Observe(time.Since(start.(time.Time)).Seconds())
+		}
+	}
+}
+
+func (m *Metrics) afterUpdate(db *gorm.DB) {
+	if db.Error != nil || !soft_delete.IsRestore(db.Statement) {
+		return
+	}
+	m.restores.WithLabelValues(db.Statement.Table).Inc()
+	m.rows.WithLabelValues(db.Statement.Table, soft_delete.ActionRestore).Add(float64(db.RowsAffected))
+}
+
+// Describe 实现 prometheus.Collector 接口
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.deletes.Describe(ch)
+	m.restores.Describe(ch)
+	m.purges.Describe(ch)
+	m.rows.Describe(ch)
+	m.purgeDuration.Describe(ch)
+}
+
+// Collect 实现 prometheus.Collector 接口
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.deletes.Collect(ch)
+	m.restores.Collect(ch)
+	m.purges.Collect(ch)
+	m.rows.Collect(ch)
+	m.purgeDuration.Collect(ch)
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yanqin001/soft_delete"
+)
+
+type User struct {
+	ID        uint
+	Name      string
+	Deleted   soft_delete.DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&User{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMetrics(t *testing.T) {
+	db := openDB(t)
+	m := New()
+	if err := db.Use(m); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}, {Name: "c"}})
+
+	db.Delete(&[]User{{ID: 1}, {ID: 2}})
+	db.Delete(&User{ID: 3})
+	soft_delete.Restore(db, &User{ID: 1})
+	if n, err := soft_delete.Purge(db, &User{}); n != 2 || err != nil {
+		t.Fatalf("Purge = %d, %v", n, err)
+	}
+	db.Model(&User{ID: 1}).Update("name", "x")
+
+	if v := testutil.ToFloat64(m.deletes.WithLabelValues("users")); v != 2 {
+		t.Errorf("deletes = %v, want 2", v)
+	}
+	if v := testutil.ToFloat64(m.rows.WithLabelValues("users", soft_delete.ActionDelete)); v != 3 {
+		t.Errorf("deleted rows = %v, want 3", v)
+	}
+	if v := testutil.ToFloat64(m.restores.WithLabelValues("users")); v != 1 {
+		t.Errorf("restores = %v, want 1", v)
+	}
+	if v := testutil.ToFloat64(m.rows.WithLabelValues("users", soft_delete.ActionRestore)); v != 1 {
+		t.Errorf("restored rows = %v, want 1", v)
+	}
+	if v := testutil.ToFloat64(m.purges.WithLabelValues("users")); v != 1 {
+		t.Errorf("purges = %v, want 1", v)
+	}
+	if v := testutil.ToFloat64(m.rows.WithLabelValues("users", "purge")); v != 2 {
+		t.Errorf("purged rows = %v, want 2", v)
+	}
+	if n := testutil.CollectAndCount(m, "soft_delete_purge_batch_duration_seconds"); n != 1 {
+		t.Errorf("purge duration series = %d, want 1", n)
+	}
+	if n := testutil.CollectAndCount(m); n == 0 {
+		t.Error("no metrics collected")
+	}
+}
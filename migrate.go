@@ -0,0 +1,117 @@
+package soft_delete
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultMigrateBatchSize = 1000
+
+type migrateConfig struct {
+	batchSize int
+	progress  func(done, total int64)
+}
+
+// MigrateOption 配置 MigrateTimestampToFlag 的行为
+type MigrateOption func(*migrateConfig)
+
+// MigrateBatchSize 设置每批转换的行数，默认 1000
+func MigrateBatchSize(size int) MigrateOption {
+	return func(c *migrateConfig) {
+		if size > 0 {
+			c.batchSize = size
+		}
+	}
+}
+
+// MigrateProgress 在每批转换之后调用 fn，done 为已处理的行数，total 为开始时表中的行数
+func MigrateProgress(fn func(done, total int64)) MigrateOption {
+	return func(c *migrateConfig) {
+		c.progress = fn
+	}
+}
+
+// MigrateTimestampToFlag 将 gorm.DeletedAt 的时间戳列 from 转换为标记列 to：from 不为 NULL 的记录写入已删除的值。
+// from 为 NULL 的记录保持不变，迁移添加的标记列默认为未删除，迁移期间由新代码删除的记录也不会被改回。
+// to 必须是模型的软删除标记列，写入字段的取值。按主键顺序分批执行，每批一条 UPDATE，不在事务中，
+// 中断后可以重新执行；每批之间检查 context 是否已取消，返回转换的行数
+//
+//	soft_delete.MigrateTimestampToFlag(db, &User{}, "deleted_at", "deleted",
+//		soft_delete.MigrateProgress(func(done, total int64) { log.Printf("%d/%d", done, total) }))
+func MigrateTimestampToFlag(db *gorm.DB, model interface{}, from, to string, opts ...MigrateOption) (migrated int64, err error) {
+	config := migrateConfig{batchSize: defaultMigrateBatchSize}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	s, sd, err := parseDeleteClause(db, model)
+	if err != nil {
+		return 0, err
+	}
+	if !sd.Flag || sd.Token || sd.Interval || sd.Expires || s.LookUpField(to) != sd.Field {
+		return 0, fmt.Errorf("soft_delete: %s is not the soft delete flag column of %s", to, s.Name)
+	}
+	if len(s.PrimaryFields) != 1 {
+		return 0, ErrCompositePrimaryKey
+	}
+	pk := clause.Column{Name: s.PrimaryFields[0].DBName}
+	// 已经是已删除的值的记录不再写入，重新执行时返回的行数只包含本次转换的记录
+	flag := clause.Column{Name: sd.Field.DBName}
+	deletedValue := sqlValue(flagValuesOf(sd.Field).deleted)
+	deleted := clause.And(
+		clause.Neq{Column: clause.Column{Name: from}, Value: nil},
+		clause.Or(clause.Neq{Column: flag, Value: deletedValue}, clause.Eq{Column: flag, Value: nil}),
+	)
+
+	// 只用表名，不经过软删除的过滤和回调
+	table := func() *gorm.DB {
+		return db.Session(&gorm.Session{NewDB: true}).Table(s.Table)
+	}
+	var total int64
+	if err = table().Count(&total).Error; err != nil {
+		return 0, err
+	}
+
+	var done int64
+	var last interface{}
+	for {
+		if err = db.Statement.Context.Err(); err != nil {
+			return
+		}
+
+		tx := table()
+		if last != nil {
+			tx = tx.Where(clause.Gt{Column: pk, Value: last})
+		}
+		keys := reflect.New(reflect.SliceOf(s.PrimaryFields[0].FieldType))
+		if err = tx.Order(clause.OrderByColumn{Column: pk}).Limit(config.batchSize).Pluck(pk.Name, keys.Interface()).Error; err != nil {
+			return
+		}
+		size := keys.Elem().Len()
+		if size == 0 {
+			return
+		}
+
+		batch := make([]interface{}, size)
+		for i := range batch {
+			batch[i] = keys.Elem().Index(i).Interface()
+		}
+		result := table().Where(clause.IN{Column: pk, Values: batch}).Where(deleted).UpdateColumn(sd.Field.DBName, deletedValue)
+		if err = result.Error; err != nil {
+			return
+		}
+		migrated += result.RowsAffected
+		done += int64(size)
+		last = batch[size-1]
+		if config.progress != nil {
+			config.progress(done, total)
+		}
+
+		if size < config.batchSize {
+			return
+		}
+	}
+}
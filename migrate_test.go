@@ -0,0 +1,125 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// LegacyAccount 为迁移前使用 gorm.DeletedAt 的模型
+type LegacyAccount struct {
+	ID        uint
+	Name      string
+	DeletedAt gorm.DeletedAt
+}
+
+func (LegacyAccount) TableName() string { return "accounts" }
+
+// TransitionAccount 在迁移期间查询仍按 deleted_at 过滤
+type TransitionAccount struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag,TimestampColumn:deleted_at"`
+}
+
+func (TransitionAccount) TableName() string { return "accounts" }
+
+// MigratedAccount 为迁移完成后的模型
+type MigratedAccount struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+func (MigratedAccount) TableName() string { return "accounts" }
+
+// openLegacyAccounts 以旧模型创建 5 条记录并删除其中的 2、4，再加上标记列
+func openLegacyAccounts(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := openDB(t, &LegacyAccount{})
+	accounts := make([]LegacyAccount, 5)
+	db.Create(&accounts)
+	db.Delete(&LegacyAccount{}, []uint{2, 4})
+	if err := db.AutoMigrate(&TransitionAccount{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestScanTimestamp(t *testing.T) {
+	db := openLegacyAccounts(t)
+	var flags []DeletedAt
+	if err := db.Table("accounts").Order("id").Pluck("deleted_at", &flags).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := []DeletedAt{false, true, false, true, false}
+	for i := range want {
+		if flags[i] != want[i] {
+			t.Fatalf("scanned %v, want %v", flags, want)
+		}
+	}
+}
+
+func TestTimestampColumn(t *testing.T) {
+	db := openLegacyAccounts(t)
+
+	var found []TransitionAccount
+	db.Find(&found)
+	if len(found) != 3 {
+		t.Errorf("active = %+v, want 3 rows with NULL deleted_at", found)
+	}
+	var n int64
+	db.Model(&TransitionAccount{}).Scopes(OnlyDeleted).Count(&n)
+	if n != 2 {
+		t.Errorf("deleted = %d, want 2", n)
+	}
+
+	// 删除写入标记列
+	if err := db.Delete(&TransitionAccount{ID: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	var flag bool
+	db.Table("accounts").Select("deleted").Where("id = ?", 1).Scan(&flag)
+	if !flag {
+		t.Error("delete should write the flag column")
+	}
+
+	sql := sqlOf(t, dryRunDB(t, "sqlite").Find(&[]TransitionAccount{}))
+	assertContains(t, sql, "`accounts`.`deleted_at` IS NULL")
+	assertNotContains(t, sql, "`accounts`.`deleted` =")
+}
+
+func TestMigrateTimestampToFlag(t *testing.T) {
+	db := openLegacyAccounts(t)
+
+	var progress [][2]int64
+	migrated, err := MigrateTimestampToFlag(db, &MigratedAccount{}, "deleted_at", "deleted",
+		MigrateBatchSize(2),
+		MigrateProgress(func(done, total int64) { progress = append(progress, [2]int64{done, total}) }))
+	if err != nil || migrated != 2 {
+		t.Fatalf("MigrateTimestampToFlag = %d, %v, want 2", migrated, err)
+	}
+	want := [][2]int64{{2, 5}, {4, 5}, {5, 5}}
+	if len(progress) != len(want) {
+		t.Fatalf("progress = %v, want %v", progress, want)
+	}
+	for i := range want {
+		if progress[i] != want[i] {
+			t.Fatalf("progress = %v, want %v", progress, want)
+		}
+	}
+
+	var found []MigratedAccount
+	db.Order("id").Find(&found)
+	if len(found) != 3 || found[0].ID != 1 || found[1].ID != 3 || found[2].ID != 5 {
+		t.Errorf("active after migration = %+v", found)
+	}
+
+	// 重新执行不再修改
+	if migrated, err := MigrateTimestampToFlag(db, &MigratedAccount{}, "deleted_at", "deleted"); err != nil || migrated != 0 {
+		t.Errorf("second run = %d, %v, want 0", migrated, err)
+	}
+	if _, err := MigrateTimestampToFlag(db, &MigratedAccount{}, "deleted_at", "name"); err == nil {
+		t.Error("migrating into a non flag column should fail")
+	}
+}
@@ -0,0 +1,41 @@
+package soft_delete
+
+import "time"
+
+// Model 可以嵌入模型，提供标记、删除时间和删除者三个字段，删除时自动写入删除时间和 WithActor 设置的执行者。
+// 可以匿名嵌入，也可以带前缀嵌入：
+//
+//	type User struct {
+//		ID   uint
+//		Name string
+//		soft_delete.Model
+//	}
+//
+//	type Order struct {
+//		ID    uint
+//		Trash soft_delete.Model `gorm:"embedded;embeddedPrefix:trash_"`
+//	}
+//
+// 不要同时嵌入 gorm.Model，其中的 gorm.DeletedAt 也是软删除字段
+type Model struct {
+	Deleted       DeletedAt `gorm:"softDelete:DeletedAtField:DeletedAtTime,DeletedByField:DeletedBy"`
+	DeletedAtTime *time.Time
+	DeletedBy     string `gorm:"size:255"`
+}
+
+// DeletionInfo 为记录的删除信息
+type DeletionInfo struct {
+	Deleted bool
+	At      *time.Time
+	By      string
+}
+
+// IsDeleted 判断记录是否已删除
+func (m Model) IsDeleted() bool {
+	return m.Deleted.IsDeleted()
+}
+
+// DeletionInfo 返回记录的删除信息，未删除时 At 为 nil
+func (m Model) DeletionInfo() DeletionInfo {
+	return DeletionInfo{Deleted: m.Deleted.IsDeleted(), At: m.DeletedAtTime, By: m.DeletedBy}
+}
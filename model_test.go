@@ -0,0 +1,80 @@
+package soft_delete
+
+import (
+	"context"
+	"testing"
+)
+
+type ModelUser struct {
+	ID   uint
+	Name string
+	Model
+}
+
+type ModelOrder struct {
+	ID    uint
+	Trash Model `gorm:"embedded;embeddedPrefix:trash_"`
+}
+
+// 匿名嵌入 Model：删除写入删除时间和执行者，OnlyDeleted 查到后恢复清空
+func TestModel(t *testing.T) {
+	db := pinNow(openDB(t, &ModelUser{}))
+	db.Create(&[]ModelUser{{Name: "a"}, {Name: "b"}})
+
+	user := ModelUser{ID: 1}
+	if err := db.WithContext(WithActor(context.Background(), "alice")).Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !user.IsDeleted() {
+		t.Error("in memory not deleted")
+	}
+
+	var deleted []ModelUser
+	db.Scopes(OnlyDeleted).Find(&deleted)
+	if len(deleted) != 1 {
+		t.Fatalf("deleted = %+v", deleted)
+	}
+	info := deleted[0].DeletionInfo()
+	if !info.Deleted || info.At == nil || !info.At.Equal(testNow) || info.By != "alice" {
+		t.Errorf("DeletionInfo = %+v", info)
+	}
+
+	if n, err := Restore(db, &user); n != 1 || err != nil {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	var got ModelUser
+	db.First(&got, 1)
+	if info := got.DeletionInfo(); info.Deleted || info.At != nil || info.By != "" {
+		t.Errorf("after restore = %+v", info)
+	}
+}
+
+// 带前缀嵌入时列名带有前缀
+func TestModelPrefix(t *testing.T) {
+	db := pinNow(openDB(t, &ModelOrder{}))
+	for _, column := range []string{"trash_deleted", "trash_deleted_at_time", "trash_deleted_by"} {
+		if !db.Migrator().HasColumn(&ModelOrder{}, column) {
+			t.Errorf("column %s missing", column)
+		}
+	}
+	db.Create(&[]ModelOrder{{}, {}})
+
+	order := ModelOrder{ID: 2}
+	db.Delete(&order)
+	if !order.Trash.IsDeleted() || order.Trash.DeletedAtTime == nil {
+		t.Errorf("in memory = %+v", order.Trash)
+	}
+	var active []ModelOrder
+	db.Find(&active)
+	if len(active) != 1 || active[0].ID != 1 {
+		t.Errorf("active = %+v", active)
+	}
+	var deleted ModelOrder
+	if err := db.Scopes(OnlyDeleted).First(&deleted).Error; err != nil || deleted.ID != 2 || deleted.Trash.DeletedAtTime == nil {
+		t.Errorf("deleted = %+v, %v", deleted, err)
+	}
+	if n, err := Restore(db, &ModelOrder{ID: 2}); n != 1 || err != nil {
+		t.Errorf("Restore = %d, %v", n, err)
+	}
+	assertContains(t, sqlOf(t, dryRun(db).Find(&[]ModelOrder{})), "`model_orders`.`trash_deleted` = ?")
+}
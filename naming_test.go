@@ -0,0 +1,106 @@
+package soft_delete
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// upperNaming 将列名转换为大写
+type upperNaming struct {
+	schema.NamingStrategy
+}
+
+func (n upperNaming) ColumnName(table, column string) string {
+	return strings.ToUpper(n.NamingStrategy.ColumnName(table, column))
+}
+
+// ReservedUser 的标记列名为保留字 select
+type ReservedUser struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"column:select;softDelete:flag"`
+}
+
+type NamedEvent struct {
+	ID   uint
+	Name string
+}
+
+func TestNamingStrategy(t *testing.T) {
+	db := openDB(t)
+	db.NamingStrategy = upperNaming{}
+	if err := db.AutoMigrate(&User{}); err != nil {
+		t.Fatal(err)
+	}
+	users := seedUsers(t, db)
+
+	var found []User
+	db.Find(&found)
+	if len(found) != 2 {
+		t.Errorf("active = %+v", found)
+	}
+	if n, err := Restore(db, &User{ID: users[1].ID}); err != nil || n != 1 {
+		t.Errorf("Restore = %d, %v", n, err)
+	}
+
+	dry := dryRunDB(t, "postgres")
+	dry.NamingStrategy = upperNaming{}
+	assertContains(t, sqlOf(t, dry.Find(&[]User{})), "`users`.`DELETED` =")
+	sql := sqlOf(t, dry.Delete(&User{ID: 1}))
+	assertContains(t, sql, "SET `DELETED`=", "`users`.`ID` =")
+}
+
+func TestReservedColumnName(t *testing.T) {
+	db := openDB(t, &ReservedUser{})
+	users := []ReservedUser{{Name: "a"}, {Name: "b"}}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&users[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+	var found []ReservedUser
+	if err := db.Find(&found).Error; err != nil || len(found) != 1 || found[0].Name != "a" {
+		t.Errorf("Find = %+v, %v", found, err)
+	}
+	var n int64
+	if err := db.Model(&ReservedUser{}).Scopes(OnlyDeleted).Count(&n).Error; err != nil || n != 1 {
+		t.Errorf("deleted = %d, %v", n, err)
+	}
+	if n, err := Restore(db, &ReservedUser{ID: users[1].ID}); err != nil || n != 1 {
+		t.Errorf("Restore = %d, %v", n, err)
+	}
+
+	sql := sqlOf(t, dryRunDB(t, "postgres").Find(&[]ReservedUser{}))
+	assertContains(t, sql, "`reserved_users`.`select` =")
+}
+
+func TestArchiveNamingStrategy(t *testing.T) {
+	db := openDB(t)
+	db.NamingStrategy = upperNaming{}
+	db.AutoMigrate(&NamedEvent{})
+	archiver := NewArchiver(ArchiveStrategy{Model: &NamedEvent{}})
+	if err := db.Use(archiver); err != nil {
+		t.Fatal(err)
+	}
+	if err := archiver.AutoMigrate(db); err != nil {
+		t.Fatal(err)
+	}
+	event := NamedEvent{Name: "a"}
+	db.Create(&event)
+
+	if err := db.Delete(&event).Error; err != nil {
+		t.Fatal(err)
+	}
+	var deletedAt []string
+	db.Session(&gorm.Session{NewDB: true}).Table("named_events_archive").Pluck("DELETED_AT", &deletedAt)
+	if len(deletedAt) != 1 || deletedAt[0] == "" {
+		t.Errorf("DELETED_AT = %v", deletedAt)
+	}
+	if n, err := Restore(db, &NamedEvent{ID: event.ID}); err != nil || n != 1 {
+		t.Errorf("Restore = %d, %v", n, err)
+	}
+}
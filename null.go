@@ -0,0 +1,89 @@
+package soft_delete
+
+import (
+	"database/sql/driver"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// NullDeletedAt 与 sql.NullBool 类似，是可以为 NULL 的 DeletedAt，用于直接以 database/sql、sqlx 读写可空的标记列。
+// Valid 为 false 时写入 NULL，扫描到 NULL 时 Valid 为 false 而不是返回错误。
+// 作为模型字段时 NULL 视为未删除，声明 NullDeleted 时视为已删除，与 *DeletedAt 一致
+//
+//	Deleted soft_delete.NullDeletedAt `gorm:"softDelete:flag"`
+type NullDeletedAt struct {
+	Flag  DeletedAt
+	Valid bool
+}
+
+// nullDeletedAtValues 为 NullDeletedAt 默认的取值，列可以为 NULL
+func nullDeletedAtValues() flagValues {
+	values := deletedAtValues()
+	values.nullable = true
+	return values
+}
+
+func nullDeletedAtFromDeleted(deleted bool) interface{} {
+	return NullDeletedAt{Flag: DeletedAt(deleted), Valid: true}
+}
+
+// IsDeleted 判断记录是否已删除，NULL 视为未删除
+func (n NullDeletedAt) IsDeleted() bool {
+	return n.Valid && n.Flag.IsDeleted()
+}
+
+// IsActive 判断记录是否未删除
+func (n NullDeletedAt) IsActive() bool {
+	return !n.IsDeleted()
+}
+
+// String 返回 "deleted" 或 "active"
+func (n NullDeletedAt) String() string {
+	return stateString(n.IsDeleted())
+}
+
+// 实现 driver.Valuer 接口，Valid 为 false 时为 NULL
+func (n NullDeletedAt) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Flag.Value()
+}
+
+// 实现 sql.Scanner 接口，NULL 时 Valid 为 false
+func (n *NullDeletedAt) Scan(value interface{}) error {
+	if value == nil {
+		*n = NullDeletedAt{}
+		return nil
+	}
+	if err := n.Flag.Scan(value); err != nil {
+		n.Valid = false
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+func (NullDeletedAt) QueryClauses(f *schema.Field) []clause.Interface {
+	return flagQueryClauses(f, nullDeletedAtValues(), nullDeletedAtFromDeleted)
+}
+
+func (NullDeletedAt) UpdateClauses(f *schema.Field) []clause.Interface {
+	return flagUpdateClauses(f)
+}
+
+func (NullDeletedAt) DeleteClauses(f *schema.Field) []clause.Interface {
+	return flagDeleteClauses(f, nullDeletedAtValues(), nullDeletedAtFromDeleted)
+}
+
+// GormDataType 实现 schema.GormDataTypeInterface 接口
+func (NullDeletedAt) GormDataType() string {
+	return string(schema.Bool)
+}
+
+// GormDBDataType 实现 migrator.GormDataTypeInterface 接口
+func (NullDeletedAt) GormDBDataType(db *gorm.DB, f *schema.Field) string {
+	return flagDBDataType(db, f)
+}
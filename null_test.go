@@ -0,0 +1,96 @@
+package soft_delete
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+type NullFlagUser struct {
+	ID      uint
+	Name    string
+	Deleted NullDeletedAt `gorm:"softDelete:flag"`
+}
+
+// 不经过 gorm，直接以 database/sql 读写
+func TestNullDeletedAtDatabaseSQL(t *testing.T) {
+	sqlDB, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "null.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+	if _, err := sqlDB.Exec("CREATE TABLE flags (id integer PRIMARY KEY, deleted numeric)"); err != nil {
+		t.Fatal(err)
+	}
+
+	values := []NullDeletedAt{{}, {Valid: true}, {Flag: true, Valid: true}}
+	for i, v := range values {
+		if _, err := sqlDB.Exec("INSERT INTO flags (id, deleted) VALUES (?, ?)", i+1, v); err != nil {
+			t.Fatalf("insert %+v: %v", v, err)
+		}
+	}
+	var nulls int
+	sqlDB.QueryRow("SELECT COUNT(*) FROM flags WHERE deleted IS NULL").Scan(&nulls)
+	if nulls != 1 {
+		t.Errorf("NULL rows = %d, want 1", nulls)
+	}
+
+	rows, err := sqlDB.Query("SELECT deleted FROM flags ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var got []NullDeletedAt
+	for rows.Next() {
+		var v NullDeletedAt
+		if err := rows.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+	if len(got) != len(values) {
+		t.Fatalf("scanned %+v", got)
+	}
+	for i := range values {
+		if got[i] != values[i] {
+			t.Errorf("row %d = %+v, want %+v", i+1, got[i], values[i])
+		}
+	}
+	if got[0].IsDeleted() || got[1].IsDeleted() || !got[2].IsDeleted() {
+		t.Errorf("IsDeleted = %v %v %v", got[0].IsDeleted(), got[1].IsDeleted(), got[2].IsDeleted())
+	}
+}
+
+func TestNullDeletedAtGorm(t *testing.T) {
+	db := openDB(t, &NullFlagUser{})
+	users := []NullFlagUser{{Name: "a"}, {Name: "b", Deleted: NullDeletedAt{Valid: true}}}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Exec("INSERT INTO null_flag_users (name, deleted) VALUES ('null', NULL)")
+
+	var active []NullFlagUser
+	db.Order("id").Find(&active)
+	if len(active) != 3 {
+		t.Fatalf("active = %+v", active)
+	}
+	if active[2].Deleted.Valid {
+		t.Errorf("NULL scanned as %+v", active[2].Deleted)
+	}
+
+	if err := db.Delete(&active[2]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !active[2].Deleted.Valid || !active[2].Deleted.IsDeleted() {
+		t.Errorf("Deleted = %+v in memory after delete", active[2].Deleted)
+	}
+	var deleted NullFlagUser
+	if err := db.Scopes(OnlyDeleted).First(&deleted).Error; err != nil || deleted.Name != "null" || !deleted.Deleted.IsDeleted() {
+		t.Errorf("deleted = %+v, %v", deleted, err)
+	}
+	var n int64
+	db.Model(&NullFlagUser{}).Count(&n)
+	if n != 2 {
+		t.Errorf("active = %d, want 2", n)
+	}
+}
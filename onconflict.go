@@ -0,0 +1,86 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const onConflictRestoreClauseName = "soft_delete:on_conflict_restore"
+
+type onConflictRestore struct {
+	columns []clause.Column
+}
+
+func (onConflictRestore) Name() string {
+	return onConflictRestoreClauseName
+}
+
+func (onConflictRestore) Build(clause.Builder) {
+}
+
+func (r onConflictRestore) MergeClause(c *clause.Clause) {
+	c.Expression = r
+}
+
+// OnConflictRestore 使插入冲突时恢复已删除的记录：冲突更新中加上将标记设为未删除、清空删除时间等关联字段的赋值。
+// 与 clause.OnConflict 一起使用时扩展其 DoUpdates，单独使用时只恢复记录，columns 为冲突的列
+//
+//	db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "email"}}, DoUpdates: clause.AssignmentColumns([]string{"name"})},
+//		soft_delete.OnConflictRestore()).Create(&user)
+//
+// UpdateAll 已经更新插入的所有列，包括标记列，不再额外添加。Unscoped 插入不做处理
+func OnConflictRestore(columns ...string) clause.Expression {
+	r := onConflictRestore{}
+	for _, column := range columns {
+		r.columns = append(r.columns, clause.Column{Name: column})
+	}
+	return r
+}
+
+// applyOnConflictRestore 在插入语句生成 VALUES 之前处理 OnConflictRestore，此时已经解析了模型
+func (sd SoftDeleteCreateClause) applyOnConflictRestore(stmt *gorm.Statement) {
+	c, ok := stmt.Clauses[onConflictRestoreClauseName]
+	if !ok {
+		return
+	}
+	r, ok := c.Expression.(onConflictRestore)
+	if !ok {
+		return
+	}
+	deleteClause, ok := deleteClauseOf(sd.Field)
+	if !ok {
+		return
+	}
+
+	onConflict, _ := stmt.Clauses["ON CONFLICT"].Expression.(clause.OnConflict)
+	if len(onConflict.Columns) == 0 {
+		onConflict.Columns = r.columns
+	}
+	if !onConflict.UpdateAll {
+		onConflict.DoNothing = false
+		for _, assignment := range deleteClause.restoreSet() {
+			if !hasAssignment(onConflict.DoUpdates, assignment.Column.Name) {
+				onConflict.DoUpdates = append(onConflict.DoUpdates, assignment)
+			}
+		}
+	}
+	stmt.AddClause(onConflict)
+}
+
+func hasAssignment(set clause.Set, name string) bool {
+	for _, assignment := range set {
+		if assignment.Column.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreSet 返回恢复记录的赋值：标记设为未删除，关联字段设为零值
+func (sd SoftDeleteDeleteClause) restoreSet() clause.Set {
+	set := clause.Set{{Column: clause.Column{Name: sd.Field.DBName}, Value: sqlValue(activeValue(sd.Field, sd.Flag))}}
+	for _, field := range sd.companionFields() {
+		set = append(set, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: zeroValue(field)})
+	}
+	return set
+}
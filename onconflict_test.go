@@ -0,0 +1,54 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+type UpsertUser struct {
+	ID        uint
+	Email     string `gorm:"uniqueIndex"`
+	Name      string
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+func TestOnConflictRestore(t *testing.T) {
+	db := openDB(t, &UpsertUser{})
+	db.Create(&[]UpsertUser{{Email: "active@x", Name: "a"}, {Email: "deleted@x", Name: "d"}})
+	db.Delete(&UpsertUser{ID: 2})
+
+	upsert := func(email, name string) {
+		t.Helper()
+		err := db.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "email"}},
+			DoUpdates: clause.AssignmentColumns([]string{"name"}),
+		}, OnConflictRestore()).Create(&UpsertUser{Email: email, Name: name}).Error
+		if err != nil {
+			t.Fatalf("upsert %s: %v", email, err)
+		}
+	}
+	upsert("active@x", "a2")
+	upsert("deleted@x", "d2")
+	upsert("new@x", "n")
+
+	var users []UpsertUser
+	db.Order("email").Find(&users)
+	if len(users) != 3 {
+		t.Fatalf("visible = %+v", users)
+	}
+	want := map[string]string{"active@x": "a2", "deleted@x": "d2", "new@x": "n"}
+	for _, u := range users {
+		if u.Name != want[u.Email] || u.DeletedAt != nil {
+			t.Errorf("%s = %+v", u.Email, u)
+		}
+	}
+	if n := countRows(t, db, "upsert_users"); n != 3 {
+		t.Errorf("rows = %d, want 3", n)
+	}
+
+	sql := sqlOf(t, dryRun(db).Clauses(OnConflictRestore("email")).Create(&UpsertUser{Email: "x"}))
+	assertContains(t, sql, "ON CONFLICT (`email`) DO UPDATE SET `deleted`=", "`deleted_at`=")
+}
@@ -0,0 +1,33 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const permanentDeleteClauseName = "soft_delete:permanent"
+
+type permanentDeleteClause struct{}
+
+func (permanentDeleteClause) Name() string {
+	return permanentDeleteClauseName
+}
+
+func (permanentDeleteClause) Build(clause.Builder) {
+}
+
+func (c permanentDeleteClause) MergeClause(cl *clause.Clause) {
+	cl.Expression = c
+}
+
+// PermanentDelete 物理删除记录，返回删除的行数。与 Unscoped().Delete() 不同，
+// 会话上其他插件添加的作用域和条件仍然生效；没有主键也没有 WHERE 条件时返回 gorm.ErrMissingWhereClause
+func PermanentDelete(db *gorm.DB, value interface{}, conds ...interface{}) (int64, error) {
+	tx := db.Clauses(permanentDeleteClause{}).Delete(value, conds...)
+	return tx.RowsAffected, tx.Error
+}
+
+func isPermanentDelete(stmt *gorm.Statement) bool {
+	_, ok := stmt.Clauses[permanentDeleteClauseName]
+	return ok
+}
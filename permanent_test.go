@@ -0,0 +1,68 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TenantUser struct {
+	ID      uint
+	Tenant  int
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+// tenantScope 模拟其他插件添加的租户条件
+type tenantScope struct {
+	tenant int
+}
+
+func (tenantScope) Name() string {
+	return "test:tenant"
+}
+
+func (tenantScope) Build(clause.Builder) {
+}
+
+func (tenantScope) MergeClause(*clause.Clause) {
+}
+
+func (s tenantScope) ModifyStatement(stmt *gorm.Statement) {
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant"}, Value: s.tenant},
+	}})
+}
+
+// 其他插件的租户条件在物理删除时仍然生效
+func TestPermanentDelete(t *testing.T) {
+	db := openDB(t, &TenantUser{})
+	users := []TenantUser{{Tenant: 1}, {Tenant: 2}}
+	db.Create(&users)
+	scoped := db.Clauses(tenantScope{tenant: 1}).Session(&gorm.Session{})
+
+	sql := sqlOf(t, dryRun(scoped).Clauses(permanentDeleteClause{}).Delete(&TenantUser{ID: users[1].ID}))
+	assertContains(t, sql, "DELETE FROM `tenant_users`", "`tenant_users`.`tenant` = ?")
+
+	if n, err := PermanentDelete(scoped, &TenantUser{ID: users[1].ID}); err != nil || n != 0 {
+		t.Errorf("PermanentDelete other tenant = %d, %v", n, err)
+	}
+	if n, err := PermanentDelete(scoped, &TenantUser{ID: users[0].ID}); err != nil || n != 1 {
+		t.Errorf("PermanentDelete = %d, %v", n, err)
+	}
+	if n := countRows(t, db, "tenant_users"); n != 1 {
+		t.Errorf("rows left = %d, want 1", n)
+	}
+}
+
+func TestPermanentDeleteWithoutConditions(t *testing.T) {
+	db := openDB(t, &TenantUser{})
+	db.Create(&TenantUser{Tenant: 1})
+	if _, err := PermanentDelete(db, &TenantUser{}); !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Errorf("PermanentDelete error = %v", err)
+	}
+	if n := countRows(t, db, "tenant_users"); n != 1 {
+		t.Errorf("rows left = %d, want 1", n)
+	}
+}
@@ -0,0 +1,78 @@
+package soft_delete
+
+import "testing"
+
+type PtrUser struct {
+	ID      uint
+	Name    string
+	Deleted *DeletedAt `gorm:"softDelete:flag"`
+}
+
+type NullDeletedPtrUser struct {
+	ID      uint
+	Name    string
+	Deleted *DeletedAt `gorm:"softDelete:flag,NullDeleted"`
+}
+
+func flagPtr(deleted bool) *DeletedAt {
+	d := DeletedAt(deleted)
+	return &d
+}
+
+func TestPointerFlagInitialStates(t *testing.T) {
+	db := openDB(t, &PtrUser{})
+	users := []PtrUser{{Name: "nil"}, {Name: "false", Deleted: flagPtr(false)}, {Name: "true", Deleted: flagPtr(true)}}
+	for i := range users {
+		if err := db.Create(&users[i]).Error; err != nil {
+			t.Fatalf("create %s: %v", users[i].Name, err)
+		}
+	}
+	// 列中直接写入的 NULL 视为未删除
+	db.Exec("INSERT INTO ptr_users (name, deleted) VALUES ('null', NULL)")
+
+	var active []PtrUser
+	db.Order("id").Find(&active)
+	if len(active) != 3 || active[0].Name != "nil" || active[1].Name != "false" || active[2].Name != "null" {
+		t.Fatalf("active = %+v", active)
+	}
+	var deleted []PtrUser
+	db.Scopes(OnlyDeleted).Find(&deleted)
+	if len(deleted) != 1 || deleted[0].Name != "true" {
+		t.Errorf("deleted = %+v", deleted)
+	}
+
+	// 删除后内存中的指针指向已删除
+	for i := range active {
+		if err := db.Delete(&active[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+		if active[i].Deleted == nil || !active[i].Deleted.IsDeleted() {
+			t.Errorf("%s: Deleted = %v in memory after delete", active[i].Name, active[i].Deleted)
+		}
+	}
+	var n int64
+	db.Model(&PtrUser{}).Count(&n)
+	if n != 0 {
+		t.Errorf("active after delete = %d", n)
+	}
+
+	sql := sqlOf(t, dryRunDB(t, "sqlite").Find(&[]PtrUser{}))
+	assertContains(t, sql, "`ptr_users`.`deleted` = ? OR `ptr_users`.`deleted` IS NULL")
+}
+
+func TestPointerFlagNullDeleted(t *testing.T) {
+	db := openDB(t, &NullDeletedPtrUser{})
+	db.Create(&NullDeletedPtrUser{Name: "active", Deleted: flagPtr(false)})
+	db.Exec("INSERT INTO null_deleted_ptr_users (name, deleted) VALUES ('null', NULL)")
+
+	var active []NullDeletedPtrUser
+	db.Find(&active)
+	if len(active) != 1 || active[0].Name != "active" {
+		t.Errorf("active = %+v", active)
+	}
+	var deleted []NullDeletedPtrUser
+	db.Scopes(OnlyDeleted).Find(&deleted)
+	if len(deleted) != 1 || deleted[0].Name != "null" {
+		t.Errorf("deleted = %+v", deleted)
+	}
+}
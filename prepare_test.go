@@ -0,0 +1,66 @@
+package soft_delete
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// 预编译会话中反复删除，改写后的语句与参数个数保持一致
+func TestPreparedDeletes(t *testing.T) {
+	db := openDB(t, &ActorUser{}, &ReasonUser{})
+	config := DefaultConfig()
+	config.KeyBatchSize = 3
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithActor(context.Background(), "admin")
+	prepared := db.Session(&gorm.Session{PrepareStmt: true, Context: ctx})
+
+	users := make([]ActorUser, 100)
+	if err := prepared.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 80; i++ {
+		if err := prepared.Delete(&users[i]).Error; err != nil {
+			t.Fatalf("delete %d: %v", i, err)
+		}
+	}
+	// 超过 KeyBatchSize 的批量删除分批执行
+	for start := 80; start < 100; start += 10 {
+		tx := prepared.Delete(users[start : start+10])
+		if tx.Error != nil || tx.RowsAffected != 10 {
+			t.Fatalf("batch delete = %d, %v", tx.RowsAffected, tx.Error)
+		}
+	}
+
+	var active int64
+	db.Model(&ActorUser{}).Count(&active)
+	if active != 0 {
+		t.Errorf("active = %d, want 0", active)
+	}
+	var by []string
+	db.Model(&ActorUser{}).Scopes(OnlyDeleted).Distinct().Pluck("deleted_by", &by)
+	if len(by) != 1 || by[0] != "admin" {
+		t.Errorf("deleted_by = %v", by)
+	}
+
+	// 有无原因的删除交替执行，复用的语句不能串用
+	reasons := make([]ReasonUser, 100)
+	prepared.Create(&reasons)
+	for i := range reasons {
+		tx := prepared
+		if i%2 == 0 {
+			tx = tx.Clauses(Reason("cleanup"))
+		}
+		if err := tx.Delete(&reasons[i]).Error; err != nil {
+			t.Fatalf("delete %d: %v", i, err)
+		}
+	}
+	var withReason int64
+	db.Model(&ReasonUser{}).Scopes(OnlyDeleted).Where("delete_reason = ?", "cleanup").Count(&withReason)
+	if withReason != 50 {
+		t.Errorf("deleted with reason = %d, want 50", withReason)
+	}
+}
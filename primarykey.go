@@ -0,0 +1,196 @@
+package soft_delete
+
+import (
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// compositeKeyLimit 为不支持行值 IN 时一条语句中 OR 连接的主键组数
+const compositeKeyLimit = 100
+
+const defaultKeyBatchSize = 1000
+
+var keyBatchSize = defaultKeyBatchSize
+
+// SetKeyBatchSize 设置按主键删除、恢复、清理时一条语句中的主键数，默认 1000。
+// 超过时分为多条语句在同一事务中执行，RowsAffected 为各条语句之和，避免超过数据库的参数个数限制。
+// 删除只在通过 Use 设置了 Config 的 db 上分批
+//
+// Deprecated: 运行时修改会产生数据竞争，使用 Use 为 db 设置 Config.KeyBatchSize
+func SetKeyBatchSize(size int) {
+	if size > 0 {
+		keyBatchSize = size
+	}
+}
+
+// keyCondition 为删除的记录的主键条件，主键较多时分为多批，每批对应一条语句，Build 生成当前批次的条件
+type keyCondition struct {
+	conds   []clause.Expression
+	current int
+}
+
+func (k *keyCondition) Build(builder clause.Builder) {
+	k.conds[k.current].Build(builder)
+}
+
+// primaryKeyCondition 返回删除的记录的主键条件，没有主键时返回 nil。Dest 与 Model 不同时合并二者的主键，只生成一个条件；
+// 二者指向不同的记录时以 Dest 为准并记录警告，不再生成两个互相矛盾的 IN
+func primaryKeyCondition(stmt *gorm.Statement) (*keyCondition, error) {
+	if stmt.Schema == nil {
+		return nil, nil
+	}
+
+	keys, err := primaryKeys(stmt, stmt.ReflectValue, "Dest")
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.ReflectValue.CanAddr() && stmt.Dest != stmt.Model && stmt.Model != nil {
+		modelKeys, err := primaryKeys(stmt, reflect.ValueOf(stmt.Model), "Model")
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case len(keys) == 0:
+			keys = modelKeys
+		case len(modelKeys) > 0 && !sameKeys(keys, modelKeys):
+			stmt.DB.Logger.Warn(stmt.Context, "soft_delete: primary keys of Dest %v and Model %v differ, deleting by Dest", keys, modelKeys)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	size := len(keys)
+	if canChunk(stmt) {
+		size = batchSize(stmt)
+	}
+	k := &keyCondition{}
+	for start := 0; start < len(keys); start += size {
+		end := start + size
+		if end > len(keys) {
+			end = len(keys)
+		}
+		k.conds = append(k.conds, keysExpr(stmt, keys[start:end]))
+	}
+	return k, nil
+}
+
+// batchSize 返回一条语句中的主键数，不支持行值的数据库上联合主键的条件较长，不超过 compositeKeyLimit
+func batchSize(stmt *gorm.Statement) int {
+	size := configOf(stmt.DB).KeyBatchSize
+	if len(stmt.Schema.PrimaryFields) > 1 && !supportsRowValues(stmt) && size > compositeKeyLimit {
+		return compositeKeyLimit
+	}
+	return size
+}
+
+func primaryKeys(stmt *gorm.Statement, rv reflect.Value, name string) ([][]interface{}, error) {
+	rv, ok, err := identityValue(stmt.Schema, rv, name)
+	if !ok {
+		return nil, err
+	}
+	_, keys := schema.GetIdentityFieldValuesMap(stmt.Context, rv, stmt.Schema.PrimaryFields)
+	return keys, nil
+}
+
+// keysExpr 返回一批主键的条件：单列主键和支持行值的数据库使用 IN，其他数据库的联合主键使用 OR 连接的等值条件组
+func keysExpr(stmt *gorm.Statement, keys [][]interface{}) clause.Expression {
+	names := stmt.Schema.PrimaryFieldDBNames
+	if len(names) == 1 || supportsRowValues(stmt) {
+		column, values := schema.ToQueryValues(clause.CurrentTable, names, keys)
+		return clause.IN{Column: column, Values: values}
+	}
+
+	groups := make([]clause.Expression, len(keys))
+	for i, key := range keys {
+		eqs := make([]clause.Expression, len(names))
+		for j, name := range names {
+			eqs[j] = clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: name}, Value: key[j]}
+		}
+		groups[i] = clause.And(eqs...)
+	}
+	if len(groups) == 1 {
+		return groups[0]
+	}
+	return clause.Or(groups...)
+}
+
+// supportsRowValues 判断数据库是否支持 (a, b) IN ((?, ?)) 形式的行值条件，MySQL 5.7 等不支持
+func supportsRowValues(stmt *gorm.Statement) bool {
+	switch stmt.DB.Dialector.Name() {
+	case "postgres":
+		return true
+	}
+	return false
+}
+
+// identityValue 检查读取主键的值，gorm 反射读取主键时 nil 元素和其他类型的结构体会 panic。
+// nil 指针和 map 没有主键，返回 false；其他形状返回错误
+func identityValue(s *schema.Schema, rv reflect.Value, name string) (reflect.Value, bool, error) {
+	rv, ok := indirectValue(rv)
+	if !ok {
+		return rv, false, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Map:
+		return rv, false, nil
+	case reflect.Struct:
+		if rv.Type() != s.ModelType {
+			return rv, false, fmt.Errorf("soft_delete: %s of type %s does not match model %s", name, rv.Type(), s.Name)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			elem, ok := indirectValue(rv.Index(i))
+			switch {
+			case !ok:
+				return rv, false, fmt.Errorf("soft_delete: %s has a nil element at index %d", name, i)
+			case elem.Kind() == reflect.Map:
+				return rv, false, nil
+			case elem.Type() != s.ModelType:
+				return rv, false, fmt.Errorf("soft_delete: element %d of %s has type %s, expected model %s", i, name, elem.Type(), s.Name)
+			}
+		}
+	default:
+		return rv, false, fmt.Errorf("soft_delete: %s of kind %s is not a struct, slice or map", name, rv.Kind())
+	}
+	return rv, true, nil
+}
+
+// indirectValue 去掉指针和接口，遇到 nil 时返回 false
+func indirectValue(rv reflect.Value) (reflect.Value, bool) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return rv, false
+		}
+		rv = rv.Elem()
+	}
+	return rv, rv.IsValid()
+}
+
+// sameKeys 判断两组主键是否为同一组记录，不考虑顺序和重复
+func sameKeys(a, b [][]interface{}) bool {
+	keys := make(map[string]bool, len(a))
+	for _, v := range a {
+		keys[fmt.Sprint(v)] = false
+	}
+	for _, v := range b {
+		key := fmt.Sprint(v)
+		if _, ok := keys[key]; !ok {
+			return false
+		}
+		keys[key] = true
+	}
+	for _, seen := range keys {
+		if !seen {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,163 @@
+package soft_delete
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// Dest 与 Model 指向相同的记录时只有一个主键条件
+func TestPrimaryKeyDedupe(t *testing.T) {
+	db := openDB(t, &User{})
+	dry := dryRun(db)
+
+	user := User{ID: 1}
+	sql := sqlOf(t, dry.Model(&user).Delete(&user))
+	if n := strings.Count(sql, "`users`.`id`"); n != 1 {
+		t.Errorf("%s has %d key conditions", sql, n)
+	}
+
+	users := []User{{ID: 1}, {ID: 2}}
+	sql = sqlOf(t, dry.Model(&users).Delete(&users))
+	if n := strings.Count(sql, " IN "); n != 1 {
+		t.Errorf("%s has %d IN predicates", sql, n)
+	}
+
+	// 相同的主键但不是同一个值
+	sql = sqlOf(t, dry.Model(&User{ID: 1}).Delete(&User{ID: 1}))
+	if n := strings.Count(sql, "`users`.`id`"); n != 1 {
+		t.Errorf("%s has %d key conditions", sql, n)
+	}
+}
+
+// Dest、Model 的形状不正确时返回错误而不是 panic
+func TestPrimaryKeyBadShapes(t *testing.T) {
+	db := openDB(t, &User{}, &Plain{})
+	db.Create(&User{Name: "a"})
+
+	// 其他插件在 Dest 赋值后将 Model 设为 typed nil
+	if err := db.Callback().Delete().Before("gorm:delete").Register("test:nil_model", func(tx *gorm.DB) {
+		if _, ok := tx.Statement.Settings.Load("test:nil_model"); ok {
+			tx.Statement.Model = (*User)(nil)
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Set("test:nil_model", true).Delete(&User{ID: 1}).Error; err != nil {
+		t.Errorf("typed nil Model: %v", err)
+	}
+
+	tests := []struct {
+		dest interface{}
+		want string
+	}{
+		{&[]*User{{ID: 1}, nil}, "soft_delete: Dest has a nil element at index 1"},
+		{&[]interface{}{&User{ID: 1}, &Plain{ID: 2}}, "soft_delete: element 1 of Dest has type soft_delete.Plain, expected model User"},
+	}
+	for _, tt := range tests {
+		err := db.Model(&User{}).Delete(tt.dest).Error
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("Delete(%T) = %v, want %q", tt.dest, err, tt.want)
+		}
+	}
+
+	err := db.Model(&User{}).Delete(&Plain{ID: 1}).Error
+	if err == nil || !strings.Contains(err.Error(), "does not match model User") {
+		t.Errorf("Delete(Plain) = %v", err)
+	}
+}
+
+type OrderLine struct {
+	OrderID   uint      `gorm:"primaryKey;autoIncrement:false"`
+	ProductID uint      `gorm:"primaryKey;autoIncrement:false"`
+	Deleted   DeletedAt `gorm:"softDelete:flag"`
+}
+
+// 联合主键只删除指定的记录
+func TestCompositePrimaryKey(t *testing.T) {
+	db := openDB(t, &OrderLine{})
+	var lines []OrderLine
+	for order := uint(1); order <= 3; order++ {
+		for product := uint(1); product <= 3; product++ {
+			lines = append(lines, OrderLine{OrderID: order, ProductID: product})
+		}
+	}
+	db.Create(&lines)
+
+	targets := []OrderLine{{OrderID: 1, ProductID: 2}, {OrderID: 2, ProductID: 1}, {OrderID: 3, ProductID: 3}}
+	tx := db.Delete(&targets)
+	if tx.Error != nil || tx.RowsAffected != 3 {
+		t.Fatalf("Delete = %d, %v", tx.RowsAffected, tx.Error)
+	}
+
+	var deleted []OrderLine
+	db.Scopes(OnlyDeleted).Order("order_id, product_id").Find(&deleted)
+	if len(deleted) != 3 {
+		t.Fatalf("deleted = %+v", deleted)
+	}
+	for i, line := range deleted {
+		if line.OrderID != targets[i].OrderID || line.ProductID != targets[i].ProductID {
+			t.Errorf("deleted %d = %+v, want %+v", i, line, targets[i])
+		}
+	}
+	var n int64
+	db.Model(&OrderLine{}).Count(&n)
+	if n != 6 {
+		t.Errorf("active = %d, want 6", n)
+	}
+}
+
+// 联合主键在支持行值的数据库上以 (a, b) IN ((?, ?)) 删除，其他数据库以 OR 连接每组主键
+func TestCompositePrimaryKeySQL(t *testing.T) {
+	targets := []OrderLine{{OrderID: 1, ProductID: 2}, {OrderID: 2, ProductID: 1}}
+
+	sql := sqlOf(t, dryRunDB(t, "postgres").Delete(&targets))
+	assertContains(t, sql, "WHERE (`order_lines`.`order_id`,`order_lines`.`product_id`) IN ((?,?),(?,?)) AND")
+
+	sql = sqlOf(t, dryRunDB(t, "sqlite").Delete(&targets))
+	assertContains(t, sql, "WHERE ((`order_lines`.`order_id` = ? AND `order_lines`.`product_id` = ?) OR "+
+		"(`order_lines`.`order_id` = ? AND `order_lines`.`product_id` = ?)) AND")
+}
+
+// warnRecorder 记录 Warn 的日志
+type warnRecorder struct {
+	logger.Interface
+	warnings []string
+}
+
+func (r *warnRecorder) Warn(ctx context.Context, msg string, args ...interface{}) {
+	r.warnings = append(r.warnings, fmt.Sprintf(msg, args...))
+}
+
+// Dest 与 Model 指向不同的记录时按 Dest 删除并记录警告，指向相同的记录时不记录
+func TestPrimaryKeyMismatchWarning(t *testing.T) {
+	recorder := &warnRecorder{Interface: logger.Discard}
+	db := openDB(t, &User{})
+	db = db.Session(&gorm.Session{Logger: recorder})
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}})
+
+	tx := db.Model(&User{ID: 1}).Delete(&User{ID: 2})
+	if tx.Error != nil || tx.RowsAffected != 1 {
+		t.Fatalf("Delete = %d, %v", tx.RowsAffected, tx.Error)
+	}
+	var found User
+	db.First(&found)
+	if found.ID != 1 {
+		t.Errorf("remaining = %+v, want the Model record kept", found)
+	}
+	if len(recorder.warnings) != 1 || !strings.Contains(recorder.warnings[0], "differ, deleting by Dest") {
+		t.Errorf("warnings = %q", recorder.warnings)
+	}
+
+	// 顺序不同、有重复的同一组主键不算不同
+	recorder.warnings = nil
+	users := []User{{ID: 1}, {ID: 1}}
+	dryRun(db).Model(&[]User{{ID: 1}}).Delete(&users)
+	if len(recorder.warnings) != 0 {
+		t.Errorf("warnings = %q for the same keys", recorder.warnings)
+	}
+}
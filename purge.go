@@ -0,0 +1,123 @@
+package soft_delete
+
+import (
+	"errors"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrMissingDeletedAtField 按保留时间清理、按删除时间查询需要知道删除时间，标记位为 bool 时必须配置 DeletedAtField
+var ErrMissingDeletedAtField = errors.New("soft_delete: deletion time requires a DeletedAtField or a unix flag field")
+
+const defaultPurgeBatchSize = 1000
+
+type purgeConfig struct {
+	retention time.Duration
+	batchSize int
+}
+
+// PurgeOption 配置 Purge 的行为
+type PurgeOption func(*purgeConfig)
+
+// Older 只清理删除时间早于 retention 之前的记录
+func Older(retention time.Duration) PurgeOption {
+	return func(c *purgeConfig) {
+		c.retention = retention
+	}
+}
+
+// BatchSize 设置每批物理删除的行数，默认 1000
+func BatchSize(size int) PurgeOption {
+	return func(c *purgeConfig) {
+		if size > 0 {
+			c.batchSize = size
+		}
+	}
+}
+
+// Purge 分批物理删除已软删除且删除时间超过保留期的记录，返回删除的总行数。
+// 未删除的记录永远不会被清理，每批之间会检查 context 是否已取消
+//
+//	soft_delete.Purge(db, &User{}, soft_delete.Older(90*24*time.Hour))
+func Purge(db *gorm.DB, model interface{}, opts ...PurgeOption) (total int64, err error) {
+	config := purgeConfig{batchSize: defaultPurgeBatchSize}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	s, sd, err := parseDeleteClause(db, model)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, expired, err := sd.purgeConditions(db.NowFunc().Add(-config.retention))
+	if err != nil {
+		return 0, err
+	}
+
+	for {
+		if err = db.Statement.Context.Err(); err != nil {
+			return
+		}
+
+		batch := reflect.New(reflect.SliceOf(s.ModelType))
+		if err = db.Session(&gorm.Session{}).Model(model).Scopes(OnlyDeleted).Select(s.PrimaryFieldDBNames).
+			Clauses(clause.Where{Exprs: []clause.Expression{expired}}).Limit(config.batchSize).Find(batch.Interface()).Error; err != nil {
+			return
+		}
+
+		size := batch.Elem().Len()
+		if size == 0 {
+			return
+		}
+
+		result := db.Session(&gorm.Session{}).Unscoped().Clauses(purgeClause{}, clause.Where{Exprs: []clause.Expression{deleted, expired}}).Delete(batch.Interface())
+		if err = result.Error; err != nil {
+			return
+		}
+		total += result.RowsAffected
+
+		if size < config.batchSize {
+			return
+		}
+	}
+}
+
+// purgeConditions 返回可清理记录的条件：已删除，且删除时间早于 cutoff
+func (sd SoftDeleteDeleteClause) purgeConditions(cutoff time.Time) (deleted, expired clause.Expression, err error) {
+	deleted = deletedExpr(sd.Field, sd.Flag, clause.Column{Table: clause.CurrentTable, Name: sd.Field.DBName})
+
+	field := sd.deletedAtField()
+	if field == nil {
+		return nil, nil, ErrMissingDeletedAtField
+	}
+	expired = clause.Lt{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: sd.deletedAtOf(cutoff)}
+	return
+}
+
+// deletedAtField 返回记录删除时间的字段：时间戳类型、ValidTo 和 ExpiresAt 为字段本身，标记位和 DeletedToken 为非 bool 的 DeleteAtField
+func (sd SoftDeleteDeleteClause) deletedAtField() *schema.Field {
+	switch {
+	case !sd.Flag && !sd.Token, sd.Interval, sd.Expires:
+		return sd.Field
+	case sd.DeleteAtField != nil && sd.DeleteAtField.GORMDataType != schema.Bool:
+		return sd.DeleteAtField
+	default:
+		return nil
+	}
+}
+
+// deletedAtOf 将时间转换为 deletedAtField 中存储的值
+func (sd SoftDeleteDeleteClause) deletedAtOf(t time.Time) interface{} {
+	if !sd.Flag && !sd.Token {
+		return sd.timeToUnix(t)
+	}
+	if sd.Interval || sd.Expires {
+		return t
+	}
+	return sd.deleteAtValue(t)
+}
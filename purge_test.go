@@ -0,0 +1,79 @@
+package soft_delete
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PurgeUser struct {
+	ID        uint
+	Name      string
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+// seedPurge 创建 old 条 100 天前删除、recent 条昨天删除和 active 条未删除的记录
+func seedPurge(t *testing.T, db *gorm.DB, old, recent, active int) {
+	t.Helper()
+	create := func(n int, deletedAt time.Time) {
+		for i := 0; i < n; i++ {
+			user := PurgeUser{}
+			db.Create(&user)
+			if !deletedAt.IsZero() {
+				db.Session(&gorm.Session{NowFunc: func() time.Time { return deletedAt }}).Delete(&user)
+			}
+		}
+	}
+	create(old, testNow.AddDate(0, 0, -100))
+	create(recent, testNow.AddDate(0, 0, -1))
+	create(active, time.Time{})
+}
+
+func TestPurge(t *testing.T) {
+	db := pinNow(openDB(t, &PurgeUser{}))
+	seedPurge(t, db, 5, 2, 3)
+
+	total, err := Purge(db, &PurgeUser{}, Older(90*24*time.Hour), BatchSize(2))
+	if err != nil || total != 5 {
+		t.Fatalf("Purge = %d, %v", total, err)
+	}
+
+	counts, _ := Count(db, &PurgeUser{})
+	if counts != (Counts{Active: 3, Deleted: 2, Total: 5}) {
+		t.Errorf("counts after purge = %+v", counts)
+	}
+
+	// 保留期为 0 时清理所有已删除的记录，未删除的记录不受影响
+	if total, err = Purge(db, &PurgeUser{}); err != nil || total != 2 {
+		t.Fatalf("Purge all = %d, %v", total, err)
+	}
+	if n := countRows(t, db, "purge_users"); n != 3 {
+		t.Errorf("rows left = %d, want 3", n)
+	}
+}
+
+func TestPurgeRequiresDeletedAt(t *testing.T) {
+	db := openDB(t, &User{})
+	if _, err := Purge(db, &User{}, Older(time.Hour)); !errors.Is(err, ErrMissingDeletedAtField) {
+		t.Errorf("Purge error = %v", err)
+	}
+}
+
+func TestPurgeCanceled(t *testing.T) {
+	db := pinNow(openDB(t, &PurgeUser{}))
+	seedPurge(t, db, 3, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	total, err := Purge(db.WithContext(ctx), &PurgeUser{})
+	if !errors.Is(err, context.Canceled) || total != 0 {
+		t.Errorf("Purge = %d, %v", total, err)
+	}
+	if n := countRows(t, db, "purge_users"); n != 3 {
+		t.Errorf("rows left = %d, want 3", n)
+	}
+}
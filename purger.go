@@ -0,0 +1,99 @@
+package soft_delete
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const defaultPurgeInterval = time.Hour
+
+// PurgeConfig 为 Purger 的配置
+type PurgeConfig struct {
+	Models    []interface{}
+	Retention time.Duration
+	// Interval 为两轮清理之间的间隔，默认 1 小时
+	Interval time.Duration
+	// BatchSize 为每批物理删除的行数，默认 1000
+	BatchSize int
+	// OnRun 在每轮清理结束后调用
+	OnRun func(stats []PurgeStats)
+	// Now 为计算保留期截止时间使用的当前时间，默认取 db 的 NowFunc，即 time.Now
+	Now func() time.Time
+}
+
+// PurgeStats 为一轮清理中单个模型的结果
+type PurgeStats struct {
+	Table    string
+	Purged   int64
+	Duration time.Duration
+	Err      error
+}
+
+// Purger 定期清理超过保留期的软删除记录。每批都是按主键的幂等删除，
+// 多个实例同时运行也是安全的，不需要额外加锁
+type Purger struct {
+	db     *gorm.DB
+	config PurgeConfig
+}
+
+// NewPurger 创建清理器，调用 Run 开始运行
+//
+//	purger := soft_delete.NewPurger(db, soft_delete.PurgeConfig{Models: []interface{}{&User{}}, Retention: 30 * 24 * time.Hour})
+//	go purger.Run(ctx)
+func NewPurger(db *gorm.DB, config PurgeConfig) *Purger {
+	if config.Interval <= 0 {
+		config.Interval = defaultPurgeInterval
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultPurgeBatchSize
+	}
+	return &Purger{db: db, config: config}
+}
+
+// Run 立即执行一轮清理，之后按 Interval 定期执行，直到 ctx 被取消
+func (p *Purger) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		stats := p.RunOnce(ctx)
+		if p.config.OnRun != nil {
+			p.config.OnRun(stats)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce 对每个模型执行一次清理，某个模型失败不影响其他模型
+func (p *Purger) RunOnce(ctx context.Context) []PurgeStats {
+	db := p.db.WithContext(ctx)
+	if p.config.Now != nil {
+		db = db.Session(&gorm.Session{NowFunc: p.config.Now})
+	}
+	stats := make([]PurgeStats, 0, len(p.config.Models))
+	for _, model := range p.config.Models {
+		var (
+			stat  PurgeStats
+			start = time.Now()
+		)
+		if s, _, err := parseDeleteClause(db, model); s != nil {
+			stat.Table = s.Table
+		} else {
+			stat.Err = err
+		}
+
+		if stat.Err == nil {
+			stat.Purged, stat.Err = Purge(db, model, Older(p.config.Retention), BatchSize(p.config.BatchSize))
+		}
+		stat.Duration = time.Since(start)
+		stats = append(stats, stat)
+	}
+	return stats
+}
@@ -0,0 +1,65 @@
+package soft_delete
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// 按注入的时钟，记录在保留期过后才被清理；一个模型失败不影响其他模型
+func TestPurgerRunOnce(t *testing.T) {
+	db := pinNow(openDB(t, &PurgeUser{}, &User{}))
+	seedPurge(t, db, 0, 2, 1)
+
+	now := testNow
+	purger := NewPurger(db, PurgeConfig{
+		Models:    []interface{}{&User{}, &PurgeUser{}},
+		Retention: 30 * 24 * time.Hour,
+		BatchSize: 1,
+		Now:       func() time.Time { return now },
+	})
+
+	stats := purger.RunOnce(context.Background())
+	if len(stats) != 2 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if !errors.Is(stats[0].Err, ErrMissingDeletedAtField) || stats[0].Table != "users" {
+		t.Errorf("users stats = %+v", stats[0])
+	}
+	if stats[1].Err != nil || stats[1].Purged != 0 || stats[1].Table != "purge_users" {
+		t.Errorf("purge_users stats before retention = %+v", stats[1])
+	}
+
+	now = testNow.AddDate(0, 0, 31)
+	stats = purger.RunOnce(context.Background())
+	if stats[1].Err != nil || stats[1].Purged != 2 {
+		t.Errorf("purge_users stats after retention = %+v", stats[1])
+	}
+	if n := countRows(t, db, "purge_users"); n != 1 {
+		t.Errorf("rows left = %d, want 1", n)
+	}
+}
+
+func TestPurgerRun(t *testing.T) {
+	db := pinNow(openDB(t, &PurgeUser{}))
+	seedPurge(t, db, 1, 0, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := make(chan []PurgeStats, 1)
+	purger := NewPurger(db, PurgeConfig{
+		Models:   []interface{}{&PurgeUser{}},
+		Interval: time.Hour,
+		OnRun: func(stats []PurgeStats) {
+			runs <- stats
+			cancel()
+		},
+	})
+
+	if err := purger.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Run error = %v", err)
+	}
+	if stats := <-runs; len(stats) != 1 || stats[0].Purged != 1 {
+		t.Errorf("first run = %+v", stats)
+	}
+}
@@ -0,0 +1,124 @@
+package soft_delete
+
+import (
+	"sort"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// 各种读取方式都不能返回已删除的 b
+func TestReadVariants(t *testing.T) {
+	db := openDB(t, &User{})
+	seedUsers(t, db)
+	db.Create(&User{Name: "a"})
+
+	tests := []struct {
+		name string
+		read func(db *gorm.DB) ([]string, error)
+		want []string
+	}{
+		{"Pluck", func(db *gorm.DB) (names []string, err error) {
+			err = db.Model(&User{}).Pluck("name", &names).Error
+			return
+		}, []string{"a", "a", "c"}},
+		{"Distinct Pluck", func(db *gorm.DB) (names []string, err error) {
+			err = db.Model(&User{}).Distinct("name").Pluck("name", &names).Error
+			return
+		}, []string{"a", "c"}},
+		{"Distinct Find", func(db *gorm.DB) (names []string, err error) {
+			var users []User
+			err = db.Distinct("name").Find(&users).Error
+			for _, u := range users {
+				names = append(names, u.Name)
+			}
+			return
+		}, []string{"a", "c"}},
+		{"Pluck with Where", func(db *gorm.DB) (names []string, err error) {
+			err = db.Model(&User{}).Where("name = ? OR name = ?", "b", "c").Pluck("name", &names).Error
+			return
+		}, []string{"c"}},
+		{"Group", func(db *gorm.DB) (names []string, err error) {
+			err = db.Model(&User{}).Group("name").Pluck("name", &names).Error
+			return
+		}, []string{"a", "c"}},
+		{"Select aggregate", func(db *gorm.DB) (names []string, err error) {
+			var rows []struct {
+				Name  string
+				Count int
+			}
+			err = db.Model(&User{}).Select("name, COUNT(*) AS count").Group("name").Scan(&rows).Error
+			for _, r := range rows {
+				for i := 0; i < r.Count; i++ {
+					names = append(names, r.Name)
+				}
+			}
+			return
+		}, []string{"a", "a", "c"}},
+		{"Rows", func(db *gorm.DB) (names []string, err error) {
+			rows, err := db.Model(&User{}).Select("name").Rows()
+			if err != nil {
+				return nil, err
+			}
+			defer rows.Close()
+			for rows.Next() {
+				var name string
+				rows.Scan(&name)
+				names = append(names, name)
+			}
+			return names, rows.Err()
+		}, []string{"a", "a", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.read(db)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sort.Strings(got)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestCountVariants(t *testing.T) {
+	db := openDB(t, &User{})
+	seedUsers(t, db)
+	db.Create(&User{Name: "a"})
+
+	tests := []struct {
+		name  string
+		query func(db *gorm.DB) *gorm.DB
+		want  int64
+	}{
+		{"Count", func(db *gorm.DB) *gorm.DB { return db.Model(&User{}) }, 3},
+		{"Distinct Count", func(db *gorm.DB) *gorm.DB { return db.Model(&User{}).Distinct("name") }, 2},
+		{"Count with Where", func(db *gorm.DB) *gorm.DB { return db.Model(&User{}).Where("name <> ?", "a") }, 1},
+		{"Group Count", func(db *gorm.DB) *gorm.DB { return db.Model(&User{}).Group("name") }, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n int64
+			if err := tt.query(db).Count(&n).Error; err != nil {
+				t.Fatal(err)
+			}
+			if n != tt.want {
+				t.Errorf("count = %d, want %d", n, tt.want)
+			}
+		})
+	}
+
+	// 没有 Model 的 Table 查询没有 schema，不会过滤
+	var names []string
+	db.Table("users").Pluck("name", &names)
+	if len(names) != 4 {
+		t.Errorf("Table Pluck = %v, want all rows", names)
+	}
+}
@@ -0,0 +1,33 @@
+package soft_delete
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const reasonClauseName = "soft_delete:reason"
+
+// Reason 为删除附带原因，配置了 DeletedReasonField 时写入该字段
+//
+//	db.Clauses(soft_delete.Reason("gdpr request")).Delete(&user)
+type Reason string
+
+func (r Reason) Name() string {
+	return reasonClauseName
+}
+
+func (r Reason) Build(clause.Builder) {
+}
+
+func (r Reason) MergeClause(c *clause.Clause) {
+	c.Expression = r
+}
+
+func reasonFromStatement(stmt *gorm.Statement) (Reason, bool) {
+	if c, ok := stmt.Clauses[reasonClauseName]; ok {
+		if r, ok := c.Expression.(Reason); ok {
+			return r, true
+		}
+	}
+	return "", false
+}
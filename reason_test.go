@@ -0,0 +1,50 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type ReasonUser struct {
+	ID           uint
+	Deleted      DeletedAt `gorm:"softDelete:flag,DeletedReasonField:DeleteReason"`
+	DeleteReason *string
+}
+
+func TestDeleteReason(t *testing.T) {
+	db := openDB(t, &ReasonUser{})
+	withReason, withoutReason := ReasonUser{}, ReasonUser{}
+	db.Create(&withReason)
+	db.Create(&withoutReason)
+
+	// 没有 Reason 子句时原因列不在 SET 中
+	sql := sqlOf(t, db.Session(&gorm.Session{DryRun: true}).Delete(&ReasonUser{ID: withoutReason.ID}))
+	assertNotContains(t, sql, "delete_reason")
+
+	if err := db.Clauses(Reason("gdpr request")).Delete(&withReason).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Delete(&withoutReason)
+
+	var got ReasonUser
+	db.Unscoped().First(&got, withReason.ID)
+	if got.DeleteReason == nil || *got.DeleteReason != "gdpr request" {
+		t.Errorf("reason = %v", got.DeleteReason)
+	}
+	var other ReasonUser
+	db.Unscoped().First(&other, withoutReason.ID)
+	if other.DeleteReason != nil {
+		t.Errorf("reason without clause = %q", *other.DeleteReason)
+	}
+
+	// 恢复时清空原因
+	if _, err := Restore(db, &withReason); err != nil {
+		t.Fatal(err)
+	}
+	got = ReasonUser{}
+	db.First(&got, withReason.ID)
+	if got.DeleteReason != nil || withReason.DeleteReason != nil {
+		t.Errorf("reason after restore = %v / %v", got.DeleteReason, withReason.DeleteReason)
+	}
+}
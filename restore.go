@@ -0,0 +1,333 @@
+package soft_delete
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const restoreClauseName = "soft_delete:restore"
+
+// RestoreClause 将当前 UPDATE 改写为恢复操作：标记位置为未删除、清空删除时间/操作人/原因，
+// 并且只作用于已删除的记录
+//
+//	db.Model(&user).Clauses(soft_delete.RestoreClause{}).UpdateColumns(map[string]interface{}{})
+type RestoreClause struct{}
+
+func (RestoreClause) Name() string {
+	return restoreClauseName
+}
+
+func (RestoreClause) Build(clause.Builder) {
+}
+
+func (r RestoreClause) MergeClause(c *clause.Clause) {
+	c.Expression = r
+}
+
+// BeforeRestoreInterface 恢复前调用，返回错误时取消恢复
+type BeforeRestoreInterface interface {
+	BeforeRestore(tx *gorm.DB) error
+}
+
+// AfterRestoreInterface 恢复成功后调用，返回错误时回滚
+type AfterRestoreInterface interface {
+	AfterRestore(tx *gorm.DB) error
+}
+
+// Restore 按主键恢复已软删除的记录，同时更新内存中的模型，返回恢复的行数。
+// 模型实现的 BeforeRestore、AfterRestore 与恢复语句在同一事务中执行，切片中的每个元素都会调用
+//
+//	soft_delete.Restore(db, &user, soft_delete.Cascade())
+func Restore(db *gorm.DB, value interface{}, opts ...RestoreOption) (int64, error) {
+	config := restoreConfig{tolerance: defaultCascadeTolerance, strict: configOf(db).Strict}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(value); err != nil {
+		return 0, err
+	}
+	if table, ok := archiveTableOf(stmt.Schema); ok {
+		return restoreArchived(db, stmt.Schema, table, value)
+	}
+
+	before := func(tx *gorm.DB) error {
+		if config.conflicts != nil {
+			if err := checkConflicts(tx, value, config.conflicts); err != nil {
+				return err
+			}
+		}
+		if config.cascade {
+			return cascadeRestore(tx, value, config)
+		}
+		return nil
+	}
+	return restoreInTransaction(db, value, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(value)
+	}, before, config.strict)
+}
+
+// RestoreLocked 在事务中先以 SELECT ... FOR UPDATE 锁定记录并确认已被删除，再恢复记录，返回恢复的行数。
+// 并发恢复同一条记录时只有一个调用返回 1，其余的在锁释放后发现记录未删除，返回 0。value 需要带有主键值
+//
+//	n, err := soft_delete.RestoreLocked(db, &user)
+func RestoreLocked(db *gorm.DB, value interface{}, opts ...RestoreOption) (rowsAffected int64, err error) {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if rv.Kind() != reflect.Struct {
+		return 0, gorm.ErrPrimaryKeyRequired
+	}
+	s, _, err := parseDeleteClause(db, value)
+	if err != nil {
+		return 0, err
+	}
+	if _, queryValues := schema.GetIdentityFieldValuesMap(db.Statement.Context, rv, s.PrimaryFields); len(queryValues) == 0 {
+		return 0, gorm.ErrPrimaryKeyRequired
+	}
+
+	err = Transaction(db, func(tx *gorm.DB) error {
+		// 按 value 的主键查询一个副本，不覆盖调用方的值
+		locked := reflect.New(rv.Type())
+		locked.Elem().Set(rv)
+		result := tx.Scopes(OnlyDeleted).Clauses(clause.Locking{Strength: "UPDATE"}).Limit(1).Find(locked.Interface())
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		rowsAffected, err = Restore(tx, value, opts...)
+		return err
+	})
+	return
+}
+
+// RestoreWhere 按 db 上已有的条件用一条 UPDATE 恢复所有匹配的已删除记录，返回恢复的行数。
+// 与 gorm 的全局更新保护一致，没有 WHERE 条件时需要开启 AllowGlobalUpdate；Model 为切片时会调用恢复钩子
+//
+//	soft_delete.RestoreWhere(db.Model(&Order{}).Where("customer_id = ?", id))
+func RestoreWhere(db *gorm.DB) (int64, error) {
+	model := db.Statement.Model
+	if model == nil {
+		return 0, gorm.ErrModelValueRequired
+	}
+
+	var hookValue interface{}
+	if kind := reflect.Indirect(reflect.ValueOf(model)).Kind(); kind == reflect.Slice || kind == reflect.Array {
+		hookValue = model
+	}
+	return restoreInTransaction(db, hookValue, func(tx *gorm.DB) *gorm.DB {
+		return tx
+	}, nil, false)
+}
+
+// restoreInTransaction 依次执行 BeforeRestore、before、恢复语句和 AfterRestore，before 在记录恢复之前执行；
+// strict 时没有记录被恢复返回 gorm.ErrRecordNotFound
+func restoreInTransaction(db *gorm.DB, hookValue interface{}, scope func(*gorm.DB) *gorm.DB, before func(*gorm.DB) error, strict bool) (rowsAffected int64, err error) {
+	err = Transaction(db, func(tx *gorm.DB) error {
+		if err := callRestoreHooks(tx, hookValue, beforeRestore); err != nil {
+			return err
+		}
+
+		if before != nil {
+			if err := before(tx); err != nil {
+				return err
+			}
+		}
+
+		result := scope(tx).Omit(clause.Associations).Clauses(RestoreClause{}).UpdateColumns(map[string]interface{}{})
+		if result.Error != nil {
+			return result.Error
+		}
+		rowsAffected = result.RowsAffected
+		if strict && rowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		return callRestoreHooks(tx, hookValue, afterRestore)
+	})
+	return
+}
+
+func beforeRestore(value interface{}, tx *gorm.DB) error {
+	if i, ok := value.(BeforeRestoreInterface); ok {
+		return i.BeforeRestore(tx)
+	}
+	return nil
+}
+
+func afterRestore(value interface{}, tx *gorm.DB) error {
+	if i, ok := value.(AfterRestoreInterface); ok {
+		return i.AfterRestore(tx)
+	}
+	return nil
+}
+
+// callRestoreHooks 与 gorm 调用 BeforeDelete 等钩子的方式一致，对切片逐个元素调用
+func callRestoreHooks(db *gorm.DB, value interface{}, fc func(value interface{}, tx *gorm.DB) error) error {
+	if db.Statement.SkipHooks {
+		return nil
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true})
+	reflectValue := reflect.Indirect(reflect.ValueOf(value))
+	switch reflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < reflectValue.Len(); i++ {
+			elem := reflect.Indirect(reflectValue.Index(i))
+			if !elem.CanAddr() {
+				return gorm.ErrInvalidValue
+			}
+			if err := fc(elem.Addr().Interface(), tx); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if reflectValue.CanAddr() {
+			return fc(reflectValue.Addr().Interface(), tx)
+		}
+		return fc(value, tx)
+	}
+	return nil
+}
+
+func isRestoring(stmt *gorm.Statement) bool {
+	_, ok := stmt.Clauses[restoreClauseName]
+	return ok
+}
+
+// restore 构造恢复语句，与删除子句一样直接生成 UPDATE
+func (sd SoftDeleteDeleteClause) restore(stmt *gorm.Statement) {
+	if err := bitFieldError(sd.Field); err != nil {
+		stmt.AddError(err)
+		return
+	}
+	set := callbacks.ConvertToAssignments(stmt)
+
+	active := activeValue(sd.Field, sd.Flag)
+	set = append(set, clause.Assignment{Column: clause.Column{Name: sd.Field.DBName}, Value: sqlValue(active)})
+	assignField(stmt, sd.Field, active)
+
+	for _, field := range sd.companionFields() {
+		value := zeroValue(field)
+		set = append(set, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: value})
+		assignField(stmt, field, value)
+	}
+
+	// UpdateColumns 不会更新 autoUpdateTime 字段，Updates 时 gorm 已经加上
+	now := stmt.DB.NowFunc()
+	for _, field := range sd.touchedFields() {
+		if !hasAssignment(set, field.DBName) {
+			value := autoUpdateValue(field, now)
+			set = append(set, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: value})
+			assignField(stmt, field, value)
+		}
+	}
+	stmt.AddClause(set)
+
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		deletedExpr(sd.Field, sd.Flag, clause.Column{Table: clause.CurrentTable, Name: sd.Field.DBName}),
+	}})
+	if len(sd.IntervalKeyFields) > 0 {
+		if err := sd.checkIntervalFields(); err != nil {
+			stmt.AddError(err)
+			return
+		}
+		stmt.AddClause(clause.Where{Exprs: []clause.Expression{notSuperseded{sd: sd}}})
+	}
+	stmt.AddClause(filterEnabled{})
+
+	if mode, ok := tombstoneOf(stmt, sd.tombstone); ok {
+		if sd.VersionField == nil {
+			stmt.AddError(ErrMissingTombstoneVersion)
+			return
+		}
+		sd.buildTombstone(stmt, mode, set)
+		return
+	}
+	stmt.AddClauseIfNotExists(clause.Update{})
+	stmt.Build(stmt.DB.Callback().Update().Clauses...)
+}
+
+// companionFields 返回已配置的删除时间、操作人、原因字段和旧的时间戳字段
+func (sd SoftDeleteDeleteClause) companionFields() []*schema.Field {
+	fields := make([]*schema.Field, 0, 4)
+	for _, field := range []*schema.Field{sd.DeleteAtField, sd.DeleteByField, sd.DeleteReasonField, sd.LegacyTimestampField} {
+		if field != nil {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// deleteClauseOf 返回字段对应的删除子句，其中包含了伴随字段的配置
+func deleteClauseOf(f *schema.Field) (SoftDeleteDeleteClause, bool) {
+	for _, c := range f.Schema.DeleteClauses {
+		if sd, ok := c.(SoftDeleteDeleteClause); ok && sd.Field == f {
+			return sd, true
+		}
+	}
+	if sd, ok := ruleOf(f.Schema); ok && sd.Field == f {
+		return sd, true
+	}
+	return SoftDeleteDeleteClause{}, false
+}
+
+// lookUpDeleteClause 返回模型中第一个软删除字段的删除子句，没有时返回 Register 注册的删除子句。
+// ExpiresAt 与其他软删除字段同时存在时返回后者
+func lookUpDeleteClause(s *schema.Schema) (SoftDeleteDeleteClause, bool) {
+	for _, c := range s.DeleteClauses {
+		if sd, ok := c.(SoftDeleteDeleteClause); ok && !sd.yields() {
+			return sd, true
+		}
+	}
+	return ruleOf(s)
+}
+
+// parseDeleteClause 解析模型并返回其软删除配置
+func parseDeleteClause(db *gorm.DB, model interface{}) (*schema.Schema, SoftDeleteDeleteClause, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, SoftDeleteDeleteClause{}, err
+	}
+
+	sd, ok := lookUpDeleteClause(stmt.Schema)
+	if !ok {
+		return stmt.Schema, sd, ErrMissingSoftDeleteField
+	}
+	return stmt.Schema, sd, nil
+}
+
+// zeroValue 返回恢复时伴随字段应写入的值，指针和时间类型写 NULL
+func zeroValue(field *schema.Field) interface{} {
+	if field.FieldType.Kind() == reflect.Ptr {
+		return nil
+	}
+	switch field.GORMDataType {
+	case schema.Bool:
+		return false
+	case schema.Int, schema.Uint, schema.Float:
+		return 0
+	case schema.String:
+		return ""
+	default:
+		return nil
+	}
+}
+
+// assignField 将值写入内存中的模型，Dest 为 map 时 stmt.SetColumn 不会修改模型
+func assignField(stmt *gorm.Statement, field *schema.Field, value interface{}) {
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			if elem := reflect.Indirect(stmt.ReflectValue.Index(i)); elem.Kind() == reflect.Struct {
+				stmt.AddError(field.Set(stmt.Context, elem, value))
+			}
+		}
+	case reflect.Struct:
+		if stmt.ReflectValue.CanAddr() {
+			stmt.AddError(field.Set(stmt.Context, stmt.ReflectValue, value))
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type RestoreUser struct {
+	ID        uint
+	Name      string
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt,DeletedByField:DeletedBy"`
+	DeletedAt *time.Time
+	DeletedBy *string
+}
+
+func TestRestore(t *testing.T) {
+	db := openDB(t, &RestoreUser{})
+	user := RestoreUser{Name: "a"}
+	db.Create(&user)
+	db.WithContext(WithActor(db.Statement.Context, "alice")).Delete(&user)
+	if !user.Deleted || user.DeletedAt == nil || user.DeletedBy == nil {
+		t.Fatalf("after delete = %+v", user)
+	}
+
+	n, err := Restore(db, &user)
+	if err != nil || n != 1 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	if user.Deleted || user.DeletedAt != nil || user.DeletedBy != nil {
+		t.Errorf("in-memory after restore = %+v", user)
+	}
+	var got RestoreUser
+	if err := db.First(&got, user.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got.Deleted || got.DeletedAt != nil || got.DeletedBy != nil {
+		t.Errorf("row after restore = %+v", got)
+	}
+
+	// 恢复未删除的记录不影响任何行
+	if n, err := Restore(db, &user); err != nil || n != 0 {
+		t.Errorf("second Restore = %d, %v", n, err)
+	}
+}
+
+func TestRestoreInTransaction(t *testing.T) {
+	db := openDB(t, &RestoreUser{})
+	user := RestoreUser{Name: "a"}
+	db.Create(&user)
+	db.Delete(&user)
+
+	errRollback := errors.New("rollback")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if n, err := Restore(tx, &user); err != nil || n != 1 {
+			t.Errorf("Restore = %d, %v", n, err)
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatal(err)
+	}
+	var count int64
+	db.Model(&RestoreUser{}).Count(&count)
+	if count != 0 {
+		t.Errorf("restore was not rolled back, active = %d", count)
+	}
+
+	db.Transaction(func(tx *gorm.DB) error {
+		_, err := Restore(tx, &user)
+		return err
+	})
+	db.Model(&RestoreUser{}).Count(&count)
+	if count != 1 {
+		t.Errorf("active after committed restore = %d", count)
+	}
+}
+
+func TestRestoreClause(t *testing.T) {
+	db := openDB(t, &RestoreUser{})
+	sql := sqlOf(t, dryRun(db).Model(&RestoreUser{ID: 1}).Clauses(RestoreClause{}).UpdateColumns(map[string]interface{}{}))
+	assertContains(t, sql, "`deleted`=?", "`deleted_at`=?", "`deleted_by`=?", "`restore_users`.`deleted` <> ?", "`id` = ?")
+}
+
+type HookUser struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+
+	calls []string
+}
+
+var errRefuseRestore = errors.New("refuse restore")
+
+func (u *HookUser) BeforeRestore(tx *gorm.DB) error {
+	u.calls = append(u.calls, "before")
+	if u.Name == "locked" {
+		return errRefuseRestore
+	}
+	return nil
+}
+
+func (u *HookUser) AfterRestore(tx *gorm.DB) error {
+	u.calls = append(u.calls, "after")
+	return nil
+}
+
+func TestRestoreHooks(t *testing.T) {
+	db := openDB(t, &HookUser{})
+	users := []HookUser{{Name: "a"}, {Name: "b"}}
+	db.Create(&users)
+	db.Delete(&users)
+
+	if n, err := Restore(db, &users); err != nil || n != 2 {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	for _, u := range users {
+		if len(u.calls) != 2 || u.calls[0] != "before" || u.calls[1] != "after" {
+			t.Errorf("hooks of %s = %v", u.Name, u.calls)
+		}
+	}
+}
+
+// BeforeRestore 返回错误时取消恢复，记录保持删除
+func TestRestoreHookAbort(t *testing.T) {
+	db := openDB(t, &HookUser{})
+	user := HookUser{Name: "locked"}
+	db.Create(&user)
+	db.Delete(&user)
+
+	if _, err := Restore(db, &user); !errors.Is(err, errRefuseRestore) {
+		t.Fatalf("Restore error = %v", err)
+	}
+	if len(user.calls) != 1 {
+		t.Errorf("hooks = %v, AfterRestore should not run", user.calls)
+	}
+	var got HookUser
+	db.Unscoped().First(&got, user.ID)
+	if !got.Deleted {
+		t.Error("row was restored despite BeforeRestore error")
+	}
+}
+
+type Order struct {
+	ID         uint
+	CustomerID uint
+	Deleted    DeletedAt `gorm:"softDelete:flag"`
+}
+
+func TestRestoreWhere(t *testing.T) {
+	db := openDB(t, &Order{})
+	orders := []Order{{CustomerID: 1}, {CustomerID: 1}, {CustomerID: 2}}
+	db.Create(&orders)
+	db.Delete(&orders)
+
+	sql := sqlOf(t, dryRun(db).Model(&Order{}).Where("customer_id = ?", 1).Clauses(RestoreClause{}).UpdateColumns(map[string]interface{}{}))
+	assertContains(t, sql, "customer_id = ?", "`orders`.`deleted` <> ?")
+
+	n, err := RestoreWhere(db.Model(&Order{}).Where("customer_id = ?", 1))
+	if err != nil || n != 2 {
+		t.Fatalf("RestoreWhere = %d, %v", n, err)
+	}
+	var active []Order
+	db.Find(&active)
+	if len(active) != 2 || active[0].CustomerID != 1 || active[1].CustomerID != 1 {
+		t.Errorf("active = %+v", active)
+	}
+}
+
+// 没有条件时与 gorm 的全局更新保护一致
+func TestRestoreWhereGlobal(t *testing.T) {
+	db := openDB(t, &Order{})
+	orders := []Order{{CustomerID: 1}, {CustomerID: 2}}
+	db.Create(&orders)
+	db.Delete(&orders)
+
+	if _, err := RestoreWhere(db.Model(&Order{})); !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Fatalf("RestoreWhere without conditions error = %v", err)
+	}
+	n, err := RestoreWhere(db.Session(&gorm.Session{AllowGlobalUpdate: true}).Model(&Order{}))
+	if err != nil || n != 2 {
+		t.Errorf("RestoreWhere with AllowGlobalUpdate = %d, %v", n, err)
+	}
+}
+
+func TestRestoreWhereHooks(t *testing.T) {
+	db := openDB(t, &HookUser{})
+	users := []HookUser{{Name: "a"}, {Name: "b"}}
+	db.Create(&users)
+	db.Delete(&users)
+
+	n, err := RestoreWhere(db.Model(&users).Where("name IN ?", []string{"a", "b"}))
+	if err != nil || n != 2 {
+		t.Fatalf("RestoreWhere = %d, %v", n, err)
+	}
+	for _, u := range users {
+		if len(u.calls) != 2 {
+			t.Errorf("hooks of %s = %v", u.Name, u.calls)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package soft_delete
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+const rulesCallbackName = "soft_delete:rules"
+
+// TableRule 为无法添加软删除字段的模型注册软删除，例如由 protobuf 生成的结构体，表中已有标记列
+type TableRule struct {
+	Model interface{}
+	// Column 为标记列名，结构体中可以没有对应的字段
+	Column string
+	// ActiveValue 为未删除的值，默认为 DeletedValue 类型的零值
+	ActiveValue interface{}
+	// DeletedValue 为已删除的值，默认与 DeletedAt 相同
+	DeletedValue interface{}
+}
+
+// tableRules 按模型的 schema 保存注册的删除子句，查询、更新、删除和插入时由回调添加对应的子句
+var tableRules sync.Map
+
+// Register 为模型注册软删除，注册后 Find、Update、Delete、Restore 等与使用软删除字段的模型行为一致，
+// 可以与使用字段的模型在同一个 DB 中共存。应在使用模型之前调用
+//
+//	soft_delete.Register(db, soft_delete.TableRule{Model: &pb.User{}, Column: "deleted", DeletedValue: true})
+func Register(db *gorm.DB, rules ...TableRule) error {
+	for _, rule := range rules {
+		if err := registerRule(db, rule); err != nil {
+			return err
+		}
+	}
+	return registerRuleCallbacks(db)
+}
+
+func registerRule(db *gorm.DB, rule TableRule) error {
+	if rule.Column == "" {
+		return errors.New("soft_delete: TableRule requires a Column")
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(rule.Model); err != nil {
+		return err
+	}
+	s := stmt.Schema
+	if _, ok := lookUpDeleteClause(s); ok {
+		return fmt.Errorf("soft_delete: %s already has a soft delete field", s.Name)
+	}
+
+	values := rule.flagValues()
+	field := s.LookUpField(rule.Column)
+	if field == nil {
+		field = ruleField(s, rule.Column, values)
+	}
+	fieldFlagValues.Store(field, values)
+	tableRules.Store(s, SoftDeleteDeleteClause{Field: field, Flag: true})
+	return nil
+}
+
+func (rule TableRule) flagValues() flagValues {
+	values := deletedAtValues()
+	if rule.DeletedValue != nil {
+		values.deleted = rule.DeletedValue
+		values.active = reflect.Zero(reflect.TypeOf(rule.DeletedValue)).Interface()
+	}
+	if rule.ActiveValue != nil {
+		values.active = rule.ActiveValue
+	}
+	return values
+}
+
+// ruleField 为结构体中没有的标记列构造字段，只用于生成 SQL，读写模型时不做任何事
+func ruleField(s *schema.Schema, column string, values flagValues) *schema.Field {
+	return &schema.Field{
+		Name:         column,
+		DBName:       column,
+		Schema:       s,
+		DataType:     schema.DataType(reflect.TypeOf(values.deleted).Kind().String()),
+		GORMDataType: schema.DataType(reflect.TypeOf(values.deleted).Kind().String()),
+		ValueOf: func(context.Context, reflect.Value) (interface{}, bool) {
+			return values.active, true
+		},
+		Set: func(context.Context, reflect.Value, interface{}) error {
+			return nil
+		},
+	}
+}
+
+// isStructField 判断字段是否在模型的结构体中，注册规则时构造的字段不在
+func isStructField(f *schema.Field) bool {
+	return f.Schema.LookUpField(f.DBName) == f
+}
+
+// ruleOf 返回模型注册的删除子句
+func ruleOf(s *schema.Schema) (SoftDeleteDeleteClause, bool) {
+	if s == nil {
+		return SoftDeleteDeleteClause{}, false
+	}
+	if sd, ok := tableRules.Load(s); ok {
+		return sd.(SoftDeleteDeleteClause), true
+	}
+	return SoftDeleteDeleteClause{}, false
+}
+
+// registerRuleCallbacks 在 gorm 构建语句之前，按 gorm 添加 schema 中子句的方式为注册的模型添加子句
+func registerRuleCallbacks(db *gorm.DB) error {
+	if db.Callback().Query().Get(rulesCallbackName) != nil {
+		return nil
+	}
+
+	query := func(tx *gorm.DB) {
+		if sd, ok := ruleOf(tx.Statement.Schema); ok && tx.Error == nil {
+			tx.Statement.AddClause(SoftDeleteQueryClause{Field: sd.Field, Flag: sd.Flag})
+		}
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register(rulesCallbackName, query); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register(rulesCallbackName, query); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Update().After("gorm:before_update").Before("gorm:update").Register(rulesCallbackName, func(tx *gorm.DB) {
+		if sd, ok := ruleOf(tx.Statement.Schema); ok && tx.Error == nil {
+			tx.Statement.AddClause(SoftDeleteUpdateClause{Field: sd.Field, Flag: sd.Flag})
+		}
+	}); err != nil {
+		return err
+	}
+
+	if err := db.Callback().Delete().After("gorm:before_delete").Before("gorm:delete").Register(rulesCallbackName, func(tx *gorm.DB) {
+		if sd, ok := ruleOf(tx.Statement.Schema); ok && tx.Error == nil {
+			tx.Statement.AddClause(sd)
+		}
+	}); err != nil {
+		return err
+	}
+
+	return db.Callback().Create().After("gorm:before_create").Before("gorm:create").Register(rulesCallbackName, func(tx *gorm.DB) {
+		if sd, ok := ruleOf(tx.Statement.Schema); ok && tx.Error == nil && !tx.Statement.Unscoped {
+			tx.Statement.AddClause(SoftDeleteCreateClause{Field: sd.Field})
+		}
+	})
+}
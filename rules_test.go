@@ -0,0 +1,58 @@
+package soft_delete
+
+import "testing"
+
+// ExternalUser 模拟无法修改的结构体，表中有 deleted 列但结构体中没有对应的字段
+type ExternalUser struct {
+	ID   uint
+	Name string
+}
+
+func TestRegister(t *testing.T) {
+	db := openDB(t, &User{})
+	if err := db.Exec("CREATE TABLE `external_users` (`id` integer PRIMARY KEY AUTOINCREMENT, `name` text, `deleted` numeric NOT NULL DEFAULT false)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(db, TableRule{Model: &ExternalUser{}, Column: "deleted"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Register(db, TableRule{Model: &ExternalUser{}}); err == nil {
+		t.Error("rule without column accepted")
+	}
+
+	db.Create(&[]ExternalUser{{Name: "a"}, {Name: "b"}})
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}})
+
+	if err := db.Delete(&ExternalUser{ID: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Delete(&User{ID: 2})
+	if n := countRows(t, db, "external_users"); n != 2 {
+		t.Errorf("external rows = %d, rule delete was not soft", n)
+	}
+
+	var external []ExternalUser
+	db.Find(&external)
+	if len(external) != 1 || external[0].ID != 2 {
+		t.Errorf("external active = %+v", external)
+	}
+	var users []User
+	db.Find(&users)
+	if len(users) != 1 || users[0].ID != 1 {
+		t.Errorf("users active = %+v", users)
+	}
+
+	if tx := db.Model(&ExternalUser{ID: 1}).Update("name", "x"); tx.RowsAffected != 0 {
+		t.Errorf("update of deleted external row affected %d", tx.RowsAffected)
+	}
+	if tx := db.Model(&ExternalUser{ID: 2}).Update("name", "x"); tx.RowsAffected != 1 {
+		t.Errorf("update of active external row affected %d", tx.RowsAffected)
+	}
+
+	var deleted []ExternalUser
+	db.Scopes(OnlyDeleted).Find(&deleted)
+	if len(deleted) != 1 || deleted[0].ID != 1 {
+		t.Errorf("external deleted = %+v", deleted)
+	}
+	assertContains(t, sqlOf(t, dryRun(db).Find(&[]ExternalUser{})), "`external_users`.`deleted` = ?")
+}
@@ -0,0 +1,70 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tenantClause 模拟租户插件，以 StatementModifier 在语句中加入已声明作用域的租户条件
+type tenantClause struct {
+	tenantID int
+}
+
+func (tenantClause) Name() string {
+	return "test:tenant"
+}
+
+func (tenantClause) Build(clause.Builder) {
+}
+
+func (tenantClause) MergeClause(*clause.Clause) {
+}
+
+func (c tenantClause) ModifyStatement(stmt *gorm.Statement) {
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+		Scoped(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: c.tenantID}),
+	}})
+}
+
+func TestScopedConditionOrder(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+	tenant := tenantClause{tenantID: 7}
+
+	before := sqlOf(t, db.Clauses(tenant).Where("name = ?", "a").Or("name = ?", "b").Find(&[]TenantUser{}))
+	after := sqlOf(t, db.Where("name = ?", "a").Or("name = ?", "b").Clauses(tenant).Find(&[]TenantUser{}))
+
+	// 租户插件在回调中加入条件时，同样不参与 OR 的分组
+	callbackDB := dryRunDB(t, "sqlite")
+	callbackDB.Callback().Query().Before("gorm:query").Register("test:tenant", func(tx *gorm.DB) {
+		tenant.ModifyStatement(tx.Statement)
+	})
+	callback := sqlOf(t, callbackDB.Where("name = ?", "a").Or("name = ?", "b").Find(&[]TenantUser{}))
+
+	want := "WHERE (name = ? OR name = ?) AND `tenant_users`.`deleted` = ? AND `tenant_users`.`tenant_id` = ?"
+	for name, sql := range map[string]string{"before": before, "after": after, "callback": callback} {
+		assertContains(t, sql, want)
+		assertNotContains(t, sql, "OR `tenant_users`.`tenant_id`", "tenant_id` = ? OR")
+		if sql != before {
+			t.Errorf("%s: %q differs from %q", name, sql, before)
+		}
+	}
+}
+
+func TestScopedOrConditions(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+	scoped := Scoped(clause.Or(
+		clause.Eq{Column: clause.Column{Name: "tenant_id"}, Value: 1},
+		clause.Eq{Column: clause.Column{Name: "shared"}, Value: true},
+	))
+	sql := sqlOf(t, db.Clauses(clause.Where{Exprs: []clause.Expression{scoped}}).Where("name = ?", "a").Find(&[]User{}))
+	assertContains(t, sql, "AND (`tenant_id` = ? OR `shared` = ?)")
+}
+
+func TestScopedExprParentheses(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+	scoped := Scoped(clause.Expr{SQL: "tenant_id = ? OR shared = ?", Vars: []interface{}{1, true}})
+	sql := sqlOf(t, db.Clauses(clause.Where{Exprs: []clause.Expression{scoped}}).Where("name = ?", "a").Find(&[]User{}))
+	assertContains(t, sql, "AND (tenant_id = ? OR shared = ?)")
+}
@@ -0,0 +1,260 @@
+package soft_delete
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	onlyDeletedClauseName        = "soft_delete:only_deleted"
+	withDeletedSettingKey        = "soft_delete:with_deleted"
+	preloadWithDeletedClauseName = "soft_delete:preload_with_deleted"
+	skipClauseName               = "soft_delete:skip"
+	updateDeletedSettingKey      = "soft_delete:update_deleted"
+	activeClauseName             = "soft_delete:active"
+)
+
+// ErrMissingDeletedByField 按操作人查询时模型没有配置 DeletedByField
+var ErrMissingDeletedByField = errors.New("soft_delete: model has no DeletedByField")
+
+type onlyDeletedClause struct{}
+
+func (onlyDeletedClause) Name() string {
+	return onlyDeletedClauseName
+}
+
+func (onlyDeletedClause) Build(clause.Builder) {
+}
+
+func (c onlyDeletedClause) MergeClause(cl *clause.Clause) {
+	cl.Expression = c
+}
+
+// OnlyDeleted 只查询已软删除的记录，替换默认的未删除过滤条件
+//
+//	db.Scopes(soft_delete.OnlyDeleted).Find(&users)
+func OnlyDeleted(db *gorm.DB) *gorm.DB {
+	return db.Clauses(onlyDeletedClause{})
+}
+
+func isOnlyDeleted(stmt *gorm.Statement) bool {
+	_, ok := stmt.Clauses[onlyDeletedClauseName]
+	return ok
+}
+
+// WithDeleted 查询时包含已软删除的记录。与 Unscoped 不同，它只去掉本包添加的过滤条件，
+// Delete 仍然是软删除，也不影响其他插件的作用域；设置会随 Session 以及 Preload 传递
+//
+//	db.Scopes(soft_delete.WithDeleted).Preload("Orders").Find(&users)
+func WithDeleted(db *gorm.DB) *gorm.DB {
+	return db.Set(withDeletedSettingKey, true)
+}
+
+func isWithDeleted(stmt *gorm.Statement) bool {
+	if _, ok := stmt.Settings.Load(withDeletedSettingKey); ok {
+		return true
+	}
+	if _, ok := stmt.Clauses[preloadWithDeletedClauseName]; ok {
+		return true
+	}
+
+	// Joins 生成 ON 条件时使用的是临时语句，设置保存在所属的 DB 上
+	if stmt.DB != nil && stmt.DB.Statement != nil && stmt.DB.Statement != stmt {
+		_, ok := stmt.DB.Statement.Settings.Load(withDeletedSettingKey)
+		return ok
+	}
+	return false
+}
+
+// Skip 使当前这一条语句不添加未删除的过滤条件，不影响链上的其他语句和预加载。
+// 查询和更新都会跳过过滤，可以直接更新已删除的记录；Delete 不受影响，仍然是软删除
+//
+//	db.Clauses(soft_delete.Skip{}).Find(&users)
+type Skip struct{}
+
+func (Skip) Name() string {
+	return skipClauseName
+}
+
+func (Skip) Build(clause.Builder) {
+}
+
+func (s Skip) MergeClause(cl *clause.Clause) {
+	cl.Expression = s
+}
+
+func isSkipped(stmt *gorm.Statement) bool {
+	_, ok := stmt.Clauses[skipClauseName]
+	return ok
+}
+
+// UpdateDeleted 更新时不添加未删除的过滤条件，可以直接更新已删除记录的其他列；
+// 查询仍然过滤，Delete 仍然是软删除。设置会随 Session 传递
+//
+//	soft_delete.UpdateDeleted(db).Model(&user).Update("note", "x")
+func UpdateDeleted(db *gorm.DB) *gorm.DB {
+	return db.Set(updateDeletedSettingKey, true)
+}
+
+func isUpdateDeleted(stmt *gorm.Statement) bool {
+	_, ok := stmt.Settings.Load(updateDeletedSettingKey)
+	return ok
+}
+
+type withDeletedClause struct{}
+
+func (withDeletedClause) Name() string {
+	return preloadWithDeletedClauseName
+}
+
+func (withDeletedClause) Build(clause.Builder) {
+}
+
+func (c withDeletedClause) MergeClause(cl *clause.Clause) {
+	cl.Expression = c
+}
+
+// PreloadWithDeleted 预加载关联时包含已软删除的记录，只作用于 query 指定的这一层关联，
+// 主查询、其他预加载以及更深层的嵌套预加载仍然过滤；嵌套关联如 "Orders.Items" 可以单独指定
+//
+//	db.Scopes(soft_delete.PreloadWithDeleted("Orders")).Preload("Orders.Items").Find(&users)
+func PreloadWithDeleted(query string, args ...interface{}) func(*gorm.DB) *gorm.DB {
+	// 子句不会像 Settings 一样传递给嵌套预加载
+	withDeleted := func(db *gorm.DB) *gorm.DB {
+		return db.Clauses(withDeletedClause{})
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Preload(query, append([]interface{}{withDeleted}, args...)...)
+	}
+}
+
+type notDeleted struct {
+	model interface{}
+	table string
+}
+
+// NotDeleted 返回 model 未删除的条件，用于无法推断关联模型的字符串 Joins，table 为连接表名或别名，
+// 为空时使用模型的表名。通过关联名 Joins 时 gorm 已经在 ON 中加入了过滤条件，不需要使用。
+// 条件放在 ON 中不会改变 LEFT JOIN 的语义，WithDeleted 时不过滤
+//
+//	db.Joins("LEFT JOIN companies c ON c.id = users.company_id AND ?", soft_delete.NotDeleted(&Company{}, "c")).Find(&users)
+func NotDeleted(model interface{}, table string) clause.Expression {
+	return notDeleted{model: model, table: table}
+}
+
+func (n notDeleted) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+
+	if isWithDeleted(stmt) || isIncludeDeleted(stmt.Context) {
+		builder.WriteString("1 = 1")
+		return
+	}
+
+	s, sd, err := parseDeleteClause(stmt.DB, n.model)
+	if err != nil {
+		stmt.AddError(err)
+		return
+	}
+
+	table := n.table
+	if table == "" {
+		table = s.Table
+	}
+	activeExpr(sd.Field, sd.Flag, clause.Column{Table: table, Name: sd.Field.DBName}).Build(builder)
+}
+
+// Active 使当前语句只查询未删除的记录，优先于 WithDeleted、IncludeDeletedContext 和 Skip，
+// 用于在包含已删除记录的会话中限定一条语句；与 OnlyDeleted 同时使用时以 OnlyDeleted 为准
+//
+//	db.Scopes(soft_delete.WithDeleted).Clauses(soft_delete.Active{}).Find(&users)
+type Active struct{}
+
+func (Active) Name() string {
+	return activeClauseName
+}
+
+func (Active) Build(clause.Builder) {
+}
+
+func (a Active) MergeClause(cl *clause.Clause) {
+	cl.Expression = a
+}
+
+func isActive(stmt *gorm.Statement) bool {
+	_, ok := stmt.Clauses[activeClauseName]
+	return ok
+}
+
+type deletedAtBetween struct {
+	from, to time.Time
+}
+
+// DeletedAtBetween 返回删除时间在 [from, to) 内的条件，from、to 为零值时不限制对应的一端。
+// 构建时按语句的模型解析删除时间字段，模型没有删除时间时语句返回 ErrMissingDeletedAtField。
+// 条件不包含是否已删除，通常与 OnlyDeleted 一起使用
+//
+//	db.Scopes(soft_delete.OnlyDeleted).Where(soft_delete.DeletedAtBetween(from, to)).Find(&users)
+func DeletedAtBetween(from, to time.Time) clause.Expression {
+	return deletedAtBetween{from: from, to: to}
+}
+
+func (d deletedAtBetween) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	sd, ok := lookUpDeleteClause(stmt.Schema)
+	if !ok {
+		stmt.AddError(ErrMissingSoftDeleteField)
+		return
+	}
+	field := sd.deletedAtField()
+	if field == nil {
+		stmt.AddError(ErrMissingDeletedAtField)
+		return
+	}
+
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	exprs := []clause.Expression{clause.Neq{Column: column, Value: nil}}
+	if !d.from.IsZero() {
+		exprs = append(exprs, clause.Gte{Column: column, Value: sd.deletedAtOf(d.from)})
+	}
+	if !d.to.IsZero() {
+		exprs = append(exprs, clause.Lt{Column: column, Value: sd.deletedAtOf(d.to)})
+	}
+	clause.And(exprs...).Build(builder)
+}
+
+type deletedByIs struct {
+	actor interface{}
+}
+
+// DeletedByIs 返回删除操作人为 actor 的条件，模型没有 DeletedByField 时语句返回 ErrMissingDeletedByField
+//
+//	db.Scopes(soft_delete.OnlyDeleted).Where(soft_delete.DeletedByIs("alice")).Find(&users)
+func DeletedByIs(actor interface{}) clause.Expression {
+	return deletedByIs{actor: actor}
+}
+
+func (d deletedByIs) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	sd, ok := lookUpDeleteClause(stmt.Schema)
+	if !ok {
+		stmt.AddError(ErrMissingSoftDeleteField)
+		return
+	}
+	if sd.DeleteByField == nil {
+		stmt.AddError(ErrMissingDeletedByField)
+		return
+	}
+	clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: sd.DeleteByField.DBName}, Value: d.actor}.Build(builder)
+}
@@ -0,0 +1,45 @@
+// Package scopes 提供可以组合使用的软删除查询作用域，均为 func(*gorm.DB) *gorm.DB，与模型的自动过滤条件协同：
+// ActiveOnly 在包含已删除记录的会话中仍然只查询未删除的记录，其余作用域替换默认的未删除条件为已删除
+//
+//	db.Scopes(scopes.DeletedSince(24*time.Hour), scopes.DeletedBy("alice")).Find(&users)
+package scopes
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/yanqin001/soft_delete"
+)
+
+// ActiveOnly 只查询未删除的记录，优先于 WithDeleted、IncludeDeletedContext，与已删除的作用域同时使用时以后者为准
+func ActiveOnly(db *gorm.DB) *gorm.DB {
+	return db.Clauses(soft_delete.Active{})
+}
+
+// DeletedOnly 只查询已删除的记录
+func DeletedOnly(db *gorm.DB) *gorm.DB {
+	return soft_delete.OnlyDeleted(db)
+}
+
+// DeletedSince 只查询最近 d 之内删除的记录，当前时间取 gorm.Config 的 NowFunc。
+// 模型没有删除时间时语句返回 soft_delete.ErrMissingDeletedAtField
+func DeletedSince(d time.Duration) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return DeletedOnly(db).Where(soft_delete.DeletedAtBetween(db.NowFunc().Add(-d), time.Time{}))
+	}
+}
+
+// DeletedBetween 只查询删除时间在 [from, to) 内的记录，模型没有删除时间时语句返回 soft_delete.ErrMissingDeletedAtField
+func DeletedBetween(from, to time.Time) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return DeletedOnly(db).Where(soft_delete.DeletedAtBetween(from, to))
+	}
+}
+
+// DeletedBy 只查询由 actor 删除的记录，模型没有 DeletedByField 时语句返回 soft_delete.ErrMissingDeletedByField
+func DeletedBy(actor string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return DeletedOnly(db).Where(soft_delete.DeletedByIs(actor))
+	}
+}
@@ -0,0 +1,176 @@
+package scopes_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/yanqin001/soft_delete"
+	"github.com/yanqin001/soft_delete/scopes"
+)
+
+type User struct {
+	ID        uint
+	Name      string
+	Deleted   soft_delete.DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt,DeletedByField:DeletedBy"`
+	DeletedAt *time.Time
+	DeletedBy *string
+}
+
+type Plain struct {
+	ID      uint
+	Deleted soft_delete.DeletedAt `gorm:"softDelete:flag"`
+}
+
+var now = time.Date(2023, 8, 1, 12, 0, 0, 0, time.UTC)
+
+// openDB 返回迁移了 User、Plain 的 SQLite db，当前时间由 clock 决定
+func openDB(t *testing.T, clock *time.Time) *gorm.DB {
+	t.Helper()
+	dsn := filepath.Join(t.TempDir(), "test.db")
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger:  logger.Discard,
+		NowFunc: func() time.Time { return *clock },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	if err := db.AutoMigrate(&User{}, &Plain{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// seed 创建 active 与按 actor、时间删除的 old、recent、mine，返回查询使用的 db
+func seed(t *testing.T) *gorm.DB {
+	t.Helper()
+	clock := now
+	db := openDB(t, &clock)
+	users := []User{{Name: "active"}, {Name: "old"}, {Name: "recent"}, {Name: "mine"}}
+	db.Create(&users)
+
+	deleteAs := func(u *User, actor string, at time.Time) {
+		clock = at
+		ctx := soft_delete.WithActor(context.Background(), actor)
+		if err := db.WithContext(ctx).Delete(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleteAs(&users[1], "bob", now.Add(-48*time.Hour))
+	deleteAs(&users[2], "bob", now.Add(-time.Hour))
+	deleteAs(&users[3], "alice", now.Add(-2*time.Hour))
+	clock = now
+	return db
+}
+
+func names(t *testing.T, db *gorm.DB) []string {
+	t.Helper()
+	var users []User
+	if err := db.Order("id").Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, u := range users {
+		names = append(names, u.Name)
+	}
+	return names
+}
+
+func TestScopes(t *testing.T) {
+	db := seed(t)
+
+	tests := []struct {
+		name   string
+		scopes []func(*gorm.DB) *gorm.DB
+		want   []string
+	}{
+		{"ActiveOnly", []func(*gorm.DB) *gorm.DB{scopes.ActiveOnly}, []string{"active"}},
+		{"DeletedOnly", []func(*gorm.DB) *gorm.DB{scopes.DeletedOnly}, []string{"old", "recent", "mine"}},
+		{"DeletedSince", []func(*gorm.DB) *gorm.DB{scopes.DeletedSince(3 * time.Hour)}, []string{"recent", "mine"}},
+		{"DeletedBetween", []func(*gorm.DB) *gorm.DB{scopes.DeletedBetween(now.Add(-72*time.Hour), now.Add(-90*time.Minute))}, []string{"old", "mine"}},
+		{"DeletedBy", []func(*gorm.DB) *gorm.DB{scopes.DeletedBy("bob")}, []string{"old", "recent"}},
+		{"DeletedSince and DeletedBy", []func(*gorm.DB) *gorm.DB{scopes.DeletedSince(3 * time.Hour), scopes.DeletedBy("bob")}, []string{"recent"}},
+		{"DeletedBetween and DeletedBy", []func(*gorm.DB) *gorm.DB{scopes.DeletedBetween(now.Add(-72*time.Hour), now), scopes.DeletedBy("alice")}, []string{"mine"}},
+		// 已删除的作用域优先于 ActiveOnly
+		{"ActiveOnly and DeletedBy", []func(*gorm.DB) *gorm.DB{scopes.ActiveOnly, scopes.DeletedBy("alice")}, []string{"mine"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(t, db.Scopes(tt.scopes...))
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestActiveOnlyOverridesWithDeleted(t *testing.T) {
+	db := seed(t)
+	got := names(t, db.Scopes(soft_delete.WithDeleted, scopes.ActiveOnly))
+	if len(got) != 1 || got[0] != "active" {
+		t.Errorf("got %v, want [active]", got)
+	}
+	ctx := soft_delete.IncludeDeletedContext(context.Background())
+	got = names(t, db.WithContext(ctx).Scopes(scopes.ActiveOnly))
+	if len(got) != 1 || got[0] != "active" {
+		t.Errorf("got %v with IncludeDeletedContext, want [active]", got)
+	}
+}
+
+// DeletedSince 的截止时间在每次执行时按 NowFunc 计算，同一个作用域随时钟推移得到不同的结果
+func TestDeletedSinceFollowsClock(t *testing.T) {
+	clock := now
+	db := openDB(t, &clock)
+	user := User{Name: "a"}
+	db.Create(&user)
+	db.Delete(&user)
+
+	since := scopes.DeletedSince(time.Hour)
+	if got := names(t, db.Scopes(since)); len(got) != 1 {
+		t.Fatalf("at deletion got %v, want [a]", got)
+	}
+	clock = now.Add(time.Hour - time.Second)
+	if got := names(t, db.Scopes(since)); len(got) != 1 {
+		t.Errorf("just inside the window got %v, want [a]", got)
+	}
+	clock = now.Add(time.Hour + time.Second)
+	if got := names(t, db.Scopes(since)); len(got) != 0 {
+		t.Errorf("after the window got %v, want none", got)
+	}
+}
+
+func TestScopesMissingCompanionField(t *testing.T) {
+	clock := now
+	db := openDB(t, &clock)
+	db.Create(&Plain{})
+
+	for name, scope := range map[string]func(*gorm.DB) *gorm.DB{
+		"DeletedSince":   scopes.DeletedSince(time.Hour),
+		"DeletedBetween": scopes.DeletedBetween(now.Add(-time.Hour), now),
+	} {
+		err := db.Scopes(scope).Find(&[]Plain{}).Error
+		if !errors.Is(err, soft_delete.ErrMissingDeletedAtField) {
+			t.Errorf("%s: err = %v, want ErrMissingDeletedAtField", name, err)
+		}
+	}
+	err := db.Scopes(scopes.DeletedBy("bob")).Find(&[]Plain{}).Error
+	if !errors.Is(err, soft_delete.ErrMissingDeletedByField) {
+		t.Errorf("DeletedBy: err = %v, want ErrMissingDeletedByField", err)
+	}
+}
@@ -0,0 +1,238 @@
+package soft_delete
+
+import (
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// seedUsers 创建 a、b、c 三个用户并删除 b
+func seedUsers(t *testing.T, db *gorm.DB) []User {
+	t.Helper()
+	users := []User{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Delete(&users[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+	return users
+}
+
+func TestOnlyDeleted(t *testing.T) {
+	db := openDB(t, &User{})
+	users := seedUsers(t, db)
+
+	var found []User
+	if err := db.Scopes(OnlyDeleted).Find(&found).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].ID != users[1].ID {
+		t.Errorf("Find = %+v", found)
+	}
+
+	var count int64
+	db.Model(&User{}).Scopes(OnlyDeleted).Count(&count)
+	if count != 1 {
+		t.Errorf("Count = %d, want 1", count)
+	}
+
+	var first User
+	if err := db.Scopes(OnlyDeleted).First(&first).Error; err != nil || first.Name != "b" {
+		t.Errorf("First = %+v, %v", first, err)
+	}
+
+	// 只有已删除的条件，没有同时要求未删除
+	sql := sqlOf(t, dryRun(db).Scopes(OnlyDeleted).Find(&found))
+	assertContains(t, sql, "`users`.`deleted` <> ?")
+	assertNotContains(t, sql, "`users`.`deleted` = ?")
+}
+
+func TestOnlyDeletedNullDefault(t *testing.T) {
+	db := openDB(t, &NullUser{})
+	sql := sqlOf(t, dryRun(db).Scopes(OnlyDeleted).Find(&[]NullUser{}))
+	assertContains(t, sql, "`null_users`.`deleted` IS NOT NULL")
+}
+
+type Account struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+	Profile Profile
+	Posts   []Post
+}
+
+type Profile struct {
+	ID        uint
+	AccountID uint
+	Bio       string
+	Deleted   DeletedAt `gorm:"softDelete:flag"`
+}
+
+type Post struct {
+	ID        uint
+	AccountID uint
+	Title     string
+	Deleted   DeletedAt `gorm:"softDelete:flag"`
+}
+
+// seedAccount 创建带有资料和两篇文章的账号，删除资料和第一篇文章
+func seedAccount(t *testing.T, db *gorm.DB) Account {
+	t.Helper()
+	account := Account{Name: "a", Profile: Profile{Bio: "bio"}, Posts: []Post{{Title: "p1"}, {Title: "p2"}}}
+	if err := db.Create(&account).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Delete(&account.Profile)
+	db.Delete(&account.Posts[0])
+	return account
+}
+
+func TestWithDeleted(t *testing.T) {
+	db := openDB(t, &User{})
+	users := seedUsers(t, db)
+
+	tx := db.Scopes(WithDeleted).Session(&gorm.Session{})
+	var found []User
+	if tx.Find(&found); len(found) != 3 {
+		t.Errorf("WithDeleted Find = %+v", found)
+	}
+	// 设置随 Session 传递
+	if tx.Session(&gorm.Session{}).Find(&found); len(found) != 3 {
+		t.Errorf("Find after Session = %+v", found)
+	}
+
+	// 同一会话中的删除仍然是软删除
+	if err := tx.Delete(&users[0]).Error; err != nil {
+		t.Fatal(err)
+	}
+	if countRows(t, db, "users") != 3 {
+		t.Error("Delete under WithDeleted removed the row")
+	}
+	if db.Find(&found); len(found) != 1 || found[0].Name != "c" {
+		t.Errorf("active = %+v", found)
+	}
+}
+
+func TestWithDeletedPreloadAndJoins(t *testing.T) {
+	db := openDB(t, &Account{}, &Profile{}, &Post{})
+	seedAccount(t, db)
+
+	var account Account
+	db.Preload("Posts").First(&account)
+	if len(account.Posts) != 1 {
+		t.Errorf("Preload posts = %+v", account.Posts)
+	}
+	account = Account{}
+	db.Scopes(WithDeleted).Preload("Posts").First(&account)
+	if len(account.Posts) != 2 {
+		t.Errorf("WithDeleted Preload posts = %+v", account.Posts)
+	}
+
+	account = Account{}
+	db.Joins("Profile").First(&account)
+	if account.Profile.ID != 0 {
+		t.Errorf("Joins loaded deleted profile %+v", account.Profile)
+	}
+	account = Account{}
+	db.Scopes(WithDeleted).Joins("Profile").First(&account)
+	if account.Profile.Bio != "bio" {
+		t.Errorf("WithDeleted Joins profile = %+v", account.Profile)
+	}
+}
+
+type Shop struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+	Orders  []ShopOrder
+	Notes   []ShopNote
+}
+
+type ShopOrder struct {
+	ID      uint
+	ShopID  uint
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+	Items   []ShopItem
+}
+
+type ShopNote struct {
+	ID      uint
+	ShopID  uint
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+type ShopItem struct {
+	ID          uint
+	ShopOrderID uint
+	Deleted     DeletedAt `gorm:"softDelete:flag"`
+}
+
+// 一条查询中主查询过滤、Orders 包含已删除、Notes 和更深的 Items 仍然过滤
+func TestPreloadWithDeleted(t *testing.T) {
+	db := openDB(t, &Shop{}, &ShopOrder{}, &ShopNote{}, &ShopItem{})
+	shops := []Shop{
+		{Orders: []ShopOrder{{Items: []ShopItem{{}, {}}}, {}}, Notes: []ShopNote{{}, {}}},
+		{},
+	}
+	db.Create(&shops)
+	db.Delete(&shops[1])
+	db.Delete(&shops[0].Orders[1])
+	db.Delete(&shops[0].Notes[0])
+	db.Delete(&shops[0].Orders[0].Items[0])
+
+	var loaded []Shop
+	err := db.Scopes(PreloadWithDeleted("Orders")).Preload("Orders.Items").Preload("Notes").Find(&loaded).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 1 {
+		t.Fatalf("shops = %d, want 1", len(loaded))
+	}
+	shop := loaded[0]
+	if len(shop.Orders) != 2 || len(shop.Notes) != 1 {
+		t.Errorf("orders = %d, notes = %d, want 2 and 1", len(shop.Orders), len(shop.Notes))
+	}
+	for _, order := range shop.Orders {
+		if order.ID == shops[0].Orders[0].ID && len(order.Items) != 1 {
+			t.Errorf("items = %d, want 1", len(order.Items))
+		}
+	}
+
+	// 嵌套关联可以单独指定
+	loaded = nil
+	db.Preload("Orders").Scopes(PreloadWithDeleted("Orders.Items")).Find(&loaded)
+	if len(loaded[0].Orders) != 1 || len(loaded[0].Orders[0].Items) != 2 {
+		t.Errorf("orders = %+v", loaded[0].Orders)
+	}
+}
+
+// Skip 只作用于当前语句：查询和更新不过滤，Delete 仍然是软删除
+func TestSkip(t *testing.T) {
+	db := openDB(t, &User{})
+	seedUsers(t, db)
+
+	var users []User
+	db.Clauses(Skip{}).Find(&users)
+	if len(users) != 3 {
+		t.Errorf("Skip Find = %d rows, want 3", len(users))
+	}
+	db.Find(&users)
+	if len(users) != 2 {
+		t.Errorf("Find after Skip = %d rows, want 2", len(users))
+	}
+
+	if tx := db.Clauses(Skip{}).Model(&User{}).Where("name = ?", "b").Updates(map[string]interface{}{"name": "b2"}); tx.RowsAffected != 1 {
+		t.Errorf("Skip Updates affected %d", tx.RowsAffected)
+	}
+
+	if err := db.Clauses(Skip{}).Delete(&User{}, "name = ?", "a").Error; err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, db, "users"); n != 3 {
+		t.Errorf("Skip Delete removed rows, %d left", n)
+	}
+	db.Find(&users)
+	if len(users) != 1 {
+		t.Errorf("active after Skip Delete = %d, want 1", len(users))
+	}
+}
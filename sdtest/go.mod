@@ -0,0 +1,25 @@
+module github.com/yanqin001/soft_delete/sdtest
+
+go 1.20
+
+require (
+	github.com/DATA-DOG/go-sqlmock v1.5.0
+	github.com/glebarez/sqlite v1.9.0
+	github.com/yanqin001/soft_delete v0.0.0-20261015102848-a1d77940a2ac
+	gorm.io/gorm v1.25.4
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/glebarez/go-sqlite v1.21.2 // indirect
+	github.com/google/uuid v1.3.0 // indirect
+	github.com/jinzhu/inflection v1.0.0 // indirect
+	github.com/jinzhu/now v1.1.5 // indirect
+	github.com/mattn/go-isatty v0.0.19 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.11.0 // indirect
+	modernc.org/libc v1.22.5 // indirect
+	modernc.org/mathutil v1.5.0 // indirect
+	modernc.org/memory v1.5.0 // indirect
+	modernc.org/sqlite v1.23.1 // indirect
+)
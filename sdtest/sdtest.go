@@ -0,0 +1,120 @@
+// Package sdtest 为使用 go-sqlmock 的测试提供软删除语句的预期，正则按本包生成的 SQL 构造，
+// 兼容各数据库的引号（`、"、[]）和占位符（?、$1、@p1、:1），需要使用 sqlmock 默认的 QueryMatcherRegexp：
+//
+//	sdtest.ExpectSoftDelete(mock, "users", 1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+//	db.Delete(&User{}, []uint{1, 2})
+//
+// 删除、恢复在事务中执行时，BEGIN、COMMIT 的预期仍需要自行添加
+package sdtest
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"strings"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// placeholder 匹配各数据库的参数占位符
+const placeholder = `(?:\?|\$\d+|@p\d+|:\d+)`
+
+// Table 描述表的软删除列，零值的字段使用默认的列名
+type Table struct {
+	Name string
+	// PrimaryKey 为主键列，默认为 id
+	PrimaryKey string
+	// Column 为软删除列，默认为 deleted
+	Column string
+	// Companions 为删除、恢复时与软删除列一起写入的列，按模型中的顺序，例如 DeletedAtField、DeletedByField 对应的列
+	Companions []string
+}
+
+// ExpectSoftDelete 预期按主键软删除 table 中 ids 的记录，软删除列为 deleted、主键为 id，其他列名使用 Table
+func ExpectSoftDelete(mock sqlmock.Sqlmock, table string, ids ...driver.Value) *sqlmock.ExpectedExec {
+	return Table{Name: table}.ExpectSoftDelete(mock, ids...)
+}
+
+// ExpectRestore 预期按主键恢复 table 中 ids 的记录，软删除列为 deleted、主键为 id，其他列名使用 Table
+func ExpectRestore(mock sqlmock.Sqlmock, table string, ids ...driver.Value) *sqlmock.ExpectedExec {
+	return Table{Name: table}.ExpectRestore(mock, ids...)
+}
+
+// ExpectFilteredSelect 预期查询 table 并带有软删除列 deleted 的过滤条件，参数由调用方按需要以 WithArgs 检查
+func ExpectFilteredSelect(mock sqlmock.Sqlmock, table string) *sqlmock.ExpectedQuery {
+	return Table{Name: table}.ExpectFilteredSelect(mock)
+}
+
+// ExpectSoftDelete 预期按主键软删除 ids 的记录：UPDATE 写入软删除列和伴随列，条件为主键和未删除。
+// ids 为空时不检查主键和参数，可以匹配按任意条件的删除
+func (t Table) ExpectSoftDelete(mock sqlmock.Sqlmock, ids ...driver.Value) *sqlmock.ExpectedExec {
+	return t.expectUpdate(mock, "=", ids)
+}
+
+// ExpectRestore 预期按主键恢复 ids 的记录：UPDATE 写入软删除列和伴随列，条件为主键和已删除。
+// ids 为空时不检查主键和参数，可以匹配 RestoreWhere
+func (t Table) ExpectRestore(mock sqlmock.Sqlmock, ids ...driver.Value) *sqlmock.ExpectedExec {
+	return t.expectUpdate(mock, "<>", ids)
+}
+
+// ExpectFilteredSelect 预期查询表并带有软删除列的过滤条件
+func (t Table) ExpectFilteredSelect(mock sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`^SELECT .* FROM ` + quoted(t.Name) + ` .*WHERE .*` + t.column(t.flagColumn()) +
+		` (?:= ` + placeholder + `|IS NULL)`)
+}
+
+// expectUpdate 预期 UPDATE 语句，op 为软删除列过滤条件的比较符
+func (t Table) expectUpdate(mock sqlmock.Sqlmock, op string, ids []driver.Value) *sqlmock.ExpectedExec {
+	set := quoted(t.flagColumn()) + "=" + placeholder
+	for _, companion := range t.Companions {
+		set += "," + quoted(companion) + "=" + placeholder
+	}
+	var keys string
+	if len(ids) > 0 {
+		keys = t.column(t.primaryKey()) + ` ` + keysPattern(len(ids)) + ` AND `
+	}
+	exec := mock.ExpectExec(`^UPDATE ` + quoted(t.Name) + ` SET ` + set + ` WHERE (?:.* AND )?` + keys +
+		t.column(t.flagColumn()) + ` ` + op + ` ` + placeholder)
+	if len(ids) == 0 {
+		return exec
+	}
+
+	args := make([]driver.Value, 0, len(ids)+len(t.Companions)+2)
+	for i := 0; i <= len(t.Companions); i++ {
+		args = append(args, sqlmock.AnyArg())
+	}
+	args = append(args, ids...)
+	args = append(args, sqlmock.AnyArg())
+	return exec.WithArgs(args...)
+}
+
+func (t Table) primaryKey() string {
+	if t.PrimaryKey == "" {
+		return "id"
+	}
+	return t.PrimaryKey
+}
+
+func (t Table) flagColumn() string {
+	if t.Column == "" {
+		return "deleted"
+	}
+	return t.Column
+}
+
+// column 匹配可能带有表名的列
+func (t Table) column(name string) string {
+	return `(?:` + quoted(t.Name) + `\.)?` + quoted(name)
+}
+
+// quoted 匹配以任意数据库的引号括起或没有引号的名称
+func quoted(name string) string {
+	return "[`\"\\[]?" + regexp.QuoteMeta(name) + "[`\"\\]]?"
+}
+
+// keysPattern 匹配 n 个主键的条件，一个主键时为 = ?，多个时为 IN (?,?)
+func keysPattern(n int) string {
+	if n == 1 {
+		return `= ` + placeholder
+	}
+	return `IN \(` + placeholder + strings.Repeat(`,`+placeholder, n-1) + `\)`
+}
@@ -0,0 +1,140 @@
+package sdtest_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/logger"
+
+	"github.com/yanqin001/soft_delete"
+	"github.com/yanqin001/soft_delete/sdtest"
+)
+
+type User struct {
+	ID      uint
+	Name    string
+	Deleted soft_delete.DeletedAt `gorm:"softDelete:flag"`
+}
+
+type Audited struct {
+	ID        uint
+	Deleted   soft_delete.DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt,DeletedByField:DeletedBy"`
+	DeletedAt *time.Time
+	DeletedBy *string
+}
+
+// postgresDialector 以 PostgreSQL 的引号和占位符生成 SQL
+type postgresDialector struct {
+	sqlite.Dialector
+}
+
+func (postgresDialector) Name() string {
+	return "postgres"
+}
+
+func (postgresDialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
+	writer.WriteString("$" + strconv.Itoa(len(stmt.Vars)))
+}
+
+func (postgresDialector) QuoteTo(writer clause.Writer, str string) {
+	writer.WriteByte('"')
+	writer.WriteString(str)
+	writer.WriteByte('"')
+}
+
+// openMock 返回以 sqlmock 为连接的 db，dialect 决定生成的 SQL
+func openMock(t *testing.T, dialect string) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	mock.ExpectQuery("select sqlite_version").WillReturnRows(sqlmock.NewRows([]string{"version"}).AddRow("3.41.0"))
+
+	var dialector gorm.Dialector = sqlite.Dialector{Conn: conn}
+	if dialect == "postgres" {
+		dialector = postgresDialector{sqlite.Dialector{Conn: conn}}
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Discard, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
+
+// 以插件实际生成的语句检查各预期，插件的 SQL 改变时此测试失败
+func TestExpectations(t *testing.T) {
+	for _, dialect := range []string{"sqlite", "postgres"} {
+		t.Run(dialect, func(t *testing.T) {
+			db, mock := openMock(t, dialect)
+
+			sdtest.ExpectSoftDelete(mock, "users", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			if err := db.Delete(&User{ID: 1}).Error; err != nil {
+				t.Errorf("delete one: %v", err)
+			}
+
+			sdtest.ExpectSoftDelete(mock, "users", 1, 2).WillReturnResult(sqlmock.NewResult(0, 2))
+			if err := db.Delete(&User{}, []uint{1, 2}).Error; err != nil {
+				t.Errorf("delete many: %v", err)
+			}
+
+			sdtest.ExpectSoftDelete(mock, "users").WillReturnResult(sqlmock.NewResult(0, 1))
+			if err := db.Where("name = ?", "a").Delete(&User{}).Error; err != nil {
+				t.Errorf("delete by condition: %v", err)
+			}
+
+			mock.ExpectBegin()
+			sdtest.ExpectRestore(mock, "users", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			if _, err := soft_delete.Restore(db, &User{ID: 1}); err != nil {
+				t.Errorf("restore: %v", err)
+			}
+
+			sdtest.ExpectFilteredSelect(mock, "users").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted"}).AddRow(1, "a", false))
+			var users []User
+			if err := db.Where("name = ?", "a").Find(&users).Error; err != nil || len(users) != 1 {
+				t.Errorf("select: %+v, %v", users, err)
+			}
+
+			audited := sdtest.Table{Name: "auditeds", Companions: []string{"deleted_at", "deleted_by"}}
+			audited.ExpectSoftDelete(mock, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+			ctx := soft_delete.WithActor(context.Background(), "admin")
+			if err := db.WithContext(ctx).Delete(&Audited{ID: 3}).Error; err != nil {
+				t.Errorf("delete with companions: %v", err)
+			}
+			mock.ExpectBegin()
+			audited.ExpectRestore(mock, 3).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+			if _, err := soft_delete.Restore(db, &Audited{ID: 3}); err != nil {
+				t.Errorf("restore with companions: %v", err)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+// 预期不会匹配其他表或没有过滤条件的语句
+func TestExpectationsReject(t *testing.T) {
+	db, mock := openMock(t, "sqlite")
+
+	sdtest.ExpectSoftDelete(mock, "users", 1)
+	if err := db.Delete(&User{ID: 2}).Error; err == nil {
+		t.Error("delete of another key should not match")
+	}
+
+	db, mock = openMock(t, "sqlite")
+	sdtest.ExpectFilteredSelect(mock, "users")
+	if err := db.Unscoped().Find(&[]User{}).Error; err == nil {
+		t.Error("unfiltered select should not match")
+	}
+}
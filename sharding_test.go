@@ -0,0 +1,40 @@
+package soft_delete
+
+import (
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// shardDB 模拟分表插件，在构建 SQL 之前将表名改为 users_1
+func shardDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := dryRunDB(t, "sqlite")
+	shard := func(tx *gorm.DB) {
+		if tx.Statement.Table == "users" {
+			tx.Statement.Table = "users_1"
+		}
+	}
+	db.Callback().Query().Before("gorm:query").Register("test:shard", shard)
+	db.Callback().Delete().Before("gorm:delete").Register("test:shard", shard)
+	db.Callback().Update().Before("gorm:update").Register("test:shard", shard)
+	return db
+}
+
+func TestShardedTableName(t *testing.T) {
+	db := shardDB(t)
+	tests := map[string]*gorm.DB{
+		"query":  db.Where("name = ?", "a").Find(&[]User{}),
+		"delete": db.Delete(&[]User{{ID: 1}, {ID: 2}}),
+		"where":  db.Where("name = ?", "a").Delete(&User{}),
+		"update": db.Model(&User{ID: 1}).Update("name", "b"),
+	}
+	for name, tx := range tests {
+		sql := sqlOf(t, tx)
+		assertContains(t, sql, "`users_1`")
+		if strings.Contains(strings.ReplaceAll(sql, "`users_1`", ""), "`users`") {
+			t.Errorf("%s: %q still references the unsharded table", name, sql)
+		}
+	}
+}
@@ -3,181 +3,928 @@ package soft_delete
 import (
 	"database/sql/driver"
 	"errors"
+	"fmt"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/schema"
 	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type DeletedAt bool
 
+// ErrMissingSoftDeleteField 模型中没有本包的软删除字段
+var ErrMissingSoftDeleteField = errors.New("soft_delete: model has no soft delete field")
+
+// ErrUnsupportedClause 删除语句带有 ORDER BY、LIMIT，而数据库的 UPDATE 不支持
+var ErrUnsupportedClause = errors.New("soft_delete: clause not supported in UPDATE by this dialect")
+
+// FlagDeleted、FlagActived 为 DeletedAt 已删除和未删除时的 bool 值，在解析模型时读取
+//
+// Deprecated: 运行时修改会产生数据竞争，自定义取值使用标签 ActiveValue、DeletedValue
 var (
 	FlagDeleted = true
 	FlagActived = false
 )
 
+// ValueMode 决定标记位写入数据库时使用的类型
+type ValueMode int
+
+const (
+	// ValueBool 以 bool 写入，适用于原生支持布尔类型的数据库
+	ValueBool ValueMode = iota
+	// ValueInt 以 int64 的 0/1 写入，适用于 SQLite、旧版 MySQL 等整数列
+	ValueInt
+)
+
+var valueMode = ValueBool
+
+// SetValueMode 设置标记位的写入类型，应在解析模型之前调用
+//
+// Deprecated: 运行时修改会产生数据竞争，使用 Use 为 db 设置 Config.ValueMode
+func SetValueMode(mode ValueMode) {
+	valueMode = mode
+}
+
+func flagValue(flag bool) driver.Value {
+	if valueMode == ValueInt {
+		if flag {
+			return int64(1)
+		}
+		return int64(0)
+	}
+	return flag
+}
+
+// 软删除子句的名称，语句使用了软删除字段时会以这些名称记录在 stmt.Clauses 中，
+// 其他插件可以据此判断语句是否由软删除处理
+const (
+	ClauseQuery  = "soft_delete:query"
+	ClauseDelete = "soft_delete:delete"
+	ClauseUpdate = "soft_delete:update"
+	ClauseCreate = "soft_delete:create"
+)
+
+// recordClause 将子句以其名称记录在语句中。gorm 对实现了 StatementModifier 的子句只调用 ModifyStatement，不会保存
+func recordClause(stmt *gorm.Statement, c clause.Interface) {
+	name := c.Name()
+	cl := stmt.Clauses[name]
+	cl.Name = name
+	// 软删除子句的 MergeClause 都只保存自身，直接保存已经转换为接口的 c，避免再分配一次
+	cl.Expression = c
+	stmt.Clauses[name] = cl
+}
+
 func (DeletedAt) QueryClauses(f *schema.Field) []clause.Interface {
-	return []clause.Interface{SoftDeleteQueryClause{Field: f}}
+	return flagQueryClauses(f, deletedAtValues(), deletedAtFromDeleted)
+}
+
+func deletedAtFromDeleted(deleted bool) interface{} {
+	return DeletedAt(deleted)
 }
 
 // 实现 driver.Valuer 接口，将 BoolType 转换为数据库中的值
 func (b DeletedAt) Value() (driver.Value, error) {
-	if b {
-		return true, nil
+	return flagValue(bool(b)), nil
+}
+
+// IsDeleted 判断记录是否已删除，不依赖标记的具体表示
+func (b DeletedAt) IsDeleted() bool {
+	return bool(b) == FlagDeleted
+}
+
+// IsActive 判断记录是否未删除
+func (b DeletedAt) IsActive() bool {
+	return !b.IsDeleted()
+}
+
+// String 返回 "deleted" 或 "active"
+func (b DeletedAt) String() string {
+	return stateString(b.IsDeleted())
+}
+
+// MarkDeleted 将标记设为已删除，只修改内存中的值，需要调用方自行 Save
+func (b *DeletedAt) MarkDeleted() {
+	*b = DeletedAt(FlagDeleted)
+}
+
+// MarkActive 将标记设为未删除，只修改内存中的值，需要调用方自行 Save
+func (b *DeletedAt) MarkActive() {
+	*b = DeletedAt(FlagActived)
+}
+
+func stateString(deleted bool) string {
+	if deleted {
+		return "deleted"
 	}
-	return false, nil
+	return "active"
 }
 
 // 实现 sql.Scanner 接口，从数据库中的值将其转换为 BoolType
+// 兼容 MySQL TINYINT(1) 等以整数、字节或字符串返回的驱动，非零即视为已删除；
+// 也接受 gorm.DeletedAt 的时间戳列，非 NULL 的时间视为已删除
 func (b *DeletedAt) Scan(value interface{}) error {
-	boolVal, ok := value.(bool)
-	if !ok {
-		return errors.New("Invalid data type for DeletedAt")
+	switch v := value.(type) {
+	case nil:
+		*b = DeletedAt(FlagActived)
+	case bool:
+		*b = DeletedAt(v)
+	case int64:
+		*b = v != 0
+	case uint8:
+		*b = v != 0
+	case float64:
+		*b = v != 0
+	case time.Time:
+		// 从 gorm.DeletedAt 迁移时读取旧的时间戳列，NULL 已在上面处理
+		*b = DeletedAt(!v.IsZero())
+	case []byte:
+		return b.scanString(string(v))
+	case string:
+		return b.scanString(v)
+	default:
+		// 驱动自定义的数值类型，例如 Oracle 的 godror.Number
+		switch rv := reflect.ValueOf(value); rv.Kind() {
+		case reflect.Bool:
+			*b = DeletedAt(rv.Bool())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			*b = rv.Int() != 0
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			*b = rv.Uint() != 0
+		case reflect.Float32, reflect.Float64:
+			*b = rv.Float() != 0
+		case reflect.String:
+			return b.scanString(rv.String())
+		default:
+			return fmt.Errorf("invalid data type for DeletedAt: %T", value)
+		}
 	}
 
-	if boolVal {
-		*b = true
-	} else {
-		*b = false
+	return nil
+}
+
+func (b *DeletedAt) scanString(s string) error {
+	if boolVal, err := strconv.ParseBool(s); err == nil {
+		*b = DeletedAt(boolVal)
+		return nil
 	}
 
-	return nil
+	if floatVal, err := strconv.ParseFloat(s, 64); err == nil {
+		*b = floatVal != 0
+		return nil
+	}
+
+	return fmt.Errorf("invalid value for DeletedAt: %q", s)
+}
+
+// isNullDefault 判断标记列是否以 NULL 表示未删除，即 `default:null` 的可空列
+func isNullDefault(f *schema.Field) bool {
+	return strings.EqualFold(f.DefaultValue, "null")
+}
+
+// activeValue 返回字段处于未删除状态、即恢复时写入数据库的值，flag 为 false 时字段为时间戳
+func activeValue(f *schema.Field, flag bool) interface{} {
+	if isNullDefault(f) {
+		return nil
+	}
+	if flag {
+		if values := flagValuesOf(f); values.mask != 0 {
+			return bitAssign{column: clause.Column{Name: f.DBName}, mask: values.mask}
+		}
+		return flagValuesOf(f).active
+	}
+	return 0
+}
+
+// activeExpr 返回 column 处于未删除状态的条件。取值总是作为参数绑定，生成 `deleted` = ? 而不是字面量，
+// PrepareStmt 下与手写的 deleted = ? 共用同一条预处理语句；只有以 NULL 表示未删除的列生成 IS NULL
+func activeExpr(f *schema.Field, flag bool, column clause.Column) clause.Expression {
+	if values := flagValuesOf(f); flag && values.expires {
+		return expiryExpr{column: column}
+	} else if flag && values.mask != 0 {
+		return bitExpr{column: column, mask: values.mask}
+	} else if flag && values.byDeleted {
+		return clause.Neq{Column: column, Value: sqlValue(values.deleted)}
+	} else if flag && values.nullable && !isNullDefault(f) {
+		return nullableExpr{column: column, active: sqlValue(values.active), orNull: !values.nullDeleted}
+	}
+	return clause.Eq{Column: column, Value: sqlValue(activeValue(f, flag))}
+}
+
+// deletedExpr 返回 column 处于已删除状态的条件
+func deletedExpr(f *schema.Field, flag bool, column clause.Column) clause.Expression {
+	if values := flagValuesOf(f); flag && values.expires {
+		return expiryExpr{column: column, expired: true}
+	} else if flag && values.mask != 0 {
+		return bitExpr{column: column, mask: values.mask, deleted: true}
+	} else if flag && values.byDeleted {
+		return clause.Eq{Column: column, Value: sqlValue(values.deleted)}
+	} else if flag && values.nullable && !isNullDefault(f) {
+		return nullableExpr{column: column, active: sqlValue(values.active), deleted: true, orNull: values.nullDeleted}
+	}
+	return clause.Neq{Column: column, Value: sqlValue(activeValue(f, flag))}
+}
+
+// nullableExpr 为指针标记字段的条件，orNull 时 NULL 也满足条件
+type nullableExpr struct {
+	column  clause.Column
+	active  interface{}
+	deleted bool
+	orNull  bool
 }
 
+func (e nullableExpr) Build(builder clause.Builder) {
+	var expr clause.Expression = clause.Eq{Column: e.column, Value: e.active}
+	if e.deleted {
+		expr = clause.Neq{Column: e.column, Value: e.active}
+	}
+	if e.orNull {
+		expr = clause.Or(expr, clause.Eq{Column: e.column, Value: nil})
+	}
+	expr.Build(builder)
+}
+
+// filterExprs 为字段以 clause.CurrentTable 为表时未删除和已删除的条件。条件只由字段决定，
+// 第一次使用时生成并按字段缓存，之后每条语句共用同一个不可变的表达式，不再分配
+type filterExprs struct {
+	active  clause.Expression
+	deleted clause.Expression
+}
+
+type filterExprsKey struct {
+	field *schema.Field
+	flag  bool
+}
+
+var fieldFilterExprs sync.Map
+
+// filterExprsOf 返回 column 的未删除和已删除条件，column 为 clause.CurrentTable 时使用缓存
+func filterExprsOf(f *schema.Field, flag bool, column clause.Column) filterExprs {
+	if column.Table != clause.CurrentTable || column.Name != f.DBName {
+		return filterExprs{active: activeExpr(f, flag, column), deleted: deletedExpr(f, flag, column)}
+	}
+	key := filterExprsKey{field: f, flag: flag}
+	if exprs, ok := fieldFilterExprs.Load(key); ok {
+		return exprs.(filterExprs)
+	}
+	exprs, _ := fieldFilterExprs.LoadOrStore(key, filterExprs{active: activeExpr(f, flag, column), deleted: deletedExpr(f, flag, column)})
+	return exprs.(filterExprs)
+}
+
+// SoftDeleteQueryClause 为查询添加过滤条件。Find、Pluck、Count、Distinct、Group、Select 聚合以及 Row、Rows
+// 都由模型的 QueryClauses 添加，过滤条件总是与整个 WHERE AND。只用 Table 而没有 Model 的语句
+// 没有 schema，不会过滤，例如 db.Table("users").Pluck("email", &emails)，需要写成 db.Model(&User{})
 type SoftDeleteQueryClause struct {
 	Field *schema.Field
+	Flag  bool
+	// DisableDefaultScope 由标签中的 DisableDefaultScope 设置，查询默认包含已删除的记录，
+	// Active、OnlyDeleted 仍然可以为单条语句过滤；更新和删除不受影响
+	//
+	//	Deleted soft_delete.DeletedAt `gorm:"softDelete:flag,DisableDefaultScope"`
+	DisableDefaultScope bool
+	// TimestampColumn 由标签中的 TimestampColumn 设置，用于从 gorm.DeletedAt 迁移到标记位的过程中，
+	// 查询仍按旧的时间戳列是否为 NULL 过滤，删除、更新、恢复使用标记列
+	//
+	//	Deleted soft_delete.DeletedAt `gorm:"softDelete:flag,TimestampColumn:deleted_at"`
+	TimestampColumn string
+	// LegacyTimestampField 由标签中的 LegacyTimestampField 设置，新旧代码同时运行期间，
+	// 查询同时要求标记列和旧的时间戳列为未删除；声明 LegacyTimestampMatch:OR 时满足其一即可
+	LegacyTimestampField *schema.Field
+	LegacyMatchOr        bool
+
+	tombstone tombstoneMode
+}
+
+// withQuerySettings 按标签中的 DisableDefaultScope、TimestampColumn 设置查询的过滤
+func (sd SoftDeleteQueryClause) withQuerySettings() SoftDeleteQueryClause {
+	settings := parseSettings(sd.Field)
+	_, sd.DisableDefaultScope = settings["DISABLEDEFAULTSCOPE"]
+	sd.TimestampColumn = settings["TIMESTAMPCOLUMN"]
+	sd.tombstone = parseTombstone(settings)
+	if name := settings["LEGACYTIMESTAMPFIELD"]; name != "" {
+		sd.LegacyTimestampField = sd.Field.Schema.LookUpField(name)
+		sd.LegacyMatchOr = strings.EqualFold(settings["LEGACYTIMESTAMPMATCH"], "OR")
+	}
+	return sd
 }
 
 func (sd SoftDeleteQueryClause) Name() string {
-	return ""
+	return ClauseQuery
 }
 
 func (sd SoftDeleteQueryClause) Build(clause.Builder) {
 }
 
-func (sd SoftDeleteQueryClause) MergeClause(*clause.Clause) {
+// MergeClause 重复添加时保留最后一个，不会产生多个子句
+func (sd SoftDeleteQueryClause) MergeClause(c *clause.Clause) {
+	c.Expression = sd
 }
 
 func (sd SoftDeleteQueryClause) ModifyStatement(stmt *gorm.Statement) {
-	if _, ok := stmt.Clauses["soft_delete_enabled"]; !ok && !stmt.Statement.Unscoped {
-		if c, ok := stmt.Clauses["WHERE"]; ok {
-			if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) >= 1 {
-				for _, expr := range where.Exprs {
-					if orCond, ok := expr.(clause.OrConditions); ok && len(orCond.Exprs) == 1 {
-						where.Exprs = []clause.Expression{clause.And(where.Exprs...)}
-						c.Expression = where
-						stmt.Clauses["WHERE"] = c
-						break
-					}
-				}
-			}
+	recordClause(stmt, sd)
+	if err := bitFieldError(sd.Field); err != nil {
+		stmt.AddError(err)
+		return
+	}
+	sd.applyTombstone(stmt)
+	sd.applyFilter(stmt)
+}
+
+// applyFilter 根据 AsOf、WithDeleted、OnlyDeleted、Skip 等开关添加或去掉过滤条件
+func (sd SoftDeleteQueryClause) applyFilter(stmt *gorm.Statement) {
+	if expr := sd.asOfExpr(stmt); expr != nil {
+		sd.addExpr(stmt, expr)
+		return
+	}
+	if !isOnlyDeleted(stmt) && !isActive(stmt) &&
+		(sd.DisableDefaultScope || isWithDeleted(stmt) || isIncludeDeleted(stmt.Context) || isSkipped(stmt)) {
+		sd.removeFilter(stmt)
+		sd.recordDecision(stmt, nil, sd.skipReason(stmt))
+		return
+	}
+	sd.addFilter(stmt)
+}
+
+// addFilter 添加未删除的过滤条件，删除子句直接调用，不受 WithDeleted 等查询开关影响。
+// 过滤条件总是与已有的全部条件 AND，重复执行时先去掉上次添加的条件，保证只有一个并且位于最后
+func (sd SoftDeleteQueryClause) addFilter(stmt *gorm.Statement) {
+	exprs := sd.filterExprs(stmt)
+	if isOnlyDeleted(stmt) {
+		sd.addExpr(stmt, exprs.deleted)
+	} else {
+		sd.addExpr(stmt, exprs.active)
+	}
+}
+
+// addExpr 去掉之前的过滤条件，将 expr 作为过滤条件与已有的全部条件 AND。
+// 实现了 ScopedExpression 的条件不参与 OR 的分组，并且总是排在过滤条件之后，
+// 因此无论其他插件的子句在之前还是之后执行，生成的 SQL 都相同
+func (sd SoftDeleteQueryClause) addExpr(stmt *gorm.Statement, expr clause.Expression) {
+	sd.removeFilter(stmt)
+	if stmt.Statement.Unscoped {
+		sd.recordDecision(stmt, nil, SkipUnscoped)
+		return
+	}
+
+	c := stmt.Clauses["WHERE"]
+	where, _ := c.Expression.(clause.Where)
+	exprs := make([]clause.Expression, 0, len(where.Exprs)+1)
+	for _, e := range where.Exprs {
+		if _, ok := e.(ScopedExpression); !ok {
+			exprs = append(exprs, e)
+		}
+	}
+	if hasOrConditions(exprs) {
+		exprs = append(exprs[:0:0], clause.And(exprs...))
+	}
+	exprs = append(exprs, expr)
+	for _, e := range where.Exprs {
+		if _, ok := e.(ScopedExpression); ok {
+			exprs = append(exprs, e)
 		}
+	}
 
-		if sd.Field.DefaultValue == "null" {
-			stmt.AddClause(clause.Where{Exprs: []clause.Expression{
-				clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: sd.Field.DBName}, Value: nil},
-			}})
-		} else {
-			stmt.AddClause(clause.Where{Exprs: []clause.Expression{
-				clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: sd.Field.DBName}, Value: FlagActived},
-			}})
+	c.Name = "WHERE"
+	c.Expression = clause.Where{Exprs: exprs}
+	stmt.Clauses["WHERE"] = c
+	stmt.AddClause(filterEnabled{})
+	sd.recordDecision(stmt, expr, "")
+}
+
+// ScopedExpression 由其他插件的条件实现，声明条件已经是独立的作用域，例如租户条件。
+// 软删除的过滤条件不会将它与查询条件一起分组，也不会改写它
+type ScopedExpression interface {
+	clause.Expression
+	SoftDeleteScoped()
+}
+
+// Scoped 将插件添加的条件包装为 ScopedExpression：
+//
+//	stmt.AddClause(clause.Where{Exprs: []clause.Expression{
+//		soft_delete.Scoped(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "tenant_id"}, Value: tenantID}),
+//	}})
+func Scoped(expr clause.Expression) ScopedExpression {
+	return scopedExpr{Expression: expr}
+}
+
+type scopedExpr struct {
+	clause.Expression
+}
+
+func (scopedExpr) SoftDeleteScoped() {}
+
+// Build 与其他条件 AND 时，包含 AND、OR 的条件需要加上括号，gorm 只为它自己的表达式类型加括号
+func (e scopedExpr) Build(builder clause.Builder) {
+	if !needsParentheses(e.Expression) {
+		e.Expression.Build(builder)
+		return
+	}
+	builder.WriteByte('(')
+	e.Expression.Build(builder)
+	builder.WriteByte(')')
+}
+
+func needsParentheses(expr clause.Expression) bool {
+	switch v := expr.(type) {
+	// 多个条件的 AndConditions、OrConditions 自己会加上括号
+	case clause.AndConditions:
+		return len(v.Exprs) == 1 && needsParentheses(v.Exprs[0])
+	case clause.OrConditions:
+		return len(v.Exprs) == 1 && needsParentheses(v.Exprs[0])
+	case clause.Expr:
+		return hasLogicalOperator(v.SQL)
+	case clause.NamedExpr:
+		return hasLogicalOperator(v.SQL)
+	}
+	return false
+}
+
+func hasLogicalOperator(sql string) bool {
+	sql = strings.ToUpper(sql)
+	return strings.Contains(sql, " AND ") || strings.Contains(sql, " OR ")
+}
+
+// removeFilter 去掉语句中之前添加的过滤条件和标记。复用的语句再次执行时
+// 可能已经不需要过滤，例如之后加上了 Unscoped 或 WithDeleted。
+// 过滤条件按 WHERE 的内容查找，不依赖标记：FindInBatches 等复用语句时标记与 WHERE 可能不一致
+func (sd SoftDeleteQueryClause) removeFilter(stmt *gorm.Statement) {
+	delete(stmt.Clauses, softDeleteEnabledClauseName)
+
+	c, ok := stmt.Clauses["WHERE"]
+	if !ok {
+		return
+	}
+	where, ok := c.Expression.(clause.Where)
+	if !ok {
+		return
+	}
+
+	filters := sd.filterExprs(stmt)
+	active, deleted := filters.active, filters.deleted
+	exprs := make([]clause.Expression, 0, len(where.Exprs))
+	for _, expr := range where.Exprs {
+		if _, asOf := expr.(validAt); !asOf && expr != active && expr != deleted {
+			exprs = append(exprs, expr)
 		}
-		stmt.Clauses["soft_delete_enabled"] = clause.Clause{}
 	}
+	if len(exprs) == 0 {
+		delete(stmt.Clauses, "WHERE")
+		return
+	}
+	where.Exprs = exprs
+	c.Expression = where
+	stmt.Clauses["WHERE"] = c
 }
 
-func (DeletedAt) DeleteClauses(f *schema.Field) []clause.Interface {
-	softDeleteClause := SoftDeleteDeleteClause{
-		Field:    f,
-		DataType: getTimeType(),
+// filterColumn 返回过滤的列。sd.Field 是 gorm 解析完整个模型后传给 QueryClauses 的字段，
+// 嵌入结构体中的字段 DBName 已带有各层 embeddedPrefix，例如 audit_in_deleted
+func (sd SoftDeleteQueryClause) filterColumn(stmt *gorm.Statement) clause.Column {
+	return clause.Column{Table: filterTable(stmt), Name: sd.Field.DBName}
+}
+
+// filterExprs 返回未删除和已删除的条件，声明了 TimestampColumn 时为时间戳列 IS NULL、IS NOT NULL
+func (sd SoftDeleteQueryClause) filterExprs(stmt *gorm.Statement) filterExprs {
+	column := sd.filterColumn(stmt)
+	if sd.TimestampColumn != "" {
+		column.Name = sd.TimestampColumn
+		return filterExprs{active: clause.Eq{Column: column, Value: nil}, deleted: clause.Neq{Column: column, Value: nil}}
+	}
+	exprs := filterExprsOf(sd.Field, sd.Flag, column)
+	if legacy := sd.LegacyTimestampField; legacy != nil {
+		column.Name = legacy.DBName
+		exprs.active = legacyExpr{flag: exprs.active, column: column, or: sd.LegacyMatchOr}
+		exprs.deleted = legacyExpr{flag: exprs.deleted, column: column, deleted: true, or: !sd.LegacyMatchOr}
+	}
+	return exprs
+}
+
+// legacyExpr 将标记列的条件与旧的时间戳列是否为 NULL 组合，未删除时为 IS NULL，已删除时为 IS NOT NULL。
+// 字段都可以比较，removeFilter 能够找到之前添加的条件
+type legacyExpr struct {
+	flag    clause.Expression
+	column  clause.Column
+	deleted bool
+	or      bool
+}
+
+func (e legacyExpr) Build(builder clause.Builder) {
+	var legacy clause.Expression = clause.Eq{Column: e.column, Value: nil}
+	if e.deleted {
+		legacy = clause.Neq{Column: e.column, Value: nil}
+	}
+	builder.WriteByte('(')
+	e.flag.Build(builder)
+	if e.or {
+		builder.WriteString(" OR ")
+	} else {
+		builder.WriteString(" AND ")
+	}
+	legacy.Build(builder)
+	builder.WriteByte(')')
+}
+
+// hasOrConditions 判断条件中是否有 gorm 以 OR 连接的表达式，即只包含一个条件的 OrConditions，
+// 它在任何位置都会与前一个条件 OR，追加的过滤条件必须与整体分组后再 AND
+func hasOrConditions(exprs []clause.Expression) bool {
+	for _, expr := range exprs {
+		if orCond, ok := expr.(clause.OrConditions); ok && len(orCond.Exprs) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// softDeleteEnabledClauseName 为 gorm 的 checkMissingWhereConditions 识别的子句名，
+// 存在时 WHERE 中只有软删除条件仍然视为没有条件
+const softDeleteEnabledClauseName = "soft_delete_enabled"
+
+// filterEnabled 标记语句已添加过滤条件，重复添加只保留一个。Session 复制语句时会带上标记，
+// 因此过滤条件总是按语句自己的 WHERE 重新整理，标记只用于 gorm 的检查
+type filterEnabled struct{}
+
+func (filterEnabled) Name() string {
+	return softDeleteEnabledClauseName
+}
+
+func (filterEnabled) Build(clause.Builder) {
+}
+
+func (c filterEnabled) MergeClause(cl *clause.Clause) {
+	cl.Expression = c
+}
+
+// filterTable 返回过滤条件使用的表：FROM 子句为当前表设置了别名时使用别名，
+// Table("users AS u") 的别名已经是 stmt.Table，由 clause.CurrentTable 处理
+func filterTable(stmt *gorm.Statement) string {
+	if c, ok := stmt.Clauses["FROM"]; ok {
+		if from, ok := c.Expression.(clause.From); ok {
+			for _, table := range from.Tables {
+				if table.Alias != "" && (table.Name == stmt.Table || table.Name == clause.CurrentTable) {
+					return table.Alias
+				}
+			}
+		}
 	}
-	return []clause.Interface{softDeleteClause}
+	return clause.CurrentTable
+}
+
+func (DeletedAt) DeleteClauses(f *schema.Field) []clause.Interface {
+	return flagDeleteClauses(f, deletedAtValues(), deletedAtFromDeleted)
 }
 
 func (DeletedAt) UpdateClauses(f *schema.Field) []clause.Interface {
-	return []clause.Interface{SoftDeleteUpdateClause{Field: f}}
+	return flagUpdateClauses(f)
 }
 
 type SoftDeleteUpdateClause struct {
 	Field *schema.Field
+	Flag  bool
 }
 
 func (sd SoftDeleteUpdateClause) Name() string {
-	return ""
+	return ClauseUpdate
 }
 
 func (sd SoftDeleteUpdateClause) Build(clause.Builder) {
 }
 
-func (sd SoftDeleteUpdateClause) MergeClause(*clause.Clause) {
+// MergeClause 重复添加时保留最后一个，不会产生多个子句
+func (sd SoftDeleteUpdateClause) MergeClause(c *clause.Clause) {
+	c.Expression = sd
 }
 
 func (sd SoftDeleteUpdateClause) ModifyStatement(stmt *gorm.Statement) {
+	recordClause(stmt, sd)
+	if stmt.SQL.Len() == 0 && isRestoring(stmt) {
+		if deleteClause, ok := deleteClauseOf(sd.Field); ok && !deleteClause.yields() {
+			deleteClause.restore(stmt)
+		}
+		return
+	}
+
 	if stmt.SQL.Len() == 0 && !stmt.Statement.Unscoped {
-		SoftDeleteQueryClause(sd).ModifyStatement(stmt)
+		if isUpdateDeleted(stmt) {
+			sd.queryClause().removeFilter(stmt)
+			sd.queryClause().recordDecision(stmt, nil, SkipUpdateDeleted)
+			return
+		}
+		sd.queryClause().applyFilter(stmt)
+	} else if stmt.SQL.Len() == 0 {
+		sd.queryClause().recordDecision(stmt, nil, SkipUnscoped)
 	}
 }
 
+// queryClause 返回更新时使用的过滤，DisableDefaultScope 只影响查询，更新总是过滤
+func (sd SoftDeleteUpdateClause) queryClause() SoftDeleteQueryClause {
+	return SoftDeleteQueryClause{Field: sd.Field, Flag: sd.Flag}
+}
+
 type SoftDeleteDeleteClause struct {
 	Field         *schema.Field
 	Flag          bool
-	DataType      schema.DataType
+	TimeType      schema.TimeType
 	DeleteAtField *schema.Field
+	// DeleteAtFieldName 为标签中声明的 DeletedAtField，用于在找不到字段时报错
+	DeleteAtFieldName string
+	DeleteByField     *schema.Field
+	DeleteByFieldName string
+	// DeleteReasonField 在语句带有 Reason 子句时写入删除原因
+	DeleteReasonField     *schema.Field
+	DeleteReasonFieldName string
+	// Token 为 DeletedToken，删除时写入记录的主键，字段不记录删除时间
+	Token bool
+	// Interval 为 ValidTo，删除时写入当前时间关闭区间
+	Interval           bool
+	ValidFromField     *schema.Field
+	ValidFromFieldName string
+	// IntervalKeyFields 为区间所属的业务主键，恢复时检查是否有更新的区间
+	IntervalKeyFields []*schema.Field
+	// Expires 为 ExpiresAt，删除时写入当前时间使记录立即过期
+	Expires bool
+	// VersionField 为乐观锁的版本字段，删除单条记录时检查并加一
+	VersionField     *schema.Field
+	VersionFieldName string
+	// KeepUpdatedAt 删除和恢复时不更新 autoUpdateTime 字段
+	KeepUpdatedAt bool
+	// LegacyTimestampField 为迁移前的时间戳字段，删除时同时写入当前时间，恢复时置为 NULL，
+	// 使只读取旧列的代码看到一致的结果。字段应为 *time.Time 或 sql.NullTime，不能是 gorm.DeletedAt
+	//
+	//	Deleted      soft_delete.DeletedAt `gorm:"softDelete:flag,LegacyTimestampField:DeletedAtOld"`
+	//	DeletedAtOld *time.Time            `gorm:"column:deleted_at"`
+	LegacyTimestampField     *schema.Field
+	LegacyTimestampFieldName string
+
+	tombstone tombstoneMode
 }
 
 func (sd SoftDeleteDeleteClause) Name() string {
-	return ""
+	return ClauseDelete
 }
 
 func (sd SoftDeleteDeleteClause) Build(clause.Builder) {
 }
 
-func (sd SoftDeleteDeleteClause) MergeClause(*clause.Clause) {
+// MergeClause 重复添加时保留最后一个，不会产生多个子句
+func (sd SoftDeleteDeleteClause) MergeClause(c *clause.Clause) {
+	c.Expression = sd
 }
 
 func (sd SoftDeleteDeleteClause) ModifyStatement(stmt *gorm.Statement) {
-	if stmt.SQL.Len() == 0 && !stmt.Statement.Unscoped {
+	if sd.yields() {
+		return
+	}
+	recordClause(stmt, sd)
+	if stmt.SQL.Len() == 0 && !stmt.Statement.Unscoped && !isPermanentDelete(stmt) {
+		if errs := sd.fieldErrors(); len(errs) > 0 {
+			stmt.AddError(errs[0])
+			return
+		}
+		tombstoneMode, tombstone := tombstoneOf(stmt, sd.tombstone)
+		if tombstone && sd.VersionField == nil {
+			stmt.AddError(ErrMissingTombstoneVersion)
+			return
+		}
+
+		// 先检查 Dest 和 Model 的形状，nil 元素或其他类型的结构体在写入内存时也会 panic
+		keys, err := primaryKeyCondition(stmt)
+		if err != nil {
+			stmt.AddError(err)
+			// gorm 随后会按 ReflectValue 构建 DELETE，有错误时不会执行，清空以免它反射时 panic
+			stmt.ReflectValue = reflect.Value{}
+			return
+		}
+
 		var (
-			set clause.Set
+			curTime = stmt.DB.NowFunc()
+			set     clause.Set
 		)
 
 		if deleteAtField := sd.DeleteAtField; deleteAtField != nil {
-			var value interface{}
-			if deleteAtField.GORMDataType == "bool" {
-				value = true
-			}
+			value := sd.deleteAtValue(curTime)
 			set = append(set, clause.Assignment{Column: clause.Column{Name: deleteAtField.DBName}, Value: value})
-			stmt.SetColumn(deleteAtField.DBName, value, true)
+			setColumn(stmt, deleteAtField, value)
+		}
+
+		if legacyField := sd.LegacyTimestampField; legacyField != nil {
+			set = append(set, clause.Assignment{Column: clause.Column{Name: legacyField.DBName}, Value: curTime})
+			setColumn(stmt, legacyField, curTime)
 		}
 
-		set = append(clause.Set{{Column: clause.Column{Name: sd.Field.DBName}, Value: FlagDeleted}}, set...)
-		stmt.SetColumn(sd.Field.DBName, FlagDeleted, true)
+		if deleteByField := sd.DeleteByField; deleteByField != nil {
+			if actor, ok := ActorFromContext(stmt.Context); ok {
+				set = append(set, clause.Assignment{Column: clause.Column{Name: deleteByField.DBName}, Value: actor})
+				setColumn(stmt, deleteByField, actor)
+			}
+		}
+
+		if deleteReasonField := sd.DeleteReasonField; deleteReasonField != nil {
+			if reason, ok := reasonFromStatement(stmt); ok {
+				set = append(set, clause.Assignment{Column: clause.Column{Name: deleteReasonField.DBName}, Value: string(reason)})
+				setColumn(stmt, deleteReasonField, string(reason))
+			}
+		}
+
+		for _, field := range sd.touchedFields() {
+			value := autoUpdateValue(field, curTime)
+			set = append(set, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: value})
+			setColumn(stmt, field, value)
+		}
+
+		if pk := sd.tokenPrimaryField(); pk != nil {
+			set = append(clause.Set{{Column: clause.Column{Name: sd.Field.DBName}, Value: clause.Column{Name: pk.DBName}}}, set...)
+			sd.assignTokens(stmt, pk)
+		} else {
+			deletedValue := sd.deletedValue(curTime)
+			set = append(clause.Set{{Column: clause.Column{Name: sd.Field.DBName}, Value: sqlValue(deletedValue)}}, set...)
+			setColumn(stmt, sd.Field, deletedValue)
+		}
+		if cond, assignment := sd.versionCondition(stmt); cond != nil {
+			set = append(set, *assignment)
+			stmt.AddClause(clause.Where{Exprs: []clause.Expression{cond}})
+		}
 		stmt.AddClause(set)
 
-		if stmt.Schema != nil {
-			_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, stmt.ReflectValue, stmt.Schema.PrimaryFields)
-			column, values := schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+		if keys != nil {
+			stmt.AddClause(clause.Where{Exprs: []clause.Expression{keys}})
+		}
+
+		// 与 gorm 的全局删除保护一致，必须在级联删除子记录之前检查
+		if where, _ := stmt.Clauses["WHERE"].Expression.(clause.Where); len(where.Exprs) == 0 && !stmt.DB.AllowGlobalUpdate {
+			stmt.AddError(gorm.ErrMissingWhereClause)
+			return
+		}
 
-			if len(values) > 0 {
-				stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
+		SoftDeleteQueryClause{Field: sd.Field, Flag: sd.Flag}.addFilter(stmt)
+		cascadeDelete(stmt)
+		var buildClauses []string
+		if tombstone {
+			buildClauses = sd.buildTombstone(stmt, tombstoneMode, set)
+		} else {
+			stmt.AddClauseIfNotExists(clause.Update{})
+			var err error
+			if buildClauses, err = deleteBuildClauses(stmt); err != nil {
+				stmt.AddError(err)
+				return
 			}
+			// 语句只在这里构建一次，之后 gorm 的 Delete 回调见到 SQL 不为空直接执行；
+			// 丢弃之前残留的参数，PrepareStmt 下参数个数才与占位符一致
+			stmt.Vars = nil
+			stmt.Build(buildClauses...)
+		}
+		if keys != nil {
+			keys.execChunks(stmt, buildClauses)
+		}
+	}
+}
 
-			if stmt.ReflectValue.CanAddr() && stmt.Dest != stmt.Model && stmt.Model != nil {
-				_, queryValues = schema.GetIdentityFieldValuesMap(stmt.Context, reflect.ValueOf(stmt.Model), stmt.Schema.PrimaryFields)
-				column, values = schema.ToQueryValues(stmt.Table, stmt.Schema.PrimaryFieldDBNames, queryValues)
+// setColumn 将删除时写入的值同步到内存中：Dest 为结构体时设置结构体，为切片或数组时设置每个元素，
+// 为 map 或 []map 时写入键并同时设置 Model 指向的结构体。Register 构造的字段不在结构体中，只写入 map。
+// 以带有 embeddedPrefix 的 DBName 写入，嵌入结构体的指针为 nil 时由 gorm 分配
+func setColumn(stmt *gorm.Statement, field *schema.Field, value interface{}) {
+	switch stmt.Dest.(type) {
+	case map[string]interface{}, []map[string]interface{}:
+		stmt.SetColumn(field.DBName, value, true)
+		if model := reflect.ValueOf(stmt.Model); isStructField(field) && model.Kind() == reflect.Ptr && !model.IsNil() &&
+			model.Elem().Type() == stmt.Schema.ModelType {
+			stmt.AddError(field.Set(stmt.Context, model.Elem(), value))
+		}
+	default:
+		// Delete(User{ID: 1}) 传入的结构体无法修改，gorm 的硬删除允许这样调用
+		if isStructField(field) && (stmt.ReflectValue.Kind() != reflect.Struct || stmt.ReflectValue.CanAddr()) {
+			stmt.SetColumn(field.DBName, value, true)
+		}
+	}
+}
 
-				if len(values) > 0 {
-					stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.IN{Column: column, Values: values}}})
-				}
+// deleteBuildClauses 返回改写后的 UPDATE 使用的子句。gorm 会按删除回调是否支持 RETURNING 扫描结果，
+// 方言只为删除注册了 RETURNING 时也要带上，否则 Delete 的 clause.Returning 会被丢弃。
+// ORDER BY、LIMIT 同样带入 UPDATE，方言的 UPDATE 和 DELETE 都不支持时返回 ErrUnsupportedClause，而不是忽略。
+// clause.Locking 在方言的 UPDATE 或 DELETE 注册了 FOR 时带入，否则忽略，UPDATE 本身会锁定匹配的行
+func deleteBuildClauses(stmt *gorm.Statement) ([]string, error) {
+	updateClauses := stmt.DB.Callback().Update().Clauses
+	deleteClauses := stmt.DB.Callback().Delete().Clauses
+
+	var missing []string
+	for _, name := range []string{"ORDER BY", "LIMIT", "FOR", "RETURNING"} {
+		if !hasClause(stmt, name) || containsColumn(updateClauses, name) {
+			continue
+		}
+		if !containsColumn(deleteClauses, name) {
+			if name == "FOR" || name == "RETURNING" {
+				continue
 			}
+			return nil, fmt.Errorf("%w: %s on %s", ErrUnsupportedClause, name, stmt.DB.Dialector.Name())
+		}
+		missing = append(missing, name)
+	}
+	if len(missing) == 0 {
+		return updateClauses, nil
+	}
+
+	// 按 UPDATE ... WHERE ... ORDER BY ... LIMIT ... RETURNING 的顺序插入
+	clauses := make([]string, 0, len(updateClauses)+len(missing))
+	for _, name := range updateClauses {
+		if name == "RETURNING" {
+			clauses = append(clauses, missing...)
+			missing = nil
 		}
+		clauses = append(clauses, name)
+	}
+	return append(clauses, missing...), nil
+}
+
+// hasClause 判断语句是否带有会生成 SQL 的子句，空的或为负数的 Limit 不算
+func hasClause(stmt *gorm.Statement, name string) bool {
+	c, ok := stmt.Clauses[name]
+	if !ok {
+		return false
+	}
+	if limit, ok := c.Expression.(clause.Limit); ok {
+		return limit.Limit != nil && *limit.Limit >= 0 || limit.Offset > 0
+	}
+	return true
+}
 
-		SoftDeleteQueryClause{Field: sd.Field}.ModifyStatement(stmt)
-		stmt.AddClauseIfNotExists(clause.Update{})
-		stmt.Build(stmt.DB.Callback().Update().Clauses...)
+// withCompanionFields 按标签中的 DeletedAtField、DeletedByField、DeletedReasonField 关联删除时间、操作人和原因的字段，
+// 并关联乐观锁的版本字段，读取 KeepUpdatedAt
+func (sd SoftDeleteDeleteClause) withCompanionFields(settings map[string]string) SoftDeleteDeleteClause {
+	if name := settings["DELETEDATFIELD"]; name != "" {
+		sd.DeleteAtFieldName = name
+		sd.DeleteAtField = sd.Field.Schema.LookUpField(name)
+	}
+	if name := settings["DELETEDBYFIELD"]; name != "" {
+		sd.DeleteByFieldName = name
+		sd.DeleteByField = sd.Field.Schema.LookUpField(name)
+	}
+	if name := settings["DELETEDREASONFIELD"]; name != "" {
+		sd.DeleteReasonFieldName = name
+		sd.DeleteReasonField = sd.Field.Schema.LookUpField(name)
 	}
+	if name := settings["LEGACYTIMESTAMPFIELD"]; name != "" {
+		sd.LegacyTimestampFieldName = name
+		sd.LegacyTimestampField = sd.Field.Schema.LookUpField(name)
+	}
+	_, sd.KeepUpdatedAt = settings["KEEPUPDATEDAT"]
+	sd.tombstone = parseTombstone(settings)
+	return sd.withVersion(settings)
 }
 
-func getTimeType() schema.DataType {
-	return schema.Bool
+// deleteAtValue 将 NowFunc 返回的时间转换为 DeleteAtField 对应的数据类型
+func (sd SoftDeleteDeleteClause) deleteAtValue(curTime time.Time) interface{} {
+	switch sd.DeleteAtField.GORMDataType {
+	case schema.Bool:
+		return true
+	case schema.Int, schema.Uint, schema.Float:
+		return sd.timeToUnix(curTime)
+	default:
+		return curTime
+	}
+}
+
+// deletedValue 返回删除时写入标记列的值
+func (sd SoftDeleteDeleteClause) deletedValue(curTime time.Time) interface{} {
+	if sd.Token {
+		return curTime.UnixNano()
+	}
+	if sd.Interval || sd.Expires {
+		return curTime
+	}
+	if sd.Flag {
+		if values := flagValuesOf(sd.Field); values.mask != 0 {
+			return bitAssign{column: clause.Column{Name: sd.Field.DBName}, mask: values.mask, set: true}
+		}
+		return flagValuesOf(sd.Field).deleted
+	}
+	return sd.timeToUnix(curTime)
+}
+
+func (sd SoftDeleteDeleteClause) timeToUnix(curTime time.Time) int64 {
+	switch sd.TimeType {
+	case schema.UnixNanosecond:
+		return curTime.UnixNano()
+	case schema.UnixMillisecond:
+		return curTime.UnixMilli()
+	default:
+		return curTime.Unix()
+	}
+}
+
+// parseSettings 解析 `gorm:"softDelete:flag,DeletedAtField:DeletedAt"` 中 softDelete 的配置项
+func parseSettings(f *schema.Field) map[string]string {
+	return schema.ParseTagSetting(f.TagSettings["SOFTDELETE"], ",")
+}
+
+// getTimeType 返回 DeletedAtField 为整数列时的时间精度，由 DeletedAtFieldUnit 指定
+func getTimeType(settings map[string]string) schema.TimeType {
+	switch strings.ToUpper(settings["DELETEDATFIELDUNIT"]) {
+	case "NANO":
+		return schema.UnixNanosecond
+	case "MILLI":
+		return schema.UnixMillisecond
+	default:
+		return schema.UnixSecond
+	}
 }
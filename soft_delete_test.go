@@ -0,0 +1,490 @@
+package soft_delete
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type User struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+func TestDeletedAtScan(t *testing.T) {
+	tests := []struct {
+		value interface{}
+		want  DeletedAt
+	}{
+		{nil, false},
+		{true, true},
+		{false, false},
+		{int64(1), true},
+		{int64(0), false},
+		{uint8(1), true},
+		{uint8(0), false},
+		{float64(1), true},
+		{float64(0), false},
+		{[]byte("1"), true},
+		{[]byte("0"), false},
+		{[]byte("true"), true},
+		{"1", true},
+		{"0", false},
+		{"false", false},
+		{int32(2), true},
+	}
+	for _, tt := range tests {
+		d := DeletedAt(!tt.want)
+		if err := d.Scan(tt.value); err != nil {
+			t.Errorf("Scan(%#v): %v", tt.value, err)
+			continue
+		}
+		if d != tt.want {
+			t.Errorf("Scan(%#v) = %v, want %v", tt.value, d, tt.want)
+		}
+	}
+}
+
+func TestDeletedAtScanInvalid(t *testing.T) {
+	var d DeletedAt
+	if err := d.Scan(struct{}{}); err == nil || !strings.Contains(err.Error(), "struct {}") {
+		t.Errorf("Scan(struct{}{}) error = %v, want type in message", err)
+	}
+	if err := d.Scan("abc"); err == nil {
+		t.Error("Scan(\"abc\") should fail")
+	}
+}
+
+// MySQL 的 TINYINT(1) 以整数返回，SQLite 的 INTEGER 列同样如此
+func TestScanIntegerColumn(t *testing.T) {
+	db := openDB(t)
+	if err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, deleted INTEGER NOT NULL DEFAULT 0)").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO users (id, name, deleted) VALUES (1, 'a', 0), (2, 'b', 1)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var users []User
+	if err := db.Unscoped().Order("id").Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Deleted || !users[1].Deleted {
+		t.Fatalf("users = %+v", users)
+	}
+
+	if err := db.Find(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != 1 {
+		t.Errorf("active users = %+v", users)
+	}
+}
+
+func TestDeletedAtValue(t *testing.T) {
+	if v, _ := DeletedAt(true).Value(); v != true {
+		t.Errorf("Value() = %#v, want true", v)
+	}
+	if v, _ := DeletedAt(false).Value(); v != false {
+		t.Errorf("Value() = %#v, want false", v)
+	}
+}
+
+// ValueInt 时条件和写入都以 0/1 绑定，与 INTEGER 列一致
+func TestValueModeInt(t *testing.T) {
+	db := openDB(t)
+	config := DefaultConfig()
+	config.ValueMode = ValueInt
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, deleted INTEGER NOT NULL DEFAULT 0)").Error; err != nil {
+		t.Fatal(err)
+	}
+
+	_, vars, err := ExplainQuery(db, &[]User{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vars) != 1 || vars[0] != int64(0) {
+		t.Errorf("query vars = %#v, want [0]", vars)
+	}
+	_, vars, err = ExplainDelete(db, &User{ID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vars) == 0 || vars[0] != int64(1) {
+		t.Errorf("delete vars = %#v, want 1 first", vars)
+	}
+
+	user := User{Name: "a"}
+	db.Create(&user)
+	db.Create(&User{Name: "b"})
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	var deleted int64
+	db.Raw("SELECT deleted FROM users WHERE id = ?", user.ID).Scan(&deleted)
+	if deleted != 1 {
+		t.Errorf("deleted column = %d, want 1", deleted)
+	}
+	var users []User
+	if db.Find(&users); len(users) != 1 || users[0].Name != "b" {
+		t.Errorf("users = %+v", users)
+	}
+}
+
+type NullUser struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag;default:null"`
+}
+
+// default:null 的列以 NULL 表示未删除，恢复时写回 NULL
+func TestNullDefaultFlag(t *testing.T) {
+	db := openDB(t, &NullUser{})
+	user := NullUser{Name: "a"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&NullUser{Name: "b"})
+
+	var users []NullUser
+	if db.Find(&users); len(users) != 2 {
+		t.Fatalf("users = %+v", users)
+	}
+	sql := sqlOf(t, db.Session(&gorm.Session{DryRun: true}).Find(&users))
+	assertContains(t, sql, "`null_users`.`deleted` IS NULL")
+
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if db.Find(&users); len(users) != 1 || users[0].Name != "b" {
+		t.Fatalf("users after delete = %+v", users)
+	}
+
+	if _, err := Restore(db, &user); err != nil {
+		t.Fatal(err)
+	}
+	var nulls int64
+	db.Table("null_users").Where("deleted IS NULL").Count(&nulls)
+	if nulls != 2 {
+		t.Errorf("rows with NULL flag = %d, want 2", nulls)
+	}
+}
+
+type TimeCompanion struct {
+	ID        uint
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+type MissingCompanion struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag,DeletedAtField:Missing"`
+}
+
+// 标记和删除时间在同一条 UPDATE 中写入
+func TestDeletedAtField(t *testing.T) {
+	db := openDB(t, &TimeCompanion{})
+	sql := sqlOf(t, dryRun(pinNow(db)).Delete(&TimeCompanion{ID: 1}))
+	assertContains(t, sql, "UPDATE `time_companions` SET `deleted`=?,`deleted_at`=?")
+}
+
+// 找不到 DeletedAtField 声明的字段时在执行语句时报错，不会只写入标记
+func TestDeletedAtFieldMissing(t *testing.T) {
+	db := openDB(t, &MissingCompanion{})
+	row := MissingCompanion{}
+	db.Create(&row)
+	err := db.Delete(&row).Error
+	if err == nil || !strings.Contains(err.Error(), `DeletedAtField "Missing"`) {
+		t.Fatalf("Delete error = %v", err)
+	}
+	if countRows(t, db.Where("deleted = ?", true), "missing_companions") != 0 {
+		t.Error("row was deleted despite the error")
+	}
+}
+
+type BoolCompanion struct {
+	ID       uint
+	Deleted  DeletedAt `gorm:"softDelete:flag,DeletedAtField:Archived"`
+	Archived bool
+}
+
+type SecondCompanion struct {
+	ID        uint
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt int64
+}
+
+type MilliCompanion struct {
+	ID        uint
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt,DeletedAtFieldUnit:milli"`
+	DeletedAt int64
+}
+
+type NanoCompanion struct {
+	ID        uint
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt,DeletedAtFieldUnit:nano"`
+	DeletedAt uint64
+}
+
+// 删除时间按 NowFunc 和字段的数据类型写入，不会是 NULL
+func TestDeletedAtFieldValues(t *testing.T) {
+	db := pinNow(openDB(t, &User{}, &TimeCompanion{}, &BoolCompanion{}, &SecondCompanion{}, &MilliCompanion{}, &NanoCompanion{}))
+
+	timeRow, boolRow := TimeCompanion{}, BoolCompanion{}
+	secondRow, milliRow, nanoRow := SecondCompanion{}, MilliCompanion{}, NanoCompanion{}
+	user := User{Name: "a"}
+	for _, value := range []interface{}{&timeRow, &boolRow, &secondRow, &milliRow, &nanoRow, &user} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Delete(value).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if timeRow.DeletedAt == nil || !timeRow.DeletedAt.Equal(testNow) {
+		t.Errorf("in-memory time = %v", timeRow.DeletedAt)
+	}
+	var gotTime TimeCompanion
+	db.Unscoped().First(&gotTime, timeRow.ID)
+	if gotTime.DeletedAt == nil || !gotTime.DeletedAt.Equal(testNow) {
+		t.Errorf("time = %v, want %v", gotTime.DeletedAt, testNow)
+	}
+
+	var gotBool BoolCompanion
+	db.Unscoped().First(&gotBool, boolRow.ID)
+	if !gotBool.Archived {
+		t.Error("bool companion was not set")
+	}
+
+	var gotSecond SecondCompanion
+	var gotMilli MilliCompanion
+	var gotNano NanoCompanion
+	db.Unscoped().First(&gotSecond, secondRow.ID)
+	db.Unscoped().First(&gotMilli, milliRow.ID)
+	db.Unscoped().First(&gotNano, nanoRow.ID)
+	if gotSecond.DeletedAt != testNow.Unix() {
+		t.Errorf("second = %d, want %d", gotSecond.DeletedAt, testNow.Unix())
+	}
+	if gotMilli.DeletedAt != testNow.UnixMilli() {
+		t.Errorf("milli = %d, want %d", gotMilli.DeletedAt, testNow.UnixMilli())
+	}
+	if gotNano.DeletedAt != uint64(testNow.UnixNano()) {
+		t.Errorf("nano = %d, want %d", gotNano.DeletedAt, testNow.UnixNano())
+	}
+
+	// 没有 DeletedAtField 时只写入标记
+	sql := sqlOf(t, dryRun(db).Delete(&User{ID: user.ID}))
+	assertContains(t, sql, "SET `deleted`=? WHERE")
+}
+
+type Plain struct {
+	ID     uint
+	UserID uint
+}
+
+// 子查询总是带有自己的过滤条件，外层模型没有软删除字段时也是如此
+func TestSubqueryFilter(t *testing.T) {
+	db := openDB(t, &User{}, &Plain{})
+	users := seedUsers(t, db)
+	for _, user := range users {
+		db.Create(&Plain{UserID: user.ID})
+	}
+
+	sub := db.Model(&User{}).Select("id")
+	var plains []Plain
+	if err := db.Where("user_id IN (?)", sub).Find(&plains).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(plains) != 2 {
+		t.Errorf("plains = %+v", plains)
+	}
+	sql := sqlOf(t, dryRun(db).Where("user_id IN (?)", sub).Find(&plains))
+	assertContains(t, sql, "(SELECT `id` FROM `users` WHERE `users`.`deleted` = ?)")
+	assertNotContains(t, sql, "`plains`.`deleted`")
+
+	// 子查询复用后仍然带有条件，外层同模型的查询也只有自己的一个条件
+	var found []User
+	sql = sqlOf(t, dryRun(db).Where("id IN (?)", sub).Find(&found))
+	if strings.Count(sql, "`users`.`deleted` = ?") != 2 {
+		t.Errorf("sql = %s, want one filter in each query", sql)
+	}
+	if err := db.Where("id IN (?)", sub).Find(&found).Error; err != nil || len(found) != 2 {
+		t.Errorf("found = %d, %v", len(found), err)
+	}
+}
+
+// 无论原有条件中有多少 OR，过滤条件总是与全部条件 AND
+func TestFilterWrapsOrConditions(t *testing.T) {
+	db := openDB(t, &User{})
+	seedUsers(t, db)
+
+	tests := []struct {
+		name  string
+		scope func(*gorm.DB) *gorm.DB
+		sql   string
+		count int
+	}{
+		{"single or", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("name = ?", "a").Or("name = ?", "b")
+		}, "WHERE (name = ? OR name = ?) AND `users`.`deleted` = ?", 1},
+		{"multiple ors", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("name = ?", "a").Or("name = ?", "b").Or("name = ?", "c")
+		}, "WHERE (name = ? OR name = ? OR name = ?) AND `users`.`deleted` = ?", 2},
+		{"or group with two conditions", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where("name = ?", "a").Or(tx.Where("name = ?", "b").Where("id > ?", 0))
+		}, "WHERE (name = ? OR (name = ? AND id > ?)) AND `users`.`deleted` = ?", 1},
+		{"nested group", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(tx.Where("name = ?", "a").Or("name = ?", "b")).Where("id > ?", 0)
+		}, "WHERE (name = ? OR name = ?) AND id > ? AND `users`.`deleted` = ?", 1},
+		{"not", func(tx *gorm.DB) *gorm.DB {
+			return tx.Not("name = ?", "a").Or("name = ?", "b")
+		}, "WHERE (NOT name = ? OR name = ?) AND `users`.`deleted` = ?", 1},
+		{"clause or", func(tx *gorm.DB) *gorm.DB {
+			return tx.Where(clause.Or(clause.Eq{Column: "name", Value: "b"}, clause.Eq{Column: "name", Value: "c"}))
+		}, "WHERE (`name` = ? OR `name` = ?) AND `users`.`deleted` = ?", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var users []User
+			assertContains(t, sqlOf(t, tt.scope(dryRun(db)).Find(&users)), tt.sql)
+			if err := tt.scope(db).Find(&users).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(users) != tt.count {
+				t.Errorf("found %d users, want %d", len(users), tt.count)
+			}
+		})
+	}
+}
+
+// 同一条语句重复执行查询子句时只有一个过滤条件
+func TestFilterIdempotent(t *testing.T) {
+	db := openDB(t, &User{})
+	stmt := &gorm.Statement{DB: db, Clauses: map[string]clause.Clause{}}
+	if err := stmt.Parse(&User{}); err != nil {
+		t.Fatal(err)
+	}
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "name", Value: "a"}}})
+
+	for i := 0; i < 3; i++ {
+		for _, c := range stmt.Schema.QueryClauses {
+			stmt.AddClause(c)
+		}
+		stmt.SQL.Reset()
+		stmt.Vars = nil
+		stmt.Build("WHERE")
+		if n := strings.Count(stmt.SQL.String(), "`deleted`"); n != 1 {
+			t.Fatalf("build %d: %s has %d filters", i+1, stmt.SQL.String(), n)
+		}
+	}
+
+	tx := dryRun(db).Where("name = ?", "a").Session(&gorm.Session{})
+	for i := 0; i < 3; i++ {
+		var users []User
+		if sql := sqlOf(t, tx.Find(&users)); strings.Count(sql, "`deleted`") != 1 {
+			t.Fatalf("run %d: %s", i+1, sql)
+		}
+	}
+}
+
+// 没有主键也没有条件的删除返回 gorm.ErrMissingWhereClause，不修改任何记录
+func TestDeleteWithoutConditions(t *testing.T) {
+	db := openDB(t, &User{})
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}})
+
+	if err := db.Delete(&User{}).Error; !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Fatalf("Delete without conditions = %v", err)
+	}
+	var n int64
+	db.Model(&User{}).Count(&n)
+	if n != 2 {
+		t.Errorf("active = %d, want 2", n)
+	}
+
+	tx := db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&User{})
+	if tx.Error != nil || tx.RowsAffected != 2 {
+		t.Errorf("Delete with AllowGlobalUpdate = %d, %v", tx.RowsAffected, tx.Error)
+	}
+}
+
+type TeamUser struct {
+	ID        uint
+	TeamID    uint
+	Name      string
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+// clause.Returning 带入改写后的 UPDATE，结果写回 Dest
+func TestDeleteReturning(t *testing.T) {
+	db := openDB(t, &TeamUser{})
+	db.Create(&[]TeamUser{{TeamID: 1, Name: "a"}, {TeamID: 1, Name: "b"}, {TeamID: 2, Name: "c"}})
+
+	var users []TeamUser
+	if err := db.Clauses(clause.Returning{}).Delete(&users, "team_id = ?", 1).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("returned %d rows, want 2", len(users))
+	}
+	for _, u := range users {
+		if u.Name == "" || !u.Deleted.IsDeleted() || u.DeletedAt == nil {
+			t.Errorf("returned %+v", u)
+		}
+	}
+
+	var names []TeamUser
+	tx := db.Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}, {Name: "deleted"}}}).Delete(&names, "team_id = ?", 2)
+	if tx.Error != nil {
+		t.Fatal(tx.Error)
+	}
+	if len(names) != 1 || names[0].ID == 0 || !names[0].Deleted.IsDeleted() || names[0].Name != "" {
+		t.Errorf("returned %+v", names)
+	}
+
+	sql := sqlOf(t, dryRunDB(t, "postgres").Clauses(clause.Returning{}).Delete(&[]TeamUser{}, "team_id = ?", 1))
+	assertContains(t, sql, "UPDATE", "RETURNING *")
+}
+
+// 批量删除后切片中的每个元素及其关联字段都被设置
+func TestDeleteSetsDestinations(t *testing.T) {
+	db := pinNow(openDB(t, &TimeCompanion{}))
+	db.Create(&[]TimeCompanion{{}, {}, {}, {}, {}, {}})
+
+	slice := []TimeCompanion{{ID: 1}, {ID: 2}, {ID: 3}}
+	if err := db.Delete(&slice).Error; err != nil {
+		t.Fatal(err)
+	}
+	ptrs := []*TimeCompanion{{ID: 4}, {ID: 5}}
+	if err := db.Delete(&ptrs).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range append(ptrs, &slice[0], &slice[1], &slice[2]) {
+		if !c.Deleted.IsDeleted() || c.DeletedAt == nil || !c.DeletedAt.Equal(testNow) {
+			t.Errorf("element %d = %+v", c.ID, c)
+		}
+	}
+
+	dest := map[string]interface{}{}
+	model := TimeCompanion{}
+	if err := db.Model(&model).Where("id = ?", 6).Delete(dest).Error; err != nil {
+		t.Fatal(err)
+	}
+	if dest["deleted"] == nil || dest["deleted_at"] == nil || !model.Deleted.IsDeleted() {
+		t.Errorf("map dest = %v, model = %+v", dest, model)
+	}
+	var n int64
+	db.Model(&TimeCompanion{}).Count(&n)
+	if n != 0 {
+		t.Errorf("active = %d, want 0", n)
+	}
+}
@@ -0,0 +1,132 @@
+package soft_delete
+
+import (
+	"database/sql"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TableStats 为 Stats、StatsBy 的统计结果
+type TableStats struct {
+	// Group 为 StatsBy 分组列的值，Stats 时为 nil
+	Group   interface{}
+	Active  int64
+	Deleted int64
+	// HasDeletedAt 为 false 时模型没有记录删除时间的字段，OldestDeletedAt、NewestDeletedAt 总是零值
+	HasDeletedAt bool
+	// OldestDeletedAt、NewestDeletedAt 为已删除记录中最早和最晚的删除时间，没有已删除的记录时为零值
+	OldestDeletedAt time.Time
+	NewestDeletedAt time.Time
+}
+
+// timeLayouts 为数据库以字符串返回聚合后的时间时可能的格式，例如 SQLite
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Stats 以一条聚合查询统计未删除、已删除的记录数，以及已删除记录的删除时间范围，db 上已有的条件同样生效
+//
+//	stats, err := soft_delete.Stats(db.Where("created_at < ?", before), &User{})
+func Stats(db *gorm.DB, model interface{}) (TableStats, error) {
+	stats, err := statsBy(db, model, "")
+	if err != nil || len(stats) == 0 {
+		return TableStats{}, err
+	}
+	return stats[0], nil
+}
+
+// StatsBy 与 Stats 相同，但按 column 分组，每组一个结果
+//
+//	stats, err := soft_delete.StatsBy(db, &User{}, "tenant_id")
+func StatsBy(db *gorm.DB, model interface{}, column string) ([]TableStats, error) {
+	return statsBy(db, model, column)
+}
+
+func statsBy(db *gorm.DB, model interface{}, column string) ([]TableStats, error) {
+	s, sd, err := parseDeleteClause(db, model)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := deletedExpr(sd.Field, sd.Flag, clause.Column{Table: clause.CurrentTable, Name: sd.Field.DBName})
+	query, vars := "COUNT(*), SUM(CASE WHEN ? THEN 1 ELSE 0 END)", []interface{}{deleted}
+	deletedAtField := sd.deletedAtField()
+	if deletedAtField != nil {
+		at := clause.Column{Table: clause.CurrentTable, Name: deletedAtField.DBName}
+		query += ", MIN(CASE WHEN ? THEN ? END), MAX(CASE WHEN ? THEN ? END)"
+		vars = append(vars, deleted, at, deleted, at)
+	}
+
+	tx := db.Model(model).Scopes(WithDeleted)
+	if column != "" {
+		if field := s.LookUpField(column); field != nil {
+			column = field.DBName
+		}
+		query = "?, " + query
+		vars = append([]interface{}{clause.Column{Table: clause.CurrentTable, Name: column}}, vars...)
+		tx = tx.Group(column)
+	}
+
+	rows, err := tx.Select(query, vars...).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []TableStats
+	for rows.Next() {
+		var (
+			stat           = TableStats{HasDeletedAt: deletedAtField != nil}
+			total          int64
+			deletedCount   sql.NullInt64
+			oldest, newest interface{}
+			dest           []interface{}
+		)
+		if column != "" {
+			dest = append(dest, &stat.Group)
+		}
+		dest = append(dest, &total, &deletedCount)
+		if deletedAtField != nil {
+			dest = append(dest, &oldest, &newest)
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+
+		if b, ok := stat.Group.([]byte); ok {
+			stat.Group = string(b)
+		}
+		stat.Deleted = deletedCount.Int64
+		stat.Active = total - stat.Deleted
+		stat.OldestDeletedAt, _ = sd.statsTime(oldest)
+		stat.NewestDeletedAt, _ = sd.statsTime(newest)
+		stats = append(stats, stat)
+	}
+	return stats, rows.Err()
+}
+
+// statsTime 将聚合查询返回的删除时间转换为 time.Time，整数按 deletedAtField 的精度转换
+func (sd SoftDeleteDeleteClause) statsTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case int64:
+		return sd.unixToTime(v), true
+	case float64:
+		return sd.unixToTime(int64(v)), true
+	case []byte:
+		value = string(v)
+	}
+	if s, ok := value.(string); ok {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,132 @@
+package soft_delete
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type StatsUser struct {
+	ID        uint
+	TenantID  string
+	Name      string
+	Deleted   DeletedAt `gorm:"softDelete:flag,DeletedAtField:DeletedAt"`
+	DeletedAt *time.Time
+}
+
+// seedTenants 创建两个租户的记录，acme 的 b、c 分别在 1 天前和 3 小时前删除，globex 的 e 在 2 天前删除
+func seedTenants(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	users := []StatsUser{
+		{TenantID: "acme", Name: "a"}, {TenantID: "acme", Name: "b"}, {TenantID: "acme", Name: "c"},
+		{TenantID: "globex", Name: "d"}, {TenantID: "globex", Name: "e"},
+	}
+	if err := db.Create(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+	deleteAt := func(u *StatsUser, at time.Time) {
+		if err := db.Session(&gorm.Session{NowFunc: func() time.Time { return at }}).Delete(u).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	deleteAt(&users[1], testNow.Add(-24*time.Hour))
+	deleteAt(&users[2], testNow.Add(-3*time.Hour))
+	deleteAt(&users[4], testNow.Add(-48*time.Hour))
+}
+
+func TestStats(t *testing.T) {
+	db := openDB(t, &StatsUser{})
+	seedTenants(t, db)
+
+	stats, err := Stats(db, &StatsUser{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := TableStats{
+		Active: 2, Deleted: 3, HasDeletedAt: true,
+		OldestDeletedAt: testNow.Add(-48 * time.Hour), NewestDeletedAt: testNow.Add(-3 * time.Hour),
+	}
+	if !equalStats(stats, want) {
+		t.Errorf("Stats = %+v, want %+v", stats, want)
+	}
+
+	// 链上已有的条件同样生效
+	stats, err = Stats(db.Where("name <> ?", "c"), &StatsUser{})
+	want = TableStats{
+		Active: 2, Deleted: 2, HasDeletedAt: true,
+		OldestDeletedAt: testNow.Add(-48 * time.Hour), NewestDeletedAt: testNow.Add(-24 * time.Hour),
+	}
+	if err != nil || !equalStats(stats, want) {
+		t.Errorf("Stats with condition = %+v, %v, want %+v", stats, err, want)
+	}
+}
+
+func TestStatsBy(t *testing.T) {
+	db := openDB(t, &StatsUser{})
+	seedTenants(t, db)
+
+	for _, column := range []string{"tenant_id", "TenantID"} {
+		stats, err := StatsBy(db, &StatsUser{}, column)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(stats, func(i, j int) bool { return stats[i].Group.(string) < stats[j].Group.(string) })
+		want := []TableStats{
+			{Group: "acme", Active: 1, Deleted: 2, HasDeletedAt: true,
+				OldestDeletedAt: testNow.Add(-24 * time.Hour), NewestDeletedAt: testNow.Add(-3 * time.Hour)},
+			{Group: "globex", Active: 1, Deleted: 1, HasDeletedAt: true,
+				OldestDeletedAt: testNow.Add(-48 * time.Hour), NewestDeletedAt: testNow.Add(-48 * time.Hour)},
+		}
+		if len(stats) != len(want) {
+			t.Fatalf("StatsBy(%s) = %+v", column, stats)
+		}
+		for i := range want {
+			if !equalStats(stats[i], want[i]) {
+				t.Errorf("StatsBy(%s)[%d] = %+v, want %+v", column, i, stats[i], want[i])
+			}
+		}
+	}
+
+	stats, err := StatsBy(db.Where("tenant_id = ?", "globex"), &StatsUser{}, "tenant_id")
+	if err != nil || len(stats) != 1 || stats[0].Group != "globex" {
+		t.Errorf("StatsBy with condition = %+v, %v", stats, err)
+	}
+}
+
+// 没有删除时间字段时只统计数量，HasDeletedAt 为 false；unix 标记按字段的精度转换删除时间
+func TestStatsDeletedAt(t *testing.T) {
+	db := pinNow(openDB(t, &User{}, &UnixUser{}))
+	seedUsers(t, db)
+	stats, err := Stats(db, &User{})
+	if err != nil || stats.HasDeletedAt || stats.Active != 2 || stats.Deleted != 1 ||
+		!stats.OldestDeletedAt.IsZero() || !stats.NewestDeletedAt.IsZero() {
+		t.Errorf("Stats without deleted at = %+v, %v", stats, err)
+	}
+
+	db.Create(&[]UnixUser{{Name: "a"}, {Name: "b"}})
+	db.Delete(&UnixUser{ID: 2})
+	stats, err = Stats(db, &UnixUser{})
+	if err != nil || !stats.HasDeletedAt || stats.Active != 1 || stats.Deleted != 1 ||
+		!stats.OldestDeletedAt.Equal(testNow) || !stats.NewestDeletedAt.Equal(testNow) {
+		t.Errorf("Stats of unix flag = %+v, %v", stats, err)
+	}
+}
+
+// 没有已删除的记录时删除时间为零值
+func TestStatsEmpty(t *testing.T) {
+	db := openDB(t, &StatsUser{})
+	stats, err := Stats(db, &StatsUser{})
+	if err != nil || !equalStats(stats, TableStats{HasDeletedAt: true}) {
+		t.Errorf("Stats of empty table = %+v, %v", stats, err)
+	}
+	if _, err := Stats(db, &Plain{}); err == nil {
+		t.Error("Stats of a model without soft delete should fail")
+	}
+}
+
+func equalStats(a, b TableStats) bool {
+	return a.Group == b.Group && a.Active == b.Active && a.Deleted == b.Deleted && a.HasDeletedAt == b.HasDeletedAt &&
+		a.OldestDeletedAt.Equal(b.OldestDeletedAt) && a.NewestDeletedAt.Equal(b.NewestDeletedAt)
+}
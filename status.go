@@ -0,0 +1,106 @@
+package soft_delete
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	// StatusActive 为 Status 默认恢复时写入的值
+	StatusActive Status = "active"
+	// StatusDeleted 为 Status 默认表示已删除的值
+	StatusDeleted Status = "deleted"
+)
+
+// Status 用于以字符串状态列表示生命周期的表，"deleted" 之外的状态（如 "pending"）都视为未删除：
+// 查询过滤 status <> 'deleted'，删除时写入 'deleted'，恢复时写入 'active'。
+// 两个值可以通过标签修改，ActiveValue 为恢复时写入的值：
+//
+//	Status soft_delete.Status `gorm:"softDelete:ActiveValue:enabled,DeletedValue:removed"`
+type Status string
+
+// IsDeleted 判断状态是否为默认的已删除值 "deleted"，通过标签修改了 DeletedValue 的字段使用 IsDeletedFor
+func (s Status) IsDeleted() bool {
+	return s == StatusDeleted
+}
+
+// IsActive 判断记录是否未删除，"pending" 等其他状态也视为未删除
+func (s Status) IsActive() bool {
+	return !s.IsDeleted()
+}
+
+// String 返回 "deleted" 或 "active"，不是状态本身，需要原始状态时使用 string(s)
+func (s Status) String() string {
+	return stateString(s.IsDeleted())
+}
+
+// IsDeletedFor 按字段 f 标签中的 DeletedValue 判断状态是否已删除，f 为模型中的 Status 字段
+func (s Status) IsDeletedFor(f *schema.Field) bool {
+	return string(s) == statusValues(f).deleted
+}
+
+// IsActiveFor 按字段 f 的取值判断记录是否未删除
+func (s Status) IsActiveFor(f *schema.Field) bool {
+	return !s.IsDeletedFor(f)
+}
+
+// statusValues 解析字段的状态值，标签中的值总是按字符串处理
+func statusValues(f *schema.Field) flagValues {
+	settings := parseSettings(f)
+	values := flagValues{active: string(StatusActive), deleted: string(StatusDeleted), byDeleted: true}
+	if v, ok := settings["ACTIVEVALUE"]; ok {
+		values.active = v
+	}
+	if v, ok := settings["DELETEDVALUE"]; ok {
+		values.deleted = v
+	}
+	return values
+}
+
+// bindStatusValues 绑定字段的状态值
+func bindStatusValues(f *schema.Field) {
+	fieldFlagValues.LoadOrStore(f, statusValues(f))
+}
+
+func (Status) QueryClauses(f *schema.Field) []clause.Interface {
+	bindStatusValues(f)
+	return []clause.Interface{SoftDeleteQueryClause{Field: f, Flag: true}.withQuerySettings()}
+}
+
+func (Status) UpdateClauses(f *schema.Field) []clause.Interface {
+	return flagUpdateClauses(f)
+}
+
+func (Status) DeleteClauses(f *schema.Field) []clause.Interface {
+	bindStatusValues(f)
+	settings := parseSettings(f)
+	softDeleteClause := SoftDeleteDeleteClause{
+		Field:    f,
+		Flag:     true,
+		TimeType: getTimeType(settings),
+	}
+	return []clause.Interface{softDeleteClause.withCompanionFields(settings)}
+}
+
+// 实现 driver.Valuer 接口
+func (s Status) Value() (driver.Value, error) {
+	return string(s), nil
+}
+
+// 实现 sql.Scanner 接口
+func (s *Status) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+	case string:
+		*s = Status(v)
+	case []byte:
+		*s = Status(v)
+	default:
+		return fmt.Errorf("invalid value for Status: %v", value)
+	}
+	return nil
+}
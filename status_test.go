@@ -0,0 +1,51 @@
+package soft_delete
+
+import "testing"
+
+type Ticket struct {
+	ID     uint
+	Status Status
+}
+
+type LegacyTicket struct {
+	ID     uint
+	Status Status `gorm:"softDelete:ActiveValue:enabled,DeletedValue:removed"`
+}
+
+// 除已删除外的状态都可见，恢复时写入 ActiveValue
+func TestStatus(t *testing.T) {
+	db := openDB(t, &Ticket{}, &LegacyTicket{})
+	db.Create(&[]Ticket{{Status: "active"}, {Status: "pending"}, {Status: "active"}})
+
+	db.Delete(&Ticket{ID: 3})
+	var tickets []Ticket
+	db.Order("id").Find(&tickets)
+	if len(tickets) != 2 || tickets[1].Status != "pending" {
+		t.Fatalf("visible = %+v", tickets)
+	}
+
+	ticket := Ticket{ID: 3}
+	if n, err := Restore(db, &ticket); n != 1 || err != nil {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	db.First(&ticket, 3)
+	if ticket.Status != StatusActive {
+		t.Errorf("restored status = %q", string(ticket.Status))
+	}
+
+	db.Create(&[]LegacyTicket{{Status: "enabled"}, {Status: "pending"}})
+	db.Delete(&LegacyTicket{ID: 1})
+	var raw []string
+	db.Table("legacy_tickets").Order("id").Pluck("status", &raw)
+	if len(raw) != 2 || raw[0] != "removed" || raw[1] != "pending" {
+		t.Errorf("stored = %v", raw)
+	}
+	Restore(db, &LegacyTicket{ID: 1})
+	db.Table("legacy_tickets").Order("id").Pluck("status", &raw)
+	if raw[0] != "enabled" {
+		t.Errorf("restored = %v", raw)
+	}
+
+	sql := sqlOf(t, dryRun(db).Find(&[]Ticket{}))
+	assertContains(t, sql, "`tickets`.`status` <> ?")
+}
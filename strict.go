@@ -0,0 +1,81 @@
+package soft_delete
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrAlreadyDeleted 记录存在但已经被软删除，可以用 errors.Is 与 gorm.ErrRecordNotFound 匹配
+var ErrAlreadyDeleted = fmt.Errorf("soft_delete: record already deleted: %w", gorm.ErrRecordNotFound)
+
+// ErrUnscopedDelete DeleteWhere 的会话为 Unscoped，删除会变成物理删除
+var ErrUnscopedDelete = errors.New("soft_delete: DeleteWhere refuses unscoped deletes")
+
+// DeleteWhere 按 db 上的条件软删除 model 的记录，返回删除的行数。会话为 Unscoped 时返回 ErrUnscopedDelete，
+// 没有 Where 条件且 model 没有主键值时返回 gorm.ErrMissingWhereClause，模型没有软删除字段时返回 ErrMissingSoftDeleteField。
+// 条件需要在调用前以 Where 加上，Scopes 中的条件在执行时才生效，不计入检查
+//
+//	n, err := soft_delete.DeleteWhere(db.Where("tenant_id = ? AND created_at < ?", tenantID, before), &Log{})
+func DeleteWhere(db *gorm.DB, model interface{}) (int64, error) {
+	if db.Statement.Unscoped || isPermanentDelete(db.Statement) {
+		return 0, ErrUnscopedDelete
+	}
+	s, _, err := parseDeleteClause(db, model)
+	if err != nil {
+		if _, archived := archiveTableOf(s); !errors.Is(err, ErrMissingSoftDeleteField) || !archived {
+			return 0, err
+		}
+	}
+	if !hasConditions(db.Statement, s, model) {
+		return 0, gorm.ErrMissingWhereClause
+	}
+
+	tx := db.Delete(model)
+	return tx.RowsAffected, tx.Error
+}
+
+// hasConditions 判断语句是否有 Where 条件，或 model 带有主键值
+func hasConditions(stmt *gorm.Statement, s *schema.Schema, model interface{}) bool {
+	if where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+		return true
+	}
+	_, queryValues := schema.GetIdentityFieldValuesMap(stmt.Context, reflect.Indirect(reflect.ValueOf(model)), s.PrimaryFields)
+	return len(queryValues) > 0
+}
+
+// StrictDelete 软删除记录，没有记录被删除时返回错误：记录已经被删除时为 ErrAlreadyDeleted，不存在时为 gorm.ErrRecordNotFound。
+// 普通的 Delete 在批量删除没有匹配到记录时是正常的，只有调用方明确需要时才使用这个函数
+//
+//	switch _, err := soft_delete.StrictDelete(db, &user); {
+//	case errors.Is(err, soft_delete.ErrAlreadyDeleted):
+//		// 409
+//	case errors.Is(err, gorm.ErrRecordNotFound):
+//		// 404
+//	}
+func StrictDelete(db *gorm.DB, value interface{}, conds ...interface{}) (int64, error) {
+	tx := db.Delete(value, conds...)
+	if tx.Error != nil || tx.RowsAffected > 0 {
+		return tx.RowsAffected, tx.Error
+	}
+
+	deleted, err := ExistsDeleted(db, value, conds...)
+	if err != nil {
+		return 0, err
+	}
+	if deleted {
+		return 0, ErrAlreadyDeleted
+	}
+	return 0, gorm.ErrRecordNotFound
+}
+
+// Strict 没有记录被恢复时 Restore 返回 gorm.ErrRecordNotFound，并回滚恢复钩子中的修改
+func Strict() RestoreOption {
+	return func(c *restoreConfig) {
+		c.strict = true
+	}
+}
@@ -0,0 +1,112 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// StrictDelete 没有删除任何记录时返回 gorm.ErrRecordNotFound，普通的 Delete 不受影响
+func TestStrictDelete(t *testing.T) {
+	db := openDB(t, &User{})
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}})
+
+	if n, err := StrictDelete(db, &User{ID: 1}); n != 1 || err != nil {
+		t.Fatalf("StrictDelete = %d, %v", n, err)
+	}
+	if _, err := StrictDelete(db, &User{ID: 99}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("StrictDelete missing = %v", err)
+	}
+	if _, err := StrictDelete(db, &User{ID: 1}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("StrictDelete deleted = %v", err)
+	}
+
+	if tx := db.Where("name = ?", "x").Delete(&User{}); tx.Error != nil || tx.RowsAffected != 0 {
+		t.Errorf("Delete matching nothing = %d, %v", tx.RowsAffected, tx.Error)
+	}
+	if _, err := StrictDelete(db.Where("name = ?", "x"), &User{}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("StrictDelete matching nothing = %v", err)
+	}
+}
+
+// Config.Strict 使 Restore 没有恢复任何记录时返回 gorm.ErrRecordNotFound
+func TestStrictRestore(t *testing.T) {
+	db := openDB(t, &User{})
+	db.Create(&User{Name: "a"})
+
+	if n, err := Restore(db, &User{ID: 1}); n != 0 || err != nil {
+		t.Errorf("Restore active = %d, %v", n, err)
+	}
+	if _, err := Restore(db, &User{ID: 1}, Strict()); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Restore Strict active = %v", err)
+	}
+
+	config := DefaultConfig()
+	config.Strict = true
+	if err := Use(db, config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(db, &User{ID: 99}); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Restore with Config.Strict = %v", err)
+	}
+}
+
+// StrictDelete 区分刚删除、已经删除和不存在三种结果
+func TestStrictDeleteAlreadyDeleted(t *testing.T) {
+	db := openDB(t, &User{})
+	db.Create(&User{Name: "a"})
+
+	if _, err := StrictDelete(db, &User{ID: 1}); err != nil {
+		t.Fatalf("deleted now: %v", err)
+	}
+
+	_, err := StrictDelete(db, &User{ID: 1})
+	if !errors.Is(err, ErrAlreadyDeleted) {
+		t.Errorf("already deleted = %v", err)
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("ErrAlreadyDeleted does not match gorm.ErrRecordNotFound")
+	}
+
+	if _, err = StrictDelete(db, &User{ID: 2}); errors.Is(err, ErrAlreadyDeleted) || !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("never existed = %v", err)
+	}
+	if _, err = StrictDelete(db, &User{}, "name = ?", "a"); !errors.Is(err, ErrAlreadyDeleted) {
+		t.Errorf("already deleted with conds = %v", err)
+	}
+}
+
+func TestDeleteWhere(t *testing.T) {
+	db := openDB(t, &User{})
+	db.Create(&[]User{{Name: "a"}, {Name: "a"}, {Name: "b"}})
+
+	n, err := DeleteWhere(db.Where("name = ?", "a"), &User{})
+	if err != nil || n != 2 {
+		t.Fatalf("DeleteWhere = %d, %v", n, err)
+	}
+	if rows := countRows(t, db, "users"); rows != 3 {
+		t.Errorf("rows = %d, DeleteWhere must not hard delete", rows)
+	}
+}
+
+func TestDeleteWhereRejects(t *testing.T) {
+	db := openDB(t, &User{})
+	db.Create(&User{Name: "a"})
+
+	if _, err := DeleteWhere(db.Unscoped().Where("name = ?", "a"), &User{}); !errors.Is(err, ErrUnscopedDelete) {
+		t.Errorf("Unscoped: err = %v, want ErrUnscopedDelete", err)
+	}
+	if _, err := DeleteWhere(db.Clauses(permanentDeleteClause{}).Where("name = ?", "a"), &User{}); !errors.Is(err, ErrUnscopedDelete) {
+		t.Errorf("Permanent: err = %v, want ErrUnscopedDelete", err)
+	}
+	if _, err := DeleteWhere(db, &User{}); !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Errorf("no where: err = %v, want ErrMissingWhereClause", err)
+	}
+	if _, err := DeleteWhere(db.Where("id = ?", 1), &Plain{}); !errors.Is(err, ErrMissingSoftDeleteField) {
+		t.Errorf("plain model: err = %v, want ErrMissingSoftDeleteField", err)
+	}
+	if rows := countRows(t, db, "users"); rows != 1 {
+		t.Errorf("rows = %d after rejected deletes", rows)
+	}
+}
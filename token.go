@@ -0,0 +1,95 @@
+package soft_delete
+
+import (
+	"database/sql/driver"
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DeletedToken 用于与业务列组成联合唯一索引：未删除时为 0，删除时写入记录的主键，
+// 主键不是单个整数列时写入删除时的 unix 纳秒。UNIQUE(email, deleted_token) 只限制未删除的记录，
+// 已删除的记录各自的值不同，不会冲突
+//
+//	Email        string                  `gorm:"uniqueIndex:idx_email"`
+//	DeletedToken soft_delete.DeletedToken `gorm:"uniqueIndex:idx_email"`
+type DeletedToken uint64
+
+// IsDeleted 判断记录是否已删除
+func (t DeletedToken) IsDeleted() bool {
+	return t != 0
+}
+
+// IsActive 判断记录是否未删除
+func (t DeletedToken) IsActive() bool {
+	return t == 0
+}
+
+// String 返回 "deleted" 或 "active"
+func (t DeletedToken) String() string {
+	return stateString(t.IsDeleted())
+}
+
+// 实现 driver.Valuer 接口
+func (t DeletedToken) Value() (driver.Value, error) {
+	return int64(t), nil
+}
+
+// 实现 sql.Scanner 接口，NULL 视为未删除
+func (t *DeletedToken) Scan(value interface{}) error {
+	v, err := scanUnix(value)
+	*t = DeletedToken(v)
+	return err
+}
+
+func (DeletedToken) QueryClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{SoftDeleteQueryClause{Field: f}.withQuerySettings()}
+}
+
+func (DeletedToken) UpdateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{SoftDeleteUpdateClause{Field: f}}
+}
+
+func (DeletedToken) DeleteClauses(f *schema.Field) []clause.Interface {
+	settings := parseSettings(f)
+	softDeleteClause := SoftDeleteDeleteClause{Field: f, TimeType: getTimeType(settings), Token: true}
+	return []clause.Interface{softDeleteClause.withCompanionFields(settings)}
+}
+
+// tokenPrimaryField 返回 DeletedToken 写入的主键字段，主键不是单个整数列时返回 nil
+func (sd SoftDeleteDeleteClause) tokenPrimaryField() *schema.Field {
+	if !sd.Token || len(sd.Field.Schema.PrimaryFields) != 1 {
+		return nil
+	}
+	switch pk := sd.Field.Schema.PrimaryFields[0]; pk.FieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return pk
+	}
+	return nil
+}
+
+// assignTokens 将内存中每条记录的 DeletedToken 设为各自的主键，与 UPDATE 写入的值一致
+func (sd SoftDeleteDeleteClause) assignTokens(stmt *gorm.Statement, pk *schema.Field) {
+	assign := func(rv reflect.Value) {
+		if rv.Kind() != reflect.Struct {
+			return
+		}
+		if id, zero := pk.ValueOf(stmt.Context, rv); !zero {
+			stmt.AddError(sd.Field.Set(stmt.Context, rv, id))
+		}
+	}
+
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < stmt.ReflectValue.Len(); i++ {
+			assign(reflect.Indirect(stmt.ReflectValue.Index(i)))
+		}
+	case reflect.Struct:
+		if stmt.ReflectValue.CanAddr() {
+			assign(stmt.ReflectValue)
+		}
+	}
+}
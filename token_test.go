@@ -0,0 +1,75 @@
+package soft_delete
+
+import "testing"
+
+type TokenUser struct {
+	ID           uint
+	Email        string       `gorm:"uniqueIndex:idx_token_email"`
+	DeletedToken DeletedToken `gorm:"uniqueIndex:idx_token_email"`
+}
+
+type TokenCode struct {
+	Code         string `gorm:"primaryKey"`
+	DeletedToken DeletedToken
+}
+
+// 删除时写入主键，已删除的重复值不冲突，恢复后重置为 0
+func TestDeletedToken(t *testing.T) {
+	db := openDB(t, &TokenUser{})
+	for i := 0; i < 2; i++ {
+		if err := db.Create(&TokenUser{Email: "a@x"}).Error; err != nil {
+			t.Fatalf("create %d: %v", i+1, err)
+		}
+		user := TokenUser{ID: uint(i + 1)}
+		if err := db.Delete(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+		if user.DeletedToken != DeletedToken(i+1) {
+			t.Errorf("in memory token = %d, want %d", user.DeletedToken, i+1)
+		}
+	}
+	if err := db.Create(&TokenUser{Email: "a@x"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&TokenUser{Email: "a@x"}).Error; err == nil {
+		t.Error("duplicate active email inserted")
+	}
+
+	var tokens []uint64
+	db.Table("token_users").Order("id").Pluck("deleted_token", &tokens)
+	if len(tokens) != 3 || tokens[0] != 1 || tokens[1] != 2 || tokens[2] != 0 {
+		t.Errorf("tokens = %v", tokens)
+	}
+
+	db.Delete(&TokenUser{ID: 3})
+	user := TokenUser{ID: 1}
+	if n, err := Restore(db, &user); n != 1 || err != nil {
+		t.Fatalf("Restore = %d, %v", n, err)
+	}
+	if user.DeletedToken != 0 {
+		t.Errorf("token after restore = %d", user.DeletedToken)
+	}
+	var active []TokenUser
+	db.Find(&active)
+	if len(active) != 1 || active[0].ID != 1 {
+		t.Errorf("active = %+v", active)
+	}
+}
+
+// 主键不是整数时写入 unix 纳秒
+func TestDeletedTokenStringKey(t *testing.T) {
+	db := openDB(t, &TokenCode{})
+	db.Create(&TokenCode{Code: "a"})
+	db.Delete(&TokenCode{Code: "a"})
+
+	var token int64
+	db.Table("token_codes").Select("deleted_token").Scan(&token)
+	if token <= 0 {
+		t.Errorf("token = %d", token)
+	}
+	var n int64
+	db.Model(&TokenCode{}).Count(&n)
+	if n != 0 {
+		t.Errorf("active = %d", n)
+	}
+}
@@ -0,0 +1,197 @@
+package soft_delete
+
+import (
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrMissingTombstoneVersion 追加版本的删除需要版本字段，ReplacingMergeTree 按版本保留最新的一行
+var ErrMissingTombstoneVersion = errors.New("soft_delete: tombstone delete requires a version field")
+
+const tombstoneClauseName = "soft_delete:tombstone"
+
+// tombstoneMode 为追加版本时读取最新版本的方式
+type tombstoneMode int
+
+const (
+	// tombstoneFinal 查询时在表名后加 FINAL，由 ClickHouse 合并出每个主键的最新版本
+	tombstoneFinal tombstoneMode = iota + 1
+	// tombstoneLatest 以子查询限定每个主键的最大版本，适用于不能使用 FINAL 的查询
+	tombstoneLatest
+)
+
+// parseTombstone 解析标签中的 Tombstone，模型以追加版本的方式删除，用于 ClickHouse 等 UPDATE 代价很高的数据库。
+// 值为 Latest 时以子查询代替 FINAL：
+//
+//	Version int64
+//	Deleted soft_delete.DeletedAt `gorm:"softDelete:flag,Tombstone,VersionField:Version"`
+//
+// 删除和恢复时不修改原来的行，而是插入一行复制当前的值，标记和伴随字段为新的值，版本号加一
+func parseTombstone(settings map[string]string) tombstoneMode {
+	value, ok := settings["TOMBSTONE"]
+	switch {
+	case !ok:
+		return 0
+	case strings.EqualFold(value, "LATEST"):
+		return tombstoneLatest
+	default:
+		return tombstoneFinal
+	}
+}
+
+// tombstoneOf 返回语句是否以追加版本的方式删除：标签中声明了 Tombstone，或方言为 clickhouse
+func tombstoneOf(stmt *gorm.Statement, mode tombstoneMode) (tombstoneMode, bool) {
+	if mode != 0 {
+		return mode, true
+	}
+	if stmt.DB != nil && stmt.DB.Dialector != nil && stmt.DB.Dialector.Name() == "clickhouse" {
+		return tombstoneFinal, true
+	}
+	return 0, false
+}
+
+// applyTombstone 使查询只读取每个主键的最新版本，与是否过滤已删除无关，WithDeleted 时也读取最新版本
+func (sd SoftDeleteQueryClause) applyTombstone(stmt *gorm.Statement) {
+	mode, ok := tombstoneOf(stmt, sd.tombstone)
+	if !ok {
+		return
+	}
+	deleteClause, ok := deleteClauseOf(sd.Field)
+	if !ok {
+		return
+	}
+	if mode == tombstoneFinal {
+		// 已经指定了 Table 表达式或别名时不修改
+		if stmt.TableExpr == nil && filterTable(stmt) == clause.CurrentTable {
+			stmt.TableExpr = &clause.Expr{SQL: "? FINAL", Vars: []interface{}{clause.Table{Name: stmt.Table}}}
+		}
+		return
+	}
+	deleteClause.addLatestVersion(stmt)
+}
+
+// addLatestVersion 在 WHERE 中加入最新版本的条件，已经加入时不重复添加
+func (sd SoftDeleteDeleteClause) addLatestVersion(stmt *gorm.Statement) {
+	if sd.VersionField == nil {
+		return
+	}
+	where, _ := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	for _, expr := range where.Exprs {
+		if _, ok := expr.(latestVersion); ok {
+			return
+		}
+	}
+	stmt.AddClause(clause.Where{Exprs: []clause.Expression{latestVersion{schema: sd.Field.Schema, version: sd.VersionField}}})
+}
+
+// latestVersion 限定每个主键的最大版本，相当于 ClickHouse 的 argMax：
+// (id, version) IN (SELECT id, MAX(version) FROM t GROUP BY id)
+type latestVersion struct {
+	schema  *schema.Schema
+	version *schema.Field
+}
+
+// SoftDeleteScoped 实现 ScopedExpression，不与查询条件一起分组
+func (latestVersion) SoftDeleteScoped() {}
+
+func (l latestVersion) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	builder.WriteByte('(')
+	for _, name := range l.schema.PrimaryFieldDBNames {
+		builder.WriteQuoted(clause.Column{Table: clause.CurrentTable, Name: name})
+		builder.WriteByte(',')
+	}
+	builder.WriteQuoted(clause.Column{Table: clause.CurrentTable, Name: l.version.DBName})
+	builder.WriteString(") IN (SELECT ")
+	for _, name := range l.schema.PrimaryFieldDBNames {
+		builder.WriteQuoted(clause.Column{Name: name})
+		builder.WriteByte(',')
+	}
+	builder.WriteString("MAX(")
+	builder.WriteQuoted(clause.Column{Name: l.version.DBName})
+	builder.WriteString(") FROM ")
+	builder.WriteQuoted(clause.Table{Name: stmt.Table})
+	builder.WriteString(" GROUP BY ")
+	for i, name := range l.schema.PrimaryFieldDBNames {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteQuoted(clause.Column{Name: name})
+	}
+	builder.WriteByte(')')
+}
+
+// tombstone 为插入新版本的 INSERT INTO ... SELECT，set 中的列写入新的值，其余列复制当前的值，
+// 条件为语句的 WHERE。按主键分批时每批构建一次，WHERE 中的主键条件只输出当前批次
+type tombstone struct {
+	mode tombstoneMode
+	set  clause.Set
+}
+
+func (t tombstone) Build(builder clause.Builder) {
+	stmt, ok := builder.(*gorm.Statement)
+	if !ok {
+		return
+	}
+	table := clause.Table{Name: stmt.Table}
+	builder.WriteString("INSERT INTO ")
+	builder.WriteQuoted(table)
+	builder.WriteString(" (")
+	for i, name := range stmt.Schema.DBNames {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteQuoted(clause.Column{Name: name})
+	}
+	builder.WriteString(") SELECT ")
+	for i, name := range stmt.Schema.DBNames {
+		if i > 0 {
+			builder.WriteByte(',')
+		}
+		if value, ok := assignmentOf(t.set, name); ok {
+			builder.AddVar(builder, value)
+		} else {
+			builder.WriteQuoted(clause.Column{Table: clause.CurrentTable, Name: name})
+		}
+	}
+	builder.WriteString(" FROM ")
+	builder.WriteQuoted(table)
+	if t.mode == tombstoneFinal {
+		builder.WriteString(" FINAL")
+	}
+	if where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+		builder.WriteString(" WHERE ")
+		where.Build(builder)
+	}
+}
+
+func assignmentOf(set clause.Set, name string) (interface{}, bool) {
+	for _, assignment := range set {
+		if assignment.Column.Name == name {
+			return assignment.Value, true
+		}
+	}
+	return nil, false
+}
+
+// buildTombstone 将删除或恢复构建为插入新版本，版本号加一，返回构建时使用的子句名。调用方已检查版本字段
+func (sd SoftDeleteDeleteClause) buildTombstone(stmt *gorm.Statement, mode tombstoneMode, set clause.Set) []string {
+	if !hasAssignment(set, sd.VersionField.DBName) {
+		column := clause.Column{Table: clause.CurrentTable, Name: sd.VersionField.DBName}
+		set = append(set, clause.Assignment{Column: clause.Column{Name: sd.VersionField.DBName}, Value: gorm.Expr("? + 1", column)})
+	}
+	if mode == tombstoneLatest {
+		sd.addLatestVersion(stmt)
+	}
+	stmt.Clauses[tombstoneClauseName] = clause.Clause{Expression: tombstone{mode: mode, set: set}}
+	stmt.Vars = nil
+	stmt.Build(tombstoneClauseName)
+	return []string{tombstoneClauseName}
+}
@@ -0,0 +1,96 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+)
+
+type Metric struct {
+	ID      uint `gorm:"primaryKey;autoIncrement:false"`
+	Name    string
+	Version int64
+	Deleted DeletedAt `gorm:"softDelete:flag,Tombstone,VersionField:Version"`
+}
+
+// LatestMetric 的表没有唯一约束，同一主键可以有多个版本
+type LatestMetric struct {
+	ID      uint `gorm:"primaryKey;autoIncrement:false"`
+	Name    string
+	Version int64
+	Deleted DeletedAt `gorm:"softDelete:flag,Tombstone:Latest,VersionField:Version"`
+}
+
+type UnversionedMetric struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag,Tombstone"`
+}
+
+func TestTombstoneFinalSQL(t *testing.T) {
+	db := dryRunDB(t, "sqlite")
+
+	sql := sqlOf(t, db.Delete(&Metric{ID: 1}))
+	assertContains(t, sql,
+		"INSERT INTO `metrics` (`id`,`name`,`version`,`deleted`) SELECT `metrics`.`id`,`metrics`.`name`,`metrics`.`version` + 1,? FROM `metrics` FINAL WHERE",
+		"`metrics`.`id` = ?")
+	assertNotContains(t, sql, "UPDATE")
+
+	assertContains(t, sqlOf(t, db.Find(&[]Metric{})), "FROM `metrics` FINAL WHERE `metrics`.`deleted` = ?")
+	// WithDeleted 时仍然只读取最新版本
+	sql = sqlOf(t, db.Scopes(WithDeleted).Find(&[]Metric{}))
+	assertContains(t, sql, "FROM `metrics` FINAL")
+	assertNotContains(t, sql, "`deleted` =")
+}
+
+// 方言为 clickhouse 时没有声明 Tombstone 的模型同样追加版本
+func TestTombstoneClickHouseDialect(t *testing.T) {
+	db := dryRunDB(t, "clickhouse")
+	assertContains(t, sqlOf(t, db.Delete(&Document{ID: 1})), "INSERT INTO `documents`", "`documents`.`version` + 1", "FROM `documents` FINAL")
+	assertContains(t, sqlOf(t, db.Find(&[]Document{})), "FROM `documents` FINAL")
+
+	sql := sqlOf(t, dryRunDB(t, "sqlite").Delete(&Document{ID: 1}))
+	assertContains(t, sql, "UPDATE `documents`")
+}
+
+func TestTombstoneLatest(t *testing.T) {
+	db := openDB(t)
+	db.Exec("CREATE TABLE latest_metrics (id integer, name text, version integer, deleted numeric NOT NULL DEFAULT false)")
+	db.Create(&[]LatestMetric{{ID: 1, Name: "a", Version: 1}, {ID: 2, Name: "b", Version: 1}})
+
+	if err := db.Delete(&LatestMetric{ID: 1}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if n := countRows(t, db, "latest_metrics"); n != 3 {
+		t.Errorf("rows = %d, want the tombstone appended", n)
+	}
+	var found []LatestMetric
+	db.Find(&found)
+	if len(found) != 1 || found[0].ID != 2 {
+		t.Errorf("active = %+v", found)
+	}
+	found = nil
+	db.Scopes(OnlyDeleted).Find(&found)
+	if len(found) != 1 || found[0].ID != 1 || found[0].Version != 2 || found[0].Name != "a" {
+		t.Errorf("deleted = %+v", found)
+	}
+
+	if _, err := Restore(db, &LatestMetric{ID: 1}); err != nil {
+		t.Fatal(err)
+	}
+	found = nil
+	db.Order("id").Find(&found)
+	if len(found) != 2 || found[0].Version != 3 {
+		t.Errorf("active after restore = %+v", found)
+	}
+
+	sql := sqlOf(t, dryRun(db).Find(&[]LatestMetric{}))
+	assertContains(t, sql, "(`latest_metrics`.`id`,`latest_metrics`.`version`) IN (SELECT `id`,MAX(`version`) FROM `latest_metrics` GROUP BY `id`)")
+	assertNotContains(t, sql, "FINAL")
+}
+
+func TestTombstoneWithoutVersion(t *testing.T) {
+	db := openDB(t, &UnversionedMetric{})
+	db.Create(&UnversionedMetric{})
+	if err := db.Delete(&UnversionedMetric{ID: 1}).Error; !errors.Is(err, ErrMissingTombstoneVersion) {
+		t.Errorf("err = %v, want ErrMissingTombstoneVersion", err)
+	}
+}
@@ -0,0 +1,37 @@
+package soft_delete
+
+import (
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// touchedFields 返回删除和恢复时一并更新的 autoUpdateTime 字段，标签中有 KeepUpdatedAt 时不更新
+//
+//	Deleted soft_delete.DeletedAt `gorm:"softDelete:flag,KeepUpdatedAt"`
+func (sd SoftDeleteDeleteClause) touchedFields() []*schema.Field {
+	if sd.KeepUpdatedAt {
+		return nil
+	}
+	var fields []*schema.Field
+	for _, field := range sd.Field.Schema.Fields {
+		if field.AutoUpdateTime > 0 && field.DBName != "" && field != sd.Field {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// autoUpdateValue 与 gorm 的 Updates 一致，按字段的时间精度转换当前时间
+func autoUpdateValue(field *schema.Field, now time.Time) interface{} {
+	switch field.AutoUpdateTime {
+	case schema.UnixNanosecond:
+		return now.UnixNano()
+	case schema.UnixMillisecond:
+		return now.UnixMilli()
+	case schema.UnixSecond:
+		return now.Unix()
+	default:
+		return now
+	}
+}
@@ -0,0 +1,71 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type Article struct {
+	ID        uint
+	Title     string
+	UpdatedAt time.Time
+	Deleted   DeletedAt `gorm:"softDelete:flag"`
+}
+
+type FrozenArticle struct {
+	ID        uint
+	Title     string
+	UpdatedAt time.Time
+	Deleted   DeletedAt `gorm:"softDelete:flag,KeepUpdatedAt"`
+}
+
+// clockAt 返回当前时间固定为 now 的 db
+func clockAt(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Session(&gorm.Session{NowFunc: func() time.Time { return now }})
+}
+
+func TestDeleteTouchesUpdatedAt(t *testing.T) {
+	db := openDB(t, &Article{})
+	created := testNow.Add(-time.Hour)
+	article := Article{Title: "a"}
+	clockAt(db, created).Create(&article)
+
+	if err := clockAt(db, testNow).Delete(&article).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !article.UpdatedAt.Equal(testNow) {
+		t.Errorf("UpdatedAt = %v in memory, want %v", article.UpdatedAt, testNow)
+	}
+	var stored Article
+	db.Unscoped().First(&stored, article.ID)
+	if !stored.UpdatedAt.Equal(testNow) {
+		t.Errorf("UpdatedAt = %v after delete, want %v", stored.UpdatedAt, testNow)
+	}
+
+	restoredAt := testNow.Add(time.Hour)
+	if _, err := Restore(clockAt(db, restoredAt), &Article{ID: article.ID}); err != nil {
+		t.Fatal(err)
+	}
+	db.First(&stored, article.ID)
+	if !stored.UpdatedAt.Equal(restoredAt) {
+		t.Errorf("UpdatedAt = %v after restore, want %v", stored.UpdatedAt, restoredAt)
+	}
+}
+
+func TestDeleteKeepUpdatedAt(t *testing.T) {
+	db := openDB(t, &FrozenArticle{})
+	created := testNow.Add(-time.Hour)
+	article := FrozenArticle{Title: "a"}
+	clockAt(db, created).Create(&article)
+
+	clockAt(db, testNow).Delete(&article)
+	Restore(clockAt(db, testNow), &FrozenArticle{ID: article.ID})
+
+	var stored FrozenArticle
+	db.First(&stored, article.ID)
+	if !stored.UpdatedAt.Equal(created) {
+		t.Errorf("UpdatedAt = %v, want the untouched %v", stored.UpdatedAt, created)
+	}
+}
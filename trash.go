@@ -0,0 +1,186 @@
+package soft_delete
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const defaultPageLimit = 50
+
+// ErrInvalidCursor 游标不是 ListDeleted 返回的值，或排序与生成游标时不同
+var ErrInvalidCursor = errors.New("soft_delete: invalid page cursor")
+
+// Page 为回收站的分页参数
+type Page struct {
+	// After 为上一页返回的游标，为空时从第一页开始
+	After string
+	// Limit 为每页的记录数，默认 50
+	Limit int
+	// OrderBy 为排序列和方向，只能是删除时间列或主键，例如 "deleted_at DESC"；
+	// 默认有非 bool 的 DeletedAtField 时按删除时间倒序，否则按主键倒序。主键总是作为第二排序列保证顺序稳定
+	OrderBy string
+}
+
+// ListDeleted 按键集分页查询已删除的记录，保留 db 上已有的条件，返回下一页的游标，没有下一页时为空。
+// 删除时间为 NULL 的记录在各数据库中的排序不同，按删除时间分页时不会返回。使用 ArchiveStrategy 的模型从归档表中按主键分页
+//
+//	next, err := soft_delete.ListDeleted(db.Where("tenant_id = ?", tenant), &users, soft_delete.Page{After: cursor, Limit: 50})
+func ListDeleted(db *gorm.DB, dest interface{}, page Page) (next string, err error) {
+	s, sd, err := parseDeleteClause(db, dest)
+	if _, archived := archiveTableOf(s); err != nil && !(archived && errors.Is(err, ErrMissingSoftDeleteField)) {
+		return "", err
+	}
+	if len(s.PrimaryFields) != 1 {
+		return "", ErrCompositePrimaryKey
+	}
+
+	order, err := pageOrder(s, sd, page.OrderBy)
+	if err != nil {
+		return "", err
+	}
+	limit := page.Limit
+	if limit <= 0 {
+		limit = defaultPageLimit
+	}
+
+	tx := db.Scopes(OnlyDeleted)
+	if page.After != "" {
+		cond, err := order.after(page.After)
+		if err != nil {
+			return "", err
+		}
+		tx = tx.Where(cond)
+	}
+	if order.field != order.pk {
+		tx = tx.Where(clause.Neq{Column: order.column(order.field), Value: nil})
+	}
+	if err = tx.Clauses(order.orderBy()).Limit(limit + 1).Find(dest).Error; err != nil {
+		return "", err
+	}
+
+	rows := reflect.Indirect(reflect.ValueOf(dest))
+	if rows.Len() <= limit {
+		return "", nil
+	}
+	rows.SetLen(limit)
+	return order.cursor(db, reflect.Indirect(rows.Index(limit-1)))
+}
+
+// keysetOrder 为分页的排序，field 为排序列，pk 为第二排序列，二者相同时只按主键排序
+type keysetOrder struct {
+	field *schema.Field
+	pk    *schema.Field
+	desc  bool
+}
+
+func pageOrder(s *schema.Schema, sd SoftDeleteDeleteClause, orderBy string) (keysetOrder, error) {
+	order := keysetOrder{field: s.PrioritizedPrimaryField, pk: s.PrioritizedPrimaryField, desc: true}
+	if order.pk == nil {
+		order.field, order.pk = s.PrimaryFields[0], s.PrimaryFields[0]
+	}
+	if deletedAt := sd.deletedAtField(); deletedAt != nil {
+		order.field = deletedAt
+	}
+	if orderBy == "" {
+		return order, nil
+	}
+
+	parts := strings.Fields(orderBy)
+	if len(parts) > 2 || len(parts) == 0 {
+		return order, fmt.Errorf("soft_delete: invalid OrderBy %q", orderBy)
+	}
+	switch field := s.LookUpField(parts[0]); {
+	case field == order.pk:
+		order.field = order.pk
+	case field != nil && field == sd.deletedAtField():
+		order.field = field
+	default:
+		return order, fmt.Errorf("soft_delete: OrderBy %q must be the deletion time or the primary key", orderBy)
+	}
+	if len(parts) == 2 {
+		switch strings.ToUpper(parts[1]) {
+		case "ASC":
+			order.desc = false
+		case "DESC":
+		default:
+			return order, fmt.Errorf("soft_delete: invalid OrderBy %q", orderBy)
+		}
+	}
+	return order, nil
+}
+
+func (o keysetOrder) column(field *schema.Field) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+}
+
+func (o keysetOrder) orderBy() clause.OrderBy {
+	columns := []clause.OrderByColumn{{Column: o.column(o.field), Desc: o.desc}}
+	if o.field != o.pk {
+		columns = append(columns, clause.OrderByColumn{Column: o.column(o.pk), Desc: o.desc})
+	}
+	return clause.OrderBy{Columns: columns}
+}
+
+// cursor 将 row 的排序列编码为游标
+func (o keysetOrder) cursor(db *gorm.DB, row reflect.Value) (string, error) {
+	values := make([]interface{}, 0, 2)
+	for _, field := range o.fields() {
+		value, _ := field.ValueOf(db.Statement.Context, row)
+		values = append(values, value)
+	}
+	data, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// after 解码游标并返回其后的记录的条件：(field, pk) 按排序方向在游标之后
+func (o keysetOrder) after(cursor string) (clause.Expression, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil || len(raw) != len(o.fields()) {
+		return nil, ErrInvalidCursor
+	}
+
+	values := make([]interface{}, len(raw))
+	for i, field := range o.fields() {
+		value := reflect.New(field.FieldType)
+		if err := json.Unmarshal(raw[i], value.Interface()); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		values[i] = value.Elem().Interface()
+	}
+
+	beyond := func(field *schema.Field, value interface{}) clause.Expression {
+		if o.desc {
+			return clause.Lt{Column: o.column(field), Value: value}
+		}
+		return clause.Gt{Column: o.column(field), Value: value}
+	}
+	if o.field == o.pk {
+		return beyond(o.pk, values[0]), nil
+	}
+	return clause.Or(
+		beyond(o.field, values[0]),
+		clause.And(clause.Eq{Column: o.column(o.field), Value: values[0]}, beyond(o.pk, values[1])),
+	), nil
+}
+
+func (o keysetOrder) fields() []*schema.Field {
+	if o.field == o.pk {
+		return []*schema.Field{o.pk}
+	}
+	return []*schema.Field{o.field, o.pk}
+}
@@ -0,0 +1,79 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestListDeletedSameTimestamp(t *testing.T) {
+	db := pinNow(openDB(t, &PurgeUser{}))
+	users := make([]PurgeUser, 7)
+	for i := range users {
+		users[i].Name = "u"
+	}
+	db.Create(&users)
+	db.Create(&PurgeUser{Name: "active"})
+	// 同一语句删除，删除时间相同，只能按主键区分顺序
+	if err := db.Delete(&users).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		ids    []uint
+		cursor string
+	)
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+		var page []PurgeUser
+		next, err := ListDeleted(db, &page, Page{After: cursor, Limit: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, u := range page {
+			ids = append(ids, u.ID)
+		}
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	if len(ids) != len(users) {
+		t.Fatalf("ids = %v, want %d rows", ids, len(users))
+	}
+	for i := range ids {
+		if want := users[len(users)-1-i].ID; ids[i] != want {
+			t.Errorf("ids = %v, want descending primary keys", ids)
+			break
+		}
+	}
+}
+
+func TestListDeletedKeepsConditions(t *testing.T) {
+	db := openDB(t, &User{})
+	users := []User{{Name: "a"}, {Name: "b"}, {Name: "a"}}
+	db.Create(&users)
+	db.Delete(&users)
+
+	var page []User
+	next, err := ListDeleted(db.Where("name = ?", "a"), &page, Page{OrderBy: "id ASC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next != "" || len(page) != 2 || page[0].ID != users[0].ID || page[1].ID != users[2].ID {
+		t.Errorf("page = %+v, next = %q", page, next)
+	}
+}
+
+func TestListDeletedErrors(t *testing.T) {
+	db := openDB(t, &User{})
+	var page []User
+	if _, err := ListDeleted(db, &page, Page{After: "not a cursor"}); !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("err = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := ListDeleted(db, &page, Page{OrderBy: "name"}); err == nil {
+		t.Error("OrderBy on name should fail")
+	}
+}
@@ -0,0 +1,173 @@
+package soft_delete
+
+import (
+	"database/sql"
+	"database/sql/driver"
+
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// unixFlag 为 DeletedAtUnix、DeletedAtMilli、DeletedAtNano 共用的实现，三者只有删除时写入的时间精度不同，
+// 精度同时用于 DeletedAtField 为整数列时写入的值
+type unixFlag struct {
+	timeType schema.TimeType
+}
+
+var (
+	unixSecond = unixFlag{timeType: schema.UnixSecond}
+	unixMilli  = unixFlag{timeType: schema.UnixMillisecond}
+	unixNano   = unixFlag{timeType: schema.UnixNanosecond}
+)
+
+func (unixFlag) value(n int64) (driver.Value, error) {
+	return n, nil
+}
+
+// scan 将数据库中的值写入 n，NULL 视为未删除
+func (unixFlag) scan(n *int64, value interface{}) error {
+	v, err := scanUnix(value)
+	*n = v
+	return err
+}
+
+func (unixFlag) queryClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{SoftDeleteQueryClause{Field: f}.withQuerySettings()}
+}
+
+func (unixFlag) updateClauses(f *schema.Field) []clause.Interface {
+	return []clause.Interface{SoftDeleteUpdateClause{Field: f}}
+}
+
+func (u unixFlag) deleteClauses(f *schema.Field) []clause.Interface {
+	softDeleteClause := SoftDeleteDeleteClause{Field: f, TimeType: u.timeType}
+	return []clause.Interface{softDeleteClause.withCompanionFields(parseSettings(f))}
+}
+
+// DeletedAtUnix 以 unix 秒记录删除时间，0 表示未删除
+type DeletedAtUnix int64
+
+// 实现 driver.Valuer 接口
+func (n DeletedAtUnix) Value() (driver.Value, error) {
+	return unixSecond.value(int64(n))
+}
+
+// IsDeleted 判断记录是否已删除
+func (n DeletedAtUnix) IsDeleted() bool {
+	return n != 0
+}
+
+// IsActive 判断记录是否未删除
+func (n DeletedAtUnix) IsActive() bool {
+	return n == 0
+}
+
+// String 返回 "deleted" 或 "active"
+func (n DeletedAtUnix) String() string {
+	return stateString(n != 0)
+}
+
+// 实现 sql.Scanner 接口，NULL 视为未删除
+func (n *DeletedAtUnix) Scan(value interface{}) error {
+	return unixSecond.scan((*int64)(n), value)
+}
+
+func (DeletedAtUnix) QueryClauses(f *schema.Field) []clause.Interface {
+	return unixSecond.queryClauses(f)
+}
+
+func (DeletedAtUnix) UpdateClauses(f *schema.Field) []clause.Interface {
+	return unixSecond.updateClauses(f)
+}
+
+func (DeletedAtUnix) DeleteClauses(f *schema.Field) []clause.Interface {
+	return unixSecond.deleteClauses(f)
+}
+
+// DeletedAtMilli 以 unix 毫秒记录删除时间，0 表示未删除
+type DeletedAtMilli int64
+
+// 实现 driver.Valuer 接口
+func (n DeletedAtMilli) Value() (driver.Value, error) {
+	return unixMilli.value(int64(n))
+}
+
+// IsDeleted 判断记录是否已删除
+func (n DeletedAtMilli) IsDeleted() bool {
+	return n != 0
+}
+
+// IsActive 判断记录是否未删除
+func (n DeletedAtMilli) IsActive() bool {
+	return n == 0
+}
+
+// String 返回 "deleted" 或 "active"
+func (n DeletedAtMilli) String() string {
+	return stateString(n != 0)
+}
+
+// 实现 sql.Scanner 接口，NULL 视为未删除
+func (n *DeletedAtMilli) Scan(value interface{}) error {
+	return unixMilli.scan((*int64)(n), value)
+}
+
+func (DeletedAtMilli) QueryClauses(f *schema.Field) []clause.Interface {
+	return unixMilli.queryClauses(f)
+}
+
+func (DeletedAtMilli) UpdateClauses(f *schema.Field) []clause.Interface {
+	return unixMilli.updateClauses(f)
+}
+
+func (DeletedAtMilli) DeleteClauses(f *schema.Field) []clause.Interface {
+	return unixMilli.deleteClauses(f)
+}
+
+// DeletedAtNano 以 unix 纳秒记录删除时间，0 表示未删除
+type DeletedAtNano int64
+
+// 实现 driver.Valuer 接口
+func (n DeletedAtNano) Value() (driver.Value, error) {
+	return unixNano.value(int64(n))
+}
+
+// IsDeleted 判断记录是否已删除
+func (n DeletedAtNano) IsDeleted() bool {
+	return n != 0
+}
+
+// IsActive 判断记录是否未删除
+func (n DeletedAtNano) IsActive() bool {
+	return n == 0
+}
+
+// String 返回 "deleted" 或 "active"
+func (n DeletedAtNano) String() string {
+	return stateString(n != 0)
+}
+
+// 实现 sql.Scanner 接口，NULL 视为未删除
+func (n *DeletedAtNano) Scan(value interface{}) error {
+	return unixNano.scan((*int64)(n), value)
+}
+
+func (DeletedAtNano) QueryClauses(f *schema.Field) []clause.Interface {
+	return unixNano.queryClauses(f)
+}
+
+func (DeletedAtNano) UpdateClauses(f *schema.Field) []clause.Interface {
+	return unixNano.updateClauses(f)
+}
+
+func (DeletedAtNano) DeleteClauses(f *schema.Field) []clause.Interface {
+	return unixNano.deleteClauses(f)
+}
+
+func scanUnix(value interface{}) (int64, error) {
+	var v sql.NullInt64
+	if err := v.Scan(value); err != nil {
+		return 0, err
+	}
+	return v.Int64, nil
+}
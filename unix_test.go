@@ -0,0 +1,121 @@
+package soft_delete
+
+import (
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type UnixUser struct {
+	ID        uint
+	Name      string
+	DeletedAt DeletedAtUnix
+}
+
+func pinNow(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NowFunc: func() time.Time { return testNow }})
+}
+
+func TestDeletedAtUnix(t *testing.T) {
+	db := pinNow(openDB(t, &UnixUser{}))
+	user := UnixUser{Name: "a"}
+	db.Create(&user)
+	db.Create(&UnixUser{Name: "b"})
+
+	sql := sqlOf(t, db.Session(&gorm.Session{DryRun: true}).Find(&[]UnixUser{}))
+	assertContains(t, sql, "`unix_users`.`deleted_at` = ?")
+
+	if err := db.Delete(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	if int64(user.DeletedAt) != testNow.Unix() {
+		t.Errorf("in-memory deleted_at = %d, want %d", user.DeletedAt, testNow.Unix())
+	}
+
+	var got UnixUser
+	db.Unscoped().First(&got, user.ID)
+	if int64(got.DeletedAt) != testNow.Unix() || !got.DeletedAt.IsDeleted() {
+		t.Errorf("deleted_at = %d, want %d", got.DeletedAt, testNow.Unix())
+	}
+	var users []UnixUser
+	if db.Find(&users); len(users) != 1 || users[0].Name != "b" {
+		t.Errorf("users = %+v", users)
+	}
+}
+
+func TestDeletedAtUnixScanNull(t *testing.T) {
+	n := DeletedAtUnix(5)
+	if err := n.Scan(nil); err != nil || n != 0 {
+		t.Errorf("Scan(nil) = %d, %v", n, err)
+	}
+	if err := n.Scan("abc"); err == nil {
+		t.Error("Scan(\"abc\") should fail")
+	}
+}
+
+type MilliUser struct {
+	ID        uint
+	DeletedAt DeletedAtMilli `gorm:"softDelete:milli,DeletedAtField:RemovedAt"`
+	RemovedAt int64
+}
+
+type NanoUser struct {
+	ID        uint
+	DeletedAt DeletedAtNano `gorm:"softDelete:nano,DeletedAtField:RemovedAt"`
+	RemovedAt int64
+}
+
+// 写入的值与类型的精度一致，DeletedAtField 为整数列时使用相同的精度
+func TestDeletedAtPrecision(t *testing.T) {
+	db := pinNow(openDB(t, &UnixUser{}, &MilliUser{}, &NanoUser{}))
+
+	unix := UnixUser{}
+	milli := MilliUser{}
+	nano := NanoUser{}
+	for _, value := range []interface{}{&unix, &milli, &nano} {
+		if err := db.Create(value).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Delete(value).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gotUnix UnixUser
+	var gotMilli MilliUser
+	var gotNano NanoUser
+	db.Unscoped().First(&gotUnix, unix.ID)
+	db.Unscoped().First(&gotMilli, milli.ID)
+	db.Unscoped().First(&gotNano, nano.ID)
+
+	if int64(gotUnix.DeletedAt) != testNow.Unix() {
+		t.Errorf("unix = %d, want %d", gotUnix.DeletedAt, testNow.Unix())
+	}
+	if int64(gotMilli.DeletedAt) != testNow.UnixMilli() || gotMilli.RemovedAt != testNow.UnixMilli() {
+		t.Errorf("milli = %d/%d, want %d", gotMilli.DeletedAt, gotMilli.RemovedAt, testNow.UnixMilli())
+	}
+	if int64(gotNano.DeletedAt) != testNow.UnixNano() || gotNano.RemovedAt != testNow.UnixNano() {
+		t.Errorf("nano = %d/%d, want %d", gotNano.DeletedAt, gotNano.RemovedAt, testNow.UnixNano())
+	}
+
+	var count int64
+	db.Model(&MilliUser{}).Count(&count)
+	if count != 0 {
+		t.Errorf("active milli users = %d, want 0", count)
+	}
+}
+
+func TestDeletedAtMilliNanoScan(t *testing.T) {
+	var milli DeletedAtMilli
+	var nano DeletedAtNano
+	if err := milli.Scan(int64(1690891200000)); err != nil || milli != 1690891200000 || !milli.IsDeleted() {
+		t.Errorf("milli Scan = %d, %v", milli, err)
+	}
+	if err := nano.Scan(nil); err != nil || nano != 0 || !nano.IsActive() {
+		t.Errorf("nano Scan(nil) = %d, %v", nano, err)
+	}
+	if v, _ := DeletedAtNano(7).Value(); v != int64(7) {
+		t.Errorf("nano Value() = %#v", v)
+	}
+}
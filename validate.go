@@ -0,0 +1,176 @@
+package soft_delete
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// Validate 检查模型的软删除配置，返回列出全部问题的错误，没有问题时返回 nil。检查标签中的伴随字段是否存在、
+// 标记列和伴随字段的列是否在表中、列的类型是否与标记的取值一致，以及软删除字段是否因为所在的结构体没有嵌入而不会保存。
+// 通常在 AutoMigrate 之后调用
+//
+//	if err := soft_delete.Validate(db, &User{}, &Order{}); err != nil {
+//		log.Fatal(err)
+//	}
+func Validate(db *gorm.DB, models ...interface{}) error {
+	var errs []error
+	for _, model := range models {
+		errs = append(errs, validateModel(db, model)...)
+	}
+	return errors.Join(errs...)
+}
+
+func validateModel(db *gorm.DB, model interface{}) []error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return []error{err}
+	}
+	s := stmt.Schema
+
+	errs := unpersistedFields(s)
+	sd, ok := lookUpDeleteClause(s)
+	if !ok {
+		if len(errs) == 0 {
+			errs = append(errs, fmt.Errorf("soft_delete: %s: %w", s.Name, ErrMissingSoftDeleteField))
+		}
+		return errs
+	}
+	errs = append(errs, sd.fieldErrors()...)
+
+	columnTypes, err := db.Migrator().ColumnTypes(model)
+	if err != nil {
+		return append(errs, err)
+	}
+	types := make(map[string]string, len(columnTypes))
+	for _, columnType := range columnTypes {
+		types[columnType.Name()] = strings.ToLower(columnType.DatabaseTypeName())
+	}
+
+	// Register 注册的标记列不在结构体中，也要检查
+	fields := append([]*schema.Field{sd.Field}, sd.companionFields()...)
+	for _, field := range []*schema.Field{sd.ValidFromField, sd.VersionField} {
+		if field != nil {
+			fields = append(fields, field)
+		}
+	}
+	for _, field := range fields {
+		if _, ok := types[field.DBName]; !ok {
+			errs = append(errs, fmt.Errorf("soft_delete: column %q of %s.%s not found in table %s", field.DBName, s.Name, field.Name, s.Table))
+		}
+	}
+	if dataType, ok := types[sd.Field.DBName]; ok && !compatibleColumn(sd, dataType) {
+		errs = append(errs, fmt.Errorf("soft_delete: column %q of %s.%s has type %s, incompatible with %s values", sd.Field.DBName, s.Name, sd.Field.Name, dataType, sd.representation()))
+	}
+	return errs
+}
+
+// fieldErrors 返回标签中声明但在模型中找不到的伴随字段
+func (sd SoftDeleteDeleteClause) fieldErrors() []error {
+	var errs []error
+	missing := func(tag, name string) {
+		errs = append(errs, fmt.Errorf("soft_delete: %s %q of %s not found in schema %s", tag, name, sd.Field.Name, sd.Field.Schema.Name))
+	}
+	if sd.DeleteAtField == nil && sd.DeleteAtFieldName != "" {
+		missing("DeletedAtField", sd.DeleteAtFieldName)
+	}
+	if sd.DeleteByField == nil && sd.DeleteByFieldName != "" {
+		missing("DeletedByField", sd.DeleteByFieldName)
+	}
+	if sd.DeleteReasonField == nil && sd.DeleteReasonFieldName != "" {
+		missing("DeletedReasonField", sd.DeleteReasonFieldName)
+	}
+	if err := sd.checkIntervalFields(); err != nil {
+		errs = append(errs, err)
+	}
+	if sd.LegacyTimestampField == nil && sd.LegacyTimestampFieldName != "" {
+		missing("LegacyTimestampField", sd.LegacyTimestampFieldName)
+	}
+	if sd.VersionField == nil && sd.VersionFieldName != "" {
+		missing("VersionField", sd.VersionFieldName)
+	}
+	if err := bitFieldError(sd.Field); err != nil {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// representation 返回标记列中存储的值的类别：bool、integer、string 或 time
+func (sd SoftDeleteDeleteClause) representation() string {
+	switch {
+	case sd.Interval || sd.Expires:
+		return "time"
+	case sd.Token || !sd.Flag:
+		return "integer"
+	}
+	if _, ok := flagValuesOf(sd.Field).deleted.(string); ok {
+		return "string"
+	}
+	return "bool"
+}
+
+// compatibleColumn 按数据库返回的类型名判断列能否保存标记的值，无法识别的类型视为兼容
+func compatibleColumn(sd SoftDeleteDeleteClause, dataType string) bool {
+	has := func(names ...string) bool {
+		for _, name := range names {
+			if strings.Contains(dataType, name) {
+				return true
+			}
+		}
+		return false
+	}
+	numeric := has("int", "bool", "bit", "numeric", "decimal", "number", "serial")
+	switch sd.representation() {
+	case "time":
+		return has("time", "date")
+	case "integer":
+		return numeric || has("char", "text")
+	case "string":
+		return has("char", "text", "string", "enum")
+	default:
+		return numeric
+	}
+}
+
+// unpersistedFields 查找没有被 gorm 解析的软删除字段，例如所在的结构体作为普通字段而不是嵌入，
+// 或者结构体标记了 gorm:"-"，这样的字段既不会过滤也不会保存
+func unpersistedFields(s *schema.Schema) []error {
+	var errs []error
+	var walk func(t reflect.Type, path string, depth int)
+	walk = func(t reflect.Type, path string, depth int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			if !sf.IsExported() {
+				continue
+			}
+			ft := sf.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			name := path + sf.Name
+			if depth == 0 && s.Relationships.Relations[sf.Name] != nil {
+				continue
+			}
+			if ft.Implements(deleteClausesType) || reflect.PtrTo(ft).Implements(deleteClausesType) {
+				if field := s.LookUpField(sf.Name); depth > 0 && (field == nil || field.DBName == "" || field.StructField.Type != sf.Type) {
+					errs = append(errs, fmt.Errorf("soft_delete: %s.%s is not persisted, embed its struct or tag it with gorm:\"embedded\"", s.Name, name))
+				}
+				continue
+			}
+			if field := s.LookUpField(sf.Name); field != nil && field.DBName != "" && field.StructField.Type == sf.Type {
+				continue
+			}
+			if ft.Kind() == reflect.Struct && depth < 3 {
+				walk(ft, name+".", depth+1)
+			}
+		}
+	}
+	walk(s.ModelType, "", 0)
+	return errs
+}
+
+var deleteClausesType = reflect.TypeOf((*schema.DeleteClausesInterface)(nil)).Elem()
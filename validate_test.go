@@ -0,0 +1,72 @@
+package soft_delete
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// MissingColumnUser 的表在迁移之后才加上标记字段
+type MissingColumnUser struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+type BadTagUser struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag,DeletedAtField:RemovedAt,DeletedByField:RemovedBy"`
+}
+
+type FlagHolder struct {
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+// UnembeddedUser 的标记字段在忽略的结构体字段中，不会被保存
+type UnembeddedUser struct {
+	ID   uint
+	Info FlagHolder `gorm:"-"`
+}
+
+type WrongTypeUser struct {
+	ID      uint
+	Deleted DeletedAt `gorm:"softDelete:flag"`
+}
+
+func TestValidate(t *testing.T) {
+	db := openDB(t, &User{}, &RestoreUser{}, &BadTagUser{}, &UnembeddedUser{})
+	db.Exec("CREATE TABLE missing_column_users (id integer PRIMARY KEY)")
+	db.Exec("CREATE TABLE wrong_type_users (id integer PRIMARY KEY, deleted datetime)")
+
+	if err := Validate(db, &User{}, &RestoreUser{}); err != nil {
+		t.Errorf("Validate valid models: %v", err)
+	}
+
+	tests := []struct {
+		model interface{}
+		want  []string
+	}{
+		{&MissingColumnUser{}, []string{`column "deleted" of MissingColumnUser.Deleted not found`}},
+		{&BadTagUser{}, []string{`DeletedAtField "RemovedAt"`, `DeletedByField "RemovedBy"`}},
+		{&UnembeddedUser{}, []string{"UnembeddedUser.Info.Deleted is not persisted"}},
+		{&WrongTypeUser{}, []string{"has type datetime, incompatible with bool values"}},
+		{&Plain{}, []string{ErrMissingSoftDeleteField.Error()}},
+	}
+	for _, tt := range tests {
+		err := Validate(db, tt.model)
+		if err == nil {
+			t.Errorf("Validate(%T) should fail", tt.model)
+			continue
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Validate(%T) = %v, want %q", tt.model, err, want)
+			}
+		}
+	}
+
+	// 多个模型的问题全部列出
+	err := Validate(db, &User{}, &BadTagUser{}, &Plain{})
+	if !errors.Is(err, ErrMissingSoftDeleteField) || !strings.Contains(err.Error(), "RemovedAt") {
+		t.Errorf("Validate multiple = %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package soft_delete
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"gorm.io/gorm/schema"
+)
+
+// flagValues 为标记字段未删除和已删除时在数据库中的值，解析模型时确定，之后只读
+type flagValues struct {
+	active  interface{}
+	deleted interface{}
+	// byDeleted 以不等于已删除的值过滤，用于除未删除、已删除外还有其他状态的列
+	byDeleted bool
+	// expires 为 ExpiresAt，以过期时间与当前时间比较过滤
+	expires bool
+	// nullable 为指针字段或 NullDeletedAt，列可以为 NULL：默认视为未删除，nullDeleted 时视为已删除
+	nullable    bool
+	nullDeleted bool
+	// mask 为 BitFlag 表示删除的位，以按位运算过滤和写入，active、deleted 为空
+	mask int64
+}
+
+// fieldFlagValues 按字段保存 flagValues，查询时不再读取 FlagDeleted、FlagActived 等全局变量
+var fieldFlagValues sync.Map
+
+// bindFlagValues 在解析模型时确定字段的取值，defaults 为类型默认的取值，
+// 也可以通过标签为单个字段指定，例如 1 为未删除、2 为已删除的旧表：
+//
+//	Deleted soft_delete.DeletedAt `gorm:"softDelete:flag,ActiveValue:1,DeletedValue:2"`
+//
+// 指针字段和 NullDeletedAt 的列可以为 NULL，NULL 视为未删除；声明 NullDeleted 时视为已删除：
+//
+//	Deleted *soft_delete.DeletedAt `gorm:"softDelete:flag,NullDeleted"`
+//
+// fromDeleted 将是否已删除转换为字段类型的值，用于自定义取值时的扫描，为 nil 时由类型自行处理读写
+func bindFlagValues(f *schema.Field, defaults flagValues, fromDeleted func(deleted bool) interface{}) {
+	settings := parseSettings(f)
+	values := defaults
+
+	activeSetting, customActive := settings["ACTIVEVALUE"]
+	if customActive {
+		values.active = parseFlagValue(activeSetting)
+	}
+	deletedSetting, customDeleted := settings["DELETEDVALUE"]
+	if customDeleted {
+		values.deleted = parseFlagValue(deletedSetting)
+	}
+	if f.FieldType.Kind() == reflect.Ptr {
+		values.nullable = true
+	}
+	if values.nullable {
+		_, values.nullDeleted = settings["NULLDELETED"]
+	}
+
+	if _, loaded := fieldFlagValues.LoadOrStore(f, values); loaded {
+		return
+	}
+	bindColumnDefault(f, values)
+	if (customActive || customDeleted) && fromDeleted != nil {
+		bindFieldValues(f, values, fromDeleted)
+	}
+}
+
+// deletedAtValues 为 DeletedAt 默认的取值，取解析模型时的 FlagActived、FlagDeleted
+func deletedAtValues() flagValues {
+	return flagValues{active: flagValue(FlagActived), deleted: flagValue(FlagDeleted)}
+}
+
+func flagValuesOf(f *schema.Field) flagValues {
+	if values, ok := fieldFlagValues.Load(f); ok {
+		return values.(flagValues)
+	}
+	return deletedAtValues()
+}
+
+func parseFlagValue(s string) interface{} {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if b, err := strconv.ParseBool(s); err == nil {
+		return b
+	}
+	return s
+}
+
+// rawValuePool 使扫描时得到数据库中的原始值，由 bindFieldValues 替换后的 Set 转换
+var rawValuePool = &sync.Pool{
+	New: func() interface{} {
+		return new(interface{})
+	},
+}
+
+// bindFieldValues 使自定义取值的字段在写入时使用 flagValues，扫描时按已删除的值转换为字段类型。
+// DeletedAt 等类型的 Value、Scan 不知道所属字段，只能处理默认的取值
+func bindFieldValues(f *schema.Field, values flagValues, fromDeleted func(deleted bool) interface{}) {
+	valueOf := f.ValueOf
+	f.ValueOf = func(ctx context.Context, v reflect.Value) (interface{}, bool) {
+		value, zero := valueOf(ctx, v)
+		// 指针字段为 nil 时写入 NULL，由 nullable 的规则决定是否已删除
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			return nil, zero
+		}
+		var deleted bool
+		switch flag := value.(type) {
+		case NullDeletedAt:
+			if !flag.Valid {
+				return nil, zero
+			}
+			deleted = bool(flag.Flag)
+		case DeletedAt:
+			deleted = bool(flag)
+		case interface{ IsDeleted() bool }:
+			deleted = flag.IsDeleted()
+		default:
+			return value, zero
+		}
+
+		if deleted {
+			return values.deleted, zero
+		}
+		return values.active, zero
+	}
+
+	set := f.Set
+	f.Set = func(ctx context.Context, v reflect.Value, value interface{}) error {
+		if raw, ok := value.(*interface{}); ok {
+			value = *raw
+		}
+		switch value.(type) {
+		case DeletedAt, *DeletedAt, NullDeletedAt, bool, *bool, interface{ IsDeleted() bool }:
+			return set(ctx, v, value)
+		}
+		return set(ctx, v, fromDeleted(sameValue(value, values.deleted)))
+	}
+
+	f.NewValuePool = rawValuePool
+}
+
+// sameValue 比较数据库返回的值与配置的值，驱动可能以整数、字节或字符串返回
+func sameValue(value, expected interface{}) bool {
+	if value == nil || expected == nil {
+		return value == expected
+	}
+	if b, ok := value.([]byte); ok {
+		value = string(b)
+	}
+	return fmt.Sprint(value) == fmt.Sprint(expected)
+}
@@ -0,0 +1,96 @@
+package soft_delete
+
+import (
+	"sync"
+	"testing"
+)
+
+type LegacyUser struct {
+	ID      uint
+	Name    string
+	Deleted DeletedAt `gorm:"softDelete:flag,ActiveValue:1,DeletedValue:2"`
+}
+
+type LegacyNullUser struct {
+	ID      uint
+	Name    string
+	Deleted *DeletedAt `gorm:"softDelete:flag,ActiveValue:1,DeletedValue:2"`
+}
+
+// 以标签指定的取值写入、过滤，扫描时转换回字段类型
+func TestCustomFlagValues(t *testing.T) {
+	db := openDB(t, &LegacyUser{})
+	db.Create(&[]LegacyUser{{Name: "a"}, {Name: "b"}})
+	db.Delete(&LegacyUser{ID: 2})
+
+	var raw []int64
+	db.Table("legacy_users").Order("id").Pluck("deleted", &raw)
+	if len(raw) != 2 || raw[0] != 1 || raw[1] != 2 {
+		t.Fatalf("stored values = %v, want [1 2]", raw)
+	}
+
+	var users []LegacyUser
+	db.Find(&users)
+	if len(users) != 1 || users[0].Name != "a" || users[0].Deleted.IsDeleted() {
+		t.Errorf("active = %+v", users)
+	}
+	var deleted LegacyUser
+	if err := db.Scopes(OnlyDeleted).First(&deleted).Error; err != nil || !deleted.Deleted.IsDeleted() {
+		t.Errorf("deleted = %+v, %v", deleted, err)
+	}
+}
+
+// 自定义取值的指针字段为 nil 时创建记录不会 panic，写入 NULL 视为未删除
+func TestCustomFlagValuesNilPointer(t *testing.T) {
+	db := openDB(t, &LegacyNullUser{})
+	if err := db.Create(&LegacyNullUser{Name: "a"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	active := DeletedAt(false)
+	if err := db.Create(&LegacyNullUser{Name: "b", Deleted: &active}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var users []LegacyNullUser
+	db.Order("id").Find(&users)
+	if len(users) != 2 {
+		t.Fatalf("active = %+v", users)
+	}
+	if err := db.Delete(&users[0]).Error; err != nil {
+		t.Fatal(err)
+	}
+	var n int64
+	db.Model(&LegacyNullUser{}).Count(&n)
+	if n != 1 {
+		t.Errorf("active after delete = %d, want 1", n)
+	}
+}
+
+// 取值不同的两个模型并发查询，使用 -race 运行
+func TestCustomFlagValuesConcurrent(t *testing.T) {
+	db := openDB(t, &User{}, &LegacyUser{})
+	db.Create(&[]User{{Name: "a"}, {Name: "b"}})
+	db.Create(&[]LegacyUser{{Name: "a"}, {Name: "b"}})
+	db.Delete(&User{ID: 1})
+	db.Delete(&LegacyUser{ID: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			var users []User
+			if err := db.Find(&users).Error; err != nil || len(users) != 1 || users[0].ID != 2 {
+				t.Errorf("users = %+v, %v", users, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			var users []LegacyUser
+			if err := db.Find(&users).Error; err != nil || len(users) != 1 || users[0].ID != 1 {
+				t.Errorf("legacy users = %+v, %v", users, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,109 @@
+package soft_delete
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrStaleDelete 记录在读取之后被其他人修改或删除，VersionedDelete 没有删除任何记录时返回
+var ErrStaleDelete = errors.New("soft_delete: record was modified or deleted since it was loaded")
+
+// VersionedDelete 软删除单条记录，条件中带有读取时的版本号，删除成功时版本号加一，没有删除任何记录时返回 ErrStaleDelete。
+// 版本字段由标签中的 VersionField 指定，没有指定时使用 gorm.io/plugin/optimisticlock 的 Version 字段：
+//
+//	Version int
+//	Deleted soft_delete.DeletedAt `gorm:"softDelete:flag,VersionField:Version"`
+//
+// 普通的 Delete 在有版本字段时同样带上版本号，只是不返回错误
+func VersionedDelete(db *gorm.DB, value interface{}, conds ...interface{}) (int64, error) {
+	s, sd, err := parseDeleteClause(db, value)
+	if err != nil {
+		return 0, err
+	}
+	if sd.VersionField == nil {
+		return 0, fmt.Errorf("soft_delete: %s has no version field", s.Name)
+	}
+
+	// 删除子句在执行前已将内存中的版本号加一，没有删除时改回读取时的值
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	var loaded interface{}
+	if rv.Kind() == reflect.Struct && rv.CanAddr() {
+		loaded, _ = sd.VersionField.ValueOf(db.Statement.Context, rv)
+	}
+
+	tx := db.Delete(value, conds...)
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		if loaded != nil {
+			if err := sd.VersionField.Set(db.Statement.Context, rv, loaded); err != nil {
+				return 0, err
+			}
+		}
+		return 0, ErrStaleDelete
+	}
+	return tx.RowsAffected, nil
+}
+
+// withVersion 按标签中的 VersionField 关联版本字段，没有指定时查找 optimisticlock.Version 类型的字段
+func (sd SoftDeleteDeleteClause) withVersion(settings map[string]string) SoftDeleteDeleteClause {
+	if name := settings["VERSIONFIELD"]; name != "" {
+		sd.VersionFieldName = name
+		sd.VersionField = sd.Field.Schema.LookUpField(name)
+		return sd
+	}
+	for _, field := range sd.Field.Schema.Fields {
+		if fieldType := reflect.Indirect(reflect.New(field.FieldType)).Type(); fieldType.Name() == "Version" &&
+			strings.HasSuffix(fieldType.PkgPath(), "/optimisticlock") {
+			sd.VersionField = field
+			break
+		}
+	}
+	return sd
+}
+
+// versionCondition 在删除单条记录时返回版本号的条件和加一的赋值，并将内存中的版本号加一。
+// Dest 不是结构体或版本号为零值时不检查版本
+func (sd SoftDeleteDeleteClause) versionCondition(stmt *gorm.Statement) (clause.Expression, *clause.Assignment) {
+	field := sd.VersionField
+	if field == nil || stmt.ReflectValue.Kind() != reflect.Struct {
+		return nil, nil
+	}
+	current, zero := field.ValueOf(stmt.Context, stmt.ReflectValue)
+	if zero {
+		return nil, nil
+	}
+
+	column := clause.Column{Name: field.DBName}
+	cond := clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: current}
+	assignment := &clause.Assignment{Column: column, Value: gorm.Expr("? + 1", column)}
+	if n, ok := versionNumber(current); ok {
+		setColumn(stmt, field, n+1)
+	}
+	return cond, assignment
+}
+
+// versionNumber 返回版本号的整数值，兼容整数字段和实现了 driver.Valuer 的版本类型
+func versionNumber(value interface{}) (int64, bool) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return 0, false
+		}
+		value = v
+	}
+	switch rv := reflect.ValueOf(value); rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), true
+	}
+	return 0, false
+}
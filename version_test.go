@@ -0,0 +1,63 @@
+package soft_delete
+
+import (
+	"errors"
+	"testing"
+)
+
+type Document struct {
+	ID      uint
+	Title   string
+	Version int
+	Deleted DeletedAt `gorm:"softDelete:flag,VersionField:Version"`
+}
+
+func TestVersionedDelete(t *testing.T) {
+	db := openDB(t, &Document{})
+	doc := Document{Title: "a", Version: 1}
+	db.Create(&doc)
+
+	n, err := VersionedDelete(db, &doc)
+	if err != nil || n != 1 {
+		t.Fatalf("VersionedDelete = %d, %v", n, err)
+	}
+	if doc.Version != 2 {
+		t.Errorf("Version = %d in memory, want 2", doc.Version)
+	}
+	var stored Document
+	db.Unscoped().First(&stored, doc.ID)
+	if stored.Version != 2 || stored.Deleted.IsActive() {
+		t.Errorf("stored = %+v", stored)
+	}
+}
+
+func TestVersionedDeleteStale(t *testing.T) {
+	db := openDB(t, &Document{})
+	doc := Document{Title: "a", Version: 1}
+	db.Create(&doc)
+
+	// 其他人在读取之后修改了记录
+	db.Model(&Document{}).Where("id = ?", doc.ID).Update("version", 2)
+
+	if _, err := VersionedDelete(db, &doc); !errors.Is(err, ErrStaleDelete) {
+		t.Fatalf("err = %v, want ErrStaleDelete", err)
+	}
+	if doc.Version != 1 {
+		t.Errorf("Version = %d in memory, want the loaded 1", doc.Version)
+	}
+	var count int64
+	db.Model(&Document{}).Count(&count)
+	if count != 1 {
+		t.Error("stale delete must not delete the record")
+	}
+
+	sql := sqlOf(t, dryRunDB(t, "sqlite").Delete(&Document{ID: 1, Version: 3}))
+	assertContains(t, sql, "`version`=`version` + 1", "`documents`.`version` = ?")
+}
+
+func TestVersionedDeleteWithoutVersionField(t *testing.T) {
+	db := openDB(t, &User{})
+	if _, err := VersionedDelete(db, &User{ID: 1}); err == nil {
+		t.Error("VersionedDelete without a version field should fail")
+	}
+}